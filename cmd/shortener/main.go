@@ -16,15 +16,23 @@ import (
 	"golang.org/x/crypto/acme/autocert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
+// clicksFlushTimeout is a max time of saving buffered clicks during shutdown.
+const clicksFlushTimeout = 10 * time.Second
+
 var (
 	buildVersion string
 	buildDate    string
@@ -40,16 +48,59 @@ func naOrValue(v string) string {
 	}
 }
 
+// buildJWTHelper builds a JWT keyset from conf.
+// A PEM signing key (if it is set) signs tokens, all other keys and secrets only verify them.
+func buildJWTHelper(conf config.Config) (*secure.JWTHelper, error) {
+	var signingKey secure.JWTKey
+	var verificationKeys []secure.JWTKey
+	if conf.JWTSigningKey != "" {
+		key, err := secure.LoadPEMKey(conf.JWTSigningKey)
+		if err != nil {
+			return nil, err
+		}
+		signingKey = key
+		if conf.JWTSecret != "" {
+			verificationKeys = append(verificationKeys, secure.NewHMACKey(conf.JWTSecret))
+		}
+	} else {
+		signingKey = secure.NewHMACKey(conf.JWTSecret)
+	}
+	for _, secret := range splitList(conf.JWTOldSecrets) {
+		verificationKeys = append(verificationKeys, secure.NewHMACKey(secret))
+	}
+	for _, path := range splitList(conf.JWTVerificationKeys) {
+		key, err := secure.LoadPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+	return secure.NewJWTHelperWithKeys(signingKey, verificationKeys, conf.JWTTimeoutHours)
+}
+
+// splitList splits a comma separated list and drops empty values.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // gracefulShutdown listens for os signals syscall.SIGTERM, syscall.SIGINT and syscall.SIGQUIT.
-// Calls HTTPServer.Shutdown and gRPCServer.GracefulStop if signal received.
+// Sets NOT_SERVING gRPC health status and calls HTTPServer.Shutdown and gRPCServer.GracefulStop if signal received.
 // This func have to be called in different goroutine, because it has an endless loop.
-func gracefulShutdown(HTTPServer *http.Server, gRPCServer *grpc.Server, log zap.SugaredLogger, wg *sync.WaitGroup) {
+func gracefulShutdown(HTTPServer *http.Server, gRPCServer *grpc.Server, healthServer *health.Server, log zap.SugaredLogger, wg *sync.WaitGroup) {
 	defer wg.Done()
 	shutDownCh := make(chan os.Signal, 1)
 	signal.Notify(shutDownCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
 	for v := range shutDownCh {
 		log.Infof("Received an os signal '%s', graceful shutting down...", v.String())
+		healthServer.Shutdown()
 		err := HTTPServer.Shutdown(context.Background())
 		if err != nil {
 			log.Error("failed to shutdown gracefully", zap.Error(err))
@@ -73,6 +124,12 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	//default UTM params are used by Redirector, so they are checked before start
+	_, err = logic.ParseUTMParams(conf.UTMParams)
+	if err != nil {
+		log.Fatalf("Error in UTM params: %v", err)
+	}
+
 	//storages set
 	var URLStore logic.URLStorageInterface
 	if conf.DBConnString != "" {
@@ -102,10 +159,27 @@ func main() {
 	sugar := zapLogger.Sugar()
 
 	//JWTHelper set
-	JWTHelper := secure.NewJWTHelper(conf.JWTSecret, conf.JWTTimeoutHours)
+	if conf.InsecureDev {
+		sugar.Warn("Insecure dev mode is on, never use it in production")
+	}
+	JWTHelper, err := buildJWTHelper(conf)
+	if err != nil {
+		sugar.Fatalf("Error loading JWT keys: %v", err)
+	}
+	sugar.Infof("JWTs are signed by a key `%s`", JWTHelper.SigningKID())
+
+	//Redirector set (it is shared by HTTP and gRPC servers)
+	clickPipeline := logic.NewClickPipeline(URLStore, conf.ClickBufferSize, conf.ClickBatchSize, conf.ClickFlushInterval, *sugar)
+	go clickPipeline.Run()
+	redirector := logic.NewRedirector(URLStore, clickPipeline, conf)
+
+	//Accounts, API keys and sessions set (they are shared by HTTP and gRPC servers too)
+	accounts := logic.NewAccounts(URLStore, conf)
+	apiKeys := logic.NewAPIKeys(URLStore)
+	sessions := logic.NewSessions(URLStore, JWTHelper, conf)
 
 	//HTTP server building
-	r, err := handlers.NewRouter(conf, URLStore, *sugar, JWTHelper)
+	r, err := handlers.NewRouter(conf, URLStore, *sugar, sessions, redirector, accounts, apiKeys)
 	if err != nil {
 		sugar.Fatalf("Error creating new router: %v", err)
 	}
@@ -154,20 +228,45 @@ func main() {
 		}()
 	}
 
+	//gRPC health status follows a storage state
+	healthServer := health.NewServer()
+	healthCtx, cancelHealth := context.WithCancel(context.Background())
+	defer cancelHealth()
+	go logic.RunStorageHealthCheck(healthCtx, URLStore, conf.HealthInterval, grpchandlers.HealthStatusSetter(healthServer), *sugar)
+
 	//run gRPC
-	gRPCServer, err := runGRPCServer(&conf, URLStore, *sugar, JWTHelper)
+	gRPCServer, err := runGRPCServer(&conf, URLStore, redirector, accounts, apiKeys, sessions, healthServer, *sugar)
 	if err != nil {
 		sugar.Fatalf("Error starting gRPC server: %v", err)
 	}
 
+	//run expired URLs (and ended sessions) reaper
+	reaperCtx, cancelReaper := context.WithCancel(context.Background())
+	defer cancelReaper()
+	if conf.ReaperInterval > 0 {
+		go logic.RunExpiredURLsReaper(reaperCtx, URLStore, conf.ReaperInterval, conf.ExpiredRetention, *sugar)
+	}
+
 	//graceful shutdown
 	wg.Add(1)
-	go gracefulShutdown(server, gRPCServer, *sugar, wg)
+	go gracefulShutdown(server, gRPCServer, healthServer, *sugar, wg)
 	wg.Wait()
+
+	//flush buffered clicks (servers are stopped, so there are no new clicks)
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), clicksFlushTimeout)
+	defer cancelFlush()
+	err = clickPipeline.Close(flushCtx)
+	if err != nil {
+		sugar.Errorf("failed to flush clicks: %v", err)
+	}
+	clickStats := clickPipeline.Stats()
+	sugar.Infof("clicks pipeline stopped, enqueued: %d, saved: %d, dropped: %d, failed: %d",
+		clickStats.Enqueued, clickStats.Saved, clickStats.Dropped, clickStats.Failed)
 }
 
-// runGRPCServer creates and runs a new gRPC server. Calls logger.Fatal if starting gRPC is not possible.
-func runGRPCServer(conf *config.Config, storage logic.URLStorageInterface, logger zap.SugaredLogger, jh *secure.JWTHelper) (*grpc.Server, error) {
+// runGRPCServer creates and runs a new gRPC server with a health service (and reflection if it is enabled).
+// Calls logger.Fatal if starting gRPC is not possible.
+func runGRPCServer(conf *config.Config, storage logic.URLStorageInterface, redirector *logic.Redirector, accounts *logic.Accounts, apiKeys *logic.APIKeys, sessions *logic.Sessions, healthServer *health.Server, logger zap.SugaredLogger) (*grpc.Server, error) {
 	listen, err := net.Listen("tcp", conf.GRPCAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen gRPC: %v", err)
@@ -182,6 +281,10 @@ func runGRPCServer(conf *config.Config, storage logic.URLStorageInterface, logge
 			return nil, fmt.Errorf("error parsing trusted subnet: %w", err)
 		}
 	}
+	proxies, err := logic.NewTrustedProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
 	//prepare gRPC server
 	grpcServer := &grpc.Server{}
@@ -197,7 +300,11 @@ func runGRPCServer(conf *config.Config, storage logic.URLStorageInterface, logge
 		grpcServer = grpc.NewServer(
 			grpc.ChainUnaryInterceptor(
 				interceptors.NewIPInterceptor(trustedSubnet),
-				interceptors.NewUnaryAuthInterceptor(jh),
+				interceptors.NewUnaryAuthInterceptor(sessions, apiKeys),
+			),
+			grpc.ChainStreamInterceptor(
+				interceptors.NewStreamIPInterceptor(trustedSubnet),
+				interceptors.NewStreamAuthInterceptor(sessions, apiKeys),
 			),
 			grpc.Creds(credentials.NewTLS(&tls.Config{
 				GetCertificate: manager.GetCertificate,
@@ -208,16 +315,29 @@ func runGRPCServer(conf *config.Config, storage logic.URLStorageInterface, logge
 		grpcServer = grpc.NewServer(
 			grpc.ChainUnaryInterceptor(
 				interceptors.NewIPInterceptor(trustedSubnet),
-				interceptors.NewUnaryAuthInterceptor(jh),
+				interceptors.NewUnaryAuthInterceptor(sessions, apiKeys),
+			),
+			grpc.ChainStreamInterceptor(
+				interceptors.NewStreamIPInterceptor(trustedSubnet),
+				interceptors.NewStreamAuthInterceptor(sessions, apiKeys),
 			),
 		)
 	}
 
 	proto.RegisterURLShortenerServiceServer(grpcServer, &grpchandlers.ShortenerServer{
-		Storage: storage,
-		Logger:  logger,
-		Conf:    conf,
+		Storage:    storage,
+		Redirector: redirector,
+		Accounts:   accounts,
+		APIKeys:    apiKeys,
+		Logger:     logger,
+		Conf:       conf,
+		Sessions:   sessions,
+		Proxies:    proxies,
 	})
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if conf.GRPCReflection {
+		reflection.Register(grpcServer)
+	}
 
 	//start gRPC server
 	logger.Info("Starting gRPC server...")
@@ -7,8 +7,12 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 )
 
 // Default configurations params.
@@ -21,36 +25,125 @@ const (
 	DefaultDBConnectionString = ""
 	DefaultEnableHTTPSFlag    = false
 	DefaultTrustedSubnet      = "127.0.0.1/24"
+	DefaultTrustedProxies     = ""
 	DefaultJWTTimeoutHours    = 5
+	DefaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	DefaultReaperInterval     = time.Hour
+	DefaultExpiredRetention   = 24 * time.Hour
+	DefaultPasswordAttempts   = 5
+	DefaultPasswordWindow     = 15 * time.Minute
+	DefaultDisableClickIPs    = false
+	DefaultClickBufferSize    = 10000
+	DefaultClickBatchSize     = 100
+	DefaultClickFlushInterval = time.Second
+	DefaultRedirectCode       = http.StatusTemporaryRedirect
+	DefaultPassQuery          = false
+	DefaultUTMParams          = ""
+	DefaultBulkChunkSize      = 500
+	DefaultHealthInterval     = 5 * time.Second
+	DefaultGRPCReflection     = false
+	DefaultInsecureDev        = false
 )
 
+// DefaultJWTSecret is a JWT secret used if no JWT keys are set and InsecureDev is true.
+// It is public, so it must never be used in production.
+const DefaultJWTSecret = "superSecret"
+
+// DefaultIPHashSalt is a salt of visitors IPs hashes used if IPHashSalt is not set and InsecureDev is true.
+// It is public, so it must never be used in production.
+const DefaultIPHashSalt = "superSalt"
+
 type confFileData struct {
-	ServerAddress   string `json:"server_address"`
-	GRPCAddress     string `json:"grpc_address"`
-	BaseURL         string `json:"base_url"`
-	FileStoragePath string `json:"file_storage_path"`
-	DatabaseDsn     string `json:"database_dsn"`
-	EnableHTTPS     bool   `json:"enable_https"`
-	LogLevel        string `json:"log_level"`
-	TrustedSubnet   string `json:"trusted_subnet"`
-	JWTSecret       string `json:"jwt_secret"`
-	JWTTimeoutHours int    `json:"jwt_timeout_hours"`
+	ServerAddress       string `json:"server_address"`
+	GRPCAddress         string `json:"grpc_address"`
+	BaseURL             string `json:"base_url"`
+	FileStoragePath     string `json:"file_storage_path"`
+	DatabaseDsn         string `json:"database_dsn"`
+	EnableHTTPS         bool   `json:"enable_https"`
+	LogLevel            string `json:"log_level"`
+	TrustedSubnet       string `json:"trusted_subnet"`
+	TrustedProxies      string `json:"trusted_proxies"`
+	JWTSecret           string `json:"jwt_secret"`
+	JWTOldSecrets       string `json:"jwt_old_secrets"`
+	JWTSigningKey       string `json:"jwt_signing_key"`
+	JWTVerificationKeys string `json:"jwt_verification_keys"`
+	IPHashSalt          string `json:"ip_hash_salt"`
+	JWTTimeoutHours     int    `json:"jwt_timeout_hours"`
+	RefreshTokenTTL     string `json:"refresh_token_ttl"`
+	ReaperInterval      string `json:"reaper_interval"`
+	ExpiredRetention    string `json:"expired_retention"`
+	PasswordAttempts    int    `json:"password_attempts"`
+	PasswordWindow      string `json:"password_window"`
+	DisableClickIPs     bool   `json:"disable_click_ips"`
+	ClickBufferSize     int    `json:"click_buffer_size"`
+	ClickBatchSize      int    `json:"click_batch_size"`
+	ClickFlushInterval  string `json:"click_flush_interval"`
+	RedirectCode        int    `json:"redirect_code"`
+	PassQuery           bool   `json:"pass_query"`
+	UTMParams           string `json:"utm_params"`
+	BulkChunkSize       int    `json:"bulk_chunk_size"`
+	HealthInterval      string `json:"health_interval"`
+	GRPCReflection      bool   `json:"grpc_reflection"`
+	InsecureDev         bool   `json:"insecure_dev"`
 }
 
 // Config is a struct with configuration params.
-// Attention - JWTSecret can be read ONLY from environment or configuration file.
+// Attention - JWTSecret and JWTOldSecrets can be read ONLY from environment or configuration file.
+// JWTSecret is an HS256 secret, JWTOldSecrets are comma separated secrets used before a rotation (they only verify tokens).
+// JWTSigningKey is a path to an Ed25519 or RSA private key in PEM format. If it is set, it signs tokens
+// and JWTSecret only verifies them. JWTVerificationKeys are comma separated paths to PEM keys used only for verification.
+// IPHashSalt is a salt of hashed visitors IPs in click analytics, it can be read ONLY from environment or configuration file.
+// It is required unless DisableClickIPs is set.
+// InsecureDev allows to start without JWT keys and IPHashSalt (with DefaultJWTSecret and DefaultIPHashSalt),
+// it is for development only.
+// RefreshTokenTTL is a lifetime of a session without activity (every refresh of a session prolongs it).
+// ReaperInterval is a period of expired URLs and ended sessions cleaning (0 disables cleaning),
+// ExpiredRetention is a time while expired URLs and ended sessions are kept before cleaning.
+// TrustedProxies are comma separated CIDRs of reverse proxies. An "X-Real-IP" header (or gRPC metadata) is used as a client IP
+// only if it is sent by one of them, IPs of connections are used otherwise (clients can put anything to this header).
+// PasswordAttempts is an amount of failed password attempts allowed for one URL (or one account login from one IP) during PasswordWindow.
+// DisableClickIPs disables saving of hashed visitors IPs in click analytics.
+// Clicks are buffered (ClickBufferSize clicks at most) and saved by batches of ClickBatchSize clicks
+// or every ClickFlushInterval.
+// RedirectCode is an HTTP status code of redirects for URLs without their own code.
+// PassQuery enables merging of redirect requests queries into original URLs (for URLs without their own setting).
+// UTMParams are default UTM params (a query string like "utm_source=shortener") added to original URLs on redirects.
+// BulkChunkSize is an amount of lines of a bulk shortening request saved to a storage at once.
+// HealthInterval is a period of storage checks for a gRPC health service.
+// GRPCReflection enables gRPC server reflection (for tools like grpcurl).
 type Config struct {
-	BaseAddress     string
-	ServerAddress   string
-	GRPCAddress     string
-	LogLevel        string
-	FileStoragePath string
-	DBConnString    string
-	EnableHTTPS     bool
-	ConfigFileName  string
-	TrustedSubnet   string
-	JWTSecret       string
-	JWTTimeoutHours int
+	BaseAddress         string
+	ServerAddress       string
+	GRPCAddress         string
+	LogLevel            string
+	FileStoragePath     string
+	DBConnString        string
+	EnableHTTPS         bool
+	ConfigFileName      string
+	TrustedSubnet       string
+	TrustedProxies      string
+	JWTSecret           string
+	JWTOldSecrets       string
+	JWTSigningKey       string
+	JWTVerificationKeys string
+	IPHashSalt          string
+	JWTTimeoutHours     int
+	RefreshTokenTTL     time.Duration
+	ReaperInterval      time.Duration
+	ExpiredRetention    time.Duration
+	PasswordAttempts    int
+	PasswordWindow      time.Duration
+	DisableClickIPs     bool
+	ClickBufferSize     int
+	ClickBatchSize      int
+	ClickFlushInterval  time.Duration
+	RedirectCode        int
+	PassQuery           bool
+	UTMParams           string
+	BulkChunkSize       int
+	HealthInterval      time.Duration
+	GRPCReflection      bool
+	InsecureDev         bool
 }
 
 // Configure reads configuration params from command line args, environmental variables and DefaultConstParams.
@@ -66,7 +159,26 @@ func (c *Config) Configure() error {
 	flag.BoolVar(&(c.EnableHTTPS), "s", DefaultEnableHTTPSFlag, "This flag enables HTTPS support")
 	flag.StringVar(&(c.ConfigFileName), "c", "", "Config file name")
 	flag.StringVar(&(c.TrustedSubnet), "t", DefaultTrustedSubnet, "Trusted subnet")
+	flag.StringVar(&(c.TrustedProxies), "trusted-proxies", DefaultTrustedProxies, "Comma separated CIDRs of reverse proxies witch X-Real-IP header is trusted")
 	flag.IntVar(&(c.JWTTimeoutHours), "j", DefaultJWTTimeoutHours, "JWT timeout hours")
+	flag.DurationVar(&(c.RefreshTokenTTL), "refresh-token-ttl", DefaultRefreshTokenTTL, "Lifetime of a session without activity")
+	flag.StringVar(&(c.JWTSigningKey), "jwt-signing-key", "", "Path to an Ed25519 or RSA private key in PEM format used to sign JWTs")
+	flag.StringVar(&(c.JWTVerificationKeys), "jwt-verification-keys", "", "Comma separated paths to PEM keys used only to verify JWTs (old keys after a rotation)")
+	flag.DurationVar(&(c.ReaperInterval), "reaper-interval", DefaultReaperInterval, "Expired URLs and ended sessions cleaning period, 0 disables cleaning")
+	flag.DurationVar(&(c.ExpiredRetention), "expired-retention", DefaultExpiredRetention, "How long expired URLs and ended sessions are kept before cleaning")
+	flag.IntVar(&(c.PasswordAttempts), "password-attempts", DefaultPasswordAttempts, "Failed password attempts allowed for one URL or account login from one IP during password window, 0 disables limiting")
+	flag.DurationVar(&(c.PasswordWindow), "password-window", DefaultPasswordWindow, "Password attempts window")
+	flag.BoolVar(&(c.DisableClickIPs), "disable-click-ips", DefaultDisableClickIPs, "This flag disables saving of hashed visitors IPs in click analytics")
+	flag.IntVar(&(c.ClickBufferSize), "click-buffer-size", DefaultClickBufferSize, "Max amount of buffered clicks, new clicks are dropped if buffer is full")
+	flag.IntVar(&(c.ClickBatchSize), "click-batch-size", DefaultClickBatchSize, "Amount of clicks saved to a storage at once")
+	flag.DurationVar(&(c.ClickFlushInterval), "click-flush-interval", DefaultClickFlushInterval, "Max time while clicks are kept in a buffer")
+	flag.IntVar(&(c.RedirectCode), "redirect-code", DefaultRedirectCode, "Default HTTP status code of redirects: 301, 302, 307 or 308")
+	flag.BoolVar(&(c.PassQuery), "pass-query", DefaultPassQuery, "This flag enables merging of redirect requests queries into original URLs")
+	flag.StringVar(&(c.UTMParams), "utm-params", DefaultUTMParams, "Default UTM params added to original URLs on redirects. Example: \"utm_source=shortener&utm_medium=link\"")
+	flag.IntVar(&(c.BulkChunkSize), "bulk-chunk-size", DefaultBulkChunkSize, "Amount of lines of a bulk shortening request saved to a storage at once")
+	flag.DurationVar(&(c.HealthInterval), "health-interval", DefaultHealthInterval, "Period of storage checks for a gRPC health service")
+	flag.BoolVar(&(c.GRPCReflection), "grpc-reflection", DefaultGRPCReflection, "This flag enables gRPC server reflection")
+	flag.BoolVar(&(c.InsecureDev), "insecure-dev", DefaultInsecureDev, "This flag allows to start with a default JWT secret and IP hash salt, for development only")
 	flag.Parse()
 
 	//get env values
@@ -79,8 +191,29 @@ func (c *Config) Configure() error {
 	envEnableHTTPS, wasFoundEnableHTTPSFlag := os.LookupEnv("ENABLE_HTTPS")
 	envConfFile, wasFoundConfFile := os.LookupEnv("CONFIG")
 	envTrustedSubnet, wasFoundTrustedSubnet := os.LookupEnv("TRUSTED_SUBNET")
+	envTrustedProxies, wasFoundTrustedProxies := os.LookupEnv("TRUSTED_PROXIES")
 	envJWTSecret, wasFoundJWTSecret := os.LookupEnv("JWT_SECRET")
+	envJWTOldSecrets, wasFoundJWTOldSecrets := os.LookupEnv("JWT_OLD_SECRETS")
+	envJWTSigningKey, wasFoundJWTSigningKey := os.LookupEnv("JWT_SIGNING_KEY")
+	envJWTVerificationKeys, wasFoundJWTVerificationKeys := os.LookupEnv("JWT_VERIFICATION_KEYS")
+	envIPHashSalt, wasFoundIPHashSalt := os.LookupEnv("IP_HASH_SALT")
 	envJWTTimeoutHours, wasFoundJWTTimeoutHours := os.LookupEnv("JWT_TIMEOUT_HOURS")
+	envRefreshTokenTTL, wasFoundRefreshTokenTTL := os.LookupEnv("REFRESH_TOKEN_TTL")
+	envReaperInterval, wasFoundReaperInterval := os.LookupEnv("REAPER_INTERVAL")
+	envExpiredRetention, wasFoundExpiredRetention := os.LookupEnv("EXPIRED_RETENTION")
+	envPasswordAttempts, wasFoundPasswordAttempts := os.LookupEnv("PASSWORD_ATTEMPTS")
+	envPasswordWindow, wasFoundPasswordWindow := os.LookupEnv("PASSWORD_WINDOW")
+	envDisableClickIPs, wasFoundDisableClickIPs := os.LookupEnv("DISABLE_CLICK_IPS")
+	envClickBufferSize, wasFoundClickBufferSize := os.LookupEnv("CLICK_BUFFER_SIZE")
+	envClickBatchSize, wasFoundClickBatchSize := os.LookupEnv("CLICK_BATCH_SIZE")
+	envClickFlushInterval, wasFoundClickFlushInterval := os.LookupEnv("CLICK_FLUSH_INTERVAL")
+	envRedirectCode, wasFoundRedirectCode := os.LookupEnv("REDIRECT_CODE")
+	envPassQuery, wasFoundPassQuery := os.LookupEnv("PASS_QUERY")
+	envUTMParams, wasFoundUTMParams := os.LookupEnv("UTM_PARAMS")
+	envBulkChunkSize, wasFoundBulkChunkSize := os.LookupEnv("BULK_CHUNK_SIZE")
+	envHealthInterval, wasFoundHealthInterval := os.LookupEnv("HEALTH_INTERVAL")
+	envGRPCReflection, wasFoundGRPCReflection := os.LookupEnv("GRPC_REFLECTION")
+	envInsecureDev, wasFoundInsecureDev := os.LookupEnv("INSECURE_DEV")
 
 	//set values
 	if c.ServerAddress == DefaultServerAddress && wasFoundServerAddress {
@@ -111,16 +244,23 @@ func (c *Config) Configure() error {
 	if wasFoundTrustedSubnet {
 		c.TrustedSubnet = envTrustedSubnet
 	}
+	if c.TrustedProxies == DefaultTrustedProxies && wasFoundTrustedProxies {
+		c.TrustedProxies = envTrustedProxies
+	}
 	if wasFoundJWTSecret {
 		c.JWTSecret = envJWTSecret
-	} else {
-		// panic("JWT secret not set. Please set the environment variable `JWT_SECRET`.")
-		// I believe the panic function should be called in this case
-		// since using a default value for c.JWTSecret is not secure.
-		// However, I will use it here because Yandex.Practicum's tests don't set
-		// a `JWT_SECRET` variable.
-		// Therefore, I am using a "hardcoded" default value for c.JWTSecret solely for educational purposes.
-		c.JWTSecret = "superSecret"
+	}
+	if wasFoundJWTOldSecrets {
+		c.JWTOldSecrets = envJWTOldSecrets
+	}
+	if c.JWTSigningKey == "" && wasFoundJWTSigningKey {
+		c.JWTSigningKey = envJWTSigningKey
+	}
+	if c.JWTVerificationKeys == "" && wasFoundJWTVerificationKeys {
+		c.JWTVerificationKeys = envJWTVerificationKeys
+	}
+	if wasFoundIPHashSalt {
+		c.IPHashSalt = envIPHashSalt
 	}
 	if wasFoundJWTTimeoutHours {
 		hours, err := strconv.Atoi(envJWTTimeoutHours)
@@ -129,7 +269,115 @@ func (c *Config) Configure() error {
 		}
 		c.JWTTimeoutHours = hours
 	}
+	if wasFoundRefreshTokenTTL {
+		ttl, err := time.ParseDuration(envRefreshTokenTTL)
+		if err != nil {
+			return fmt.Errorf("error parsing REFRESH_TOKEN_TTL: %w", err)
+		}
+		c.RefreshTokenTTL = ttl
+	}
 	//`else` - flag value (it has been already set)
+	if wasFoundReaperInterval {
+		interval, err := time.ParseDuration(envReaperInterval)
+		if err != nil {
+			return fmt.Errorf("error parsing REAPER_INTERVAL: %w", err)
+		}
+		c.ReaperInterval = interval
+	}
+	if wasFoundExpiredRetention {
+		retention, err := time.ParseDuration(envExpiredRetention)
+		if err != nil {
+			return fmt.Errorf("error parsing EXPIRED_RETENTION: %w", err)
+		}
+		c.ExpiredRetention = retention
+	}
+	if wasFoundPasswordAttempts {
+		attempts, err := strconv.Atoi(envPasswordAttempts)
+		if err != nil {
+			return fmt.Errorf("error parsing PASSWORD_ATTEMPTS: %w", err)
+		}
+		c.PasswordAttempts = attempts
+	}
+	if wasFoundPasswordWindow {
+		window, err := time.ParseDuration(envPasswordWindow)
+		if err != nil {
+			return fmt.Errorf("error parsing PASSWORD_WINDOW: %w", err)
+		}
+		c.PasswordWindow = window
+	}
+	if wasFoundDisableClickIPs {
+		disable, err := strconv.ParseBool(envDisableClickIPs)
+		if err != nil {
+			return fmt.Errorf("error parsing DISABLE_CLICK_IPS env var: %w", err)
+		}
+		c.DisableClickIPs = disable
+	}
+	if wasFoundClickBufferSize {
+		size, err := strconv.Atoi(envClickBufferSize)
+		if err != nil {
+			return fmt.Errorf("error parsing CLICK_BUFFER_SIZE: %w", err)
+		}
+		c.ClickBufferSize = size
+	}
+	if wasFoundClickBatchSize {
+		size, err := strconv.Atoi(envClickBatchSize)
+		if err != nil {
+			return fmt.Errorf("error parsing CLICK_BATCH_SIZE: %w", err)
+		}
+		c.ClickBatchSize = size
+	}
+	if wasFoundClickFlushInterval {
+		interval, err := time.ParseDuration(envClickFlushInterval)
+		if err != nil {
+			return fmt.Errorf("error parsing CLICK_FLUSH_INTERVAL: %w", err)
+		}
+		c.ClickFlushInterval = interval
+	}
+	if wasFoundRedirectCode {
+		code, err := strconv.Atoi(envRedirectCode)
+		if err != nil {
+			return fmt.Errorf("error parsing REDIRECT_CODE: %w", err)
+		}
+		c.RedirectCode = code
+	}
+	if wasFoundPassQuery {
+		pass, err := strconv.ParseBool(envPassQuery)
+		if err != nil {
+			return fmt.Errorf("error parsing PASS_QUERY env var: %w", err)
+		}
+		c.PassQuery = pass
+	}
+	if wasFoundUTMParams {
+		c.UTMParams = envUTMParams
+	}
+	if wasFoundBulkChunkSize {
+		size, err := strconv.Atoi(envBulkChunkSize)
+		if err != nil {
+			return fmt.Errorf("error parsing BULK_CHUNK_SIZE: %w", err)
+		}
+		c.BulkChunkSize = size
+	}
+	if wasFoundHealthInterval {
+		interval, err := time.ParseDuration(envHealthInterval)
+		if err != nil {
+			return fmt.Errorf("error parsing HEALTH_INTERVAL: %w", err)
+		}
+		c.HealthInterval = interval
+	}
+	if wasFoundGRPCReflection {
+		reflection, err := strconv.ParseBool(envGRPCReflection)
+		if err != nil {
+			return fmt.Errorf("error parsing GRPC_REFLECTION env var: %w", err)
+		}
+		c.GRPCReflection = reflection
+	}
+	if wasFoundInsecureDev {
+		insecure, err := strconv.ParseBool(envInsecureDev)
+		if err != nil {
+			return fmt.Errorf("error parsing INSECURE_DEV env var: %w", err)
+		}
+		c.InsecureDev = insecure
+	}
 
 	//get config file values and set them if they were not provided earlier
 	if wasFoundConfFile {
@@ -178,12 +426,138 @@ func (c *Config) Configure() error {
 		if c.TrustedSubnet == DefaultTrustedSubnet && confData.TrustedSubnet != "" {
 			c.TrustedSubnet = confData.TrustedSubnet
 		}
+		if c.TrustedProxies == DefaultTrustedProxies && confData.TrustedProxies != "" {
+			c.TrustedProxies = confData.TrustedProxies
+		}
 		if !wasFoundJWTSecret && confData.JWTSecret != "" {
 			c.JWTSecret = confData.JWTSecret
 		}
+		if !wasFoundJWTOldSecrets && confData.JWTOldSecrets != "" {
+			c.JWTOldSecrets = confData.JWTOldSecrets
+		}
+		if !wasFoundIPHashSalt && confData.IPHashSalt != "" {
+			c.IPHashSalt = confData.IPHashSalt
+		}
+		if c.JWTSigningKey == "" && confData.JWTSigningKey != "" {
+			c.JWTSigningKey = confData.JWTSigningKey
+		}
+		if c.JWTVerificationKeys == "" && confData.JWTVerificationKeys != "" {
+			c.JWTVerificationKeys = confData.JWTVerificationKeys
+		}
 		if c.JWTTimeoutHours == DefaultJWTTimeoutHours && confData.JWTTimeoutHours != 0 {
 			c.JWTTimeoutHours = confData.JWTTimeoutHours
 		}
+		if c.RefreshTokenTTL == DefaultRefreshTokenTTL && confData.RefreshTokenTTL != "" {
+			ttl, err := time.ParseDuration(confData.RefreshTokenTTL)
+			if err != nil {
+				return fmt.Errorf("could not parse refresh_token_ttl from config file: %w", err)
+			}
+			c.RefreshTokenTTL = ttl
+		}
+		if c.ReaperInterval == DefaultReaperInterval && confData.ReaperInterval != "" {
+			interval, err := time.ParseDuration(confData.ReaperInterval)
+			if err != nil {
+				return fmt.Errorf("could not parse reaper_interval from config file: %w", err)
+			}
+			c.ReaperInterval = interval
+		}
+		if c.ExpiredRetention == DefaultExpiredRetention && confData.ExpiredRetention != "" {
+			retention, err := time.ParseDuration(confData.ExpiredRetention)
+			if err != nil {
+				return fmt.Errorf("could not parse expired_retention from config file: %w", err)
+			}
+			c.ExpiredRetention = retention
+		}
+		if c.PasswordAttempts == DefaultPasswordAttempts && confData.PasswordAttempts != 0 {
+			c.PasswordAttempts = confData.PasswordAttempts
+		}
+		if c.PasswordWindow == DefaultPasswordWindow && confData.PasswordWindow != "" {
+			window, err := time.ParseDuration(confData.PasswordWindow)
+			if err != nil {
+				return fmt.Errorf("could not parse password_window from config file: %w", err)
+			}
+			c.PasswordWindow = window
+		}
+		if !c.DisableClickIPs && confData.DisableClickIPs {
+			c.DisableClickIPs = confData.DisableClickIPs
+		}
+		if c.ClickBufferSize == DefaultClickBufferSize && confData.ClickBufferSize != 0 {
+			c.ClickBufferSize = confData.ClickBufferSize
+		}
+		if c.ClickBatchSize == DefaultClickBatchSize && confData.ClickBatchSize != 0 {
+			c.ClickBatchSize = confData.ClickBatchSize
+		}
+		if c.ClickFlushInterval == DefaultClickFlushInterval && confData.ClickFlushInterval != "" {
+			interval, err := time.ParseDuration(confData.ClickFlushInterval)
+			if err != nil {
+				return fmt.Errorf("could not parse click_flush_interval from config file: %w", err)
+			}
+			c.ClickFlushInterval = interval
+		}
+		if c.RedirectCode == DefaultRedirectCode && confData.RedirectCode != 0 {
+			c.RedirectCode = confData.RedirectCode
+		}
+		if !c.PassQuery && confData.PassQuery {
+			c.PassQuery = confData.PassQuery
+		}
+		if c.UTMParams == DefaultUTMParams && confData.UTMParams != "" {
+			c.UTMParams = confData.UTMParams
+		}
+		if c.BulkChunkSize == DefaultBulkChunkSize && confData.BulkChunkSize != 0 {
+			c.BulkChunkSize = confData.BulkChunkSize
+		}
+		if c.HealthInterval == DefaultHealthInterval && confData.HealthInterval != "" {
+			interval, err := time.ParseDuration(confData.HealthInterval)
+			if err != nil {
+				return fmt.Errorf("could not parse health_interval from config file: %w", err)
+			}
+			c.HealthInterval = interval
+		}
+		if !c.GRPCReflection && confData.GRPCReflection {
+			c.GRPCReflection = confData.GRPCReflection
+		}
+		if !c.InsecureDev && confData.InsecureDev {
+			c.InsecureDev = confData.InsecureDev
+		}
+	}
+
+	return c.check()
+}
+
+// check validates configuration params.
+// It sets DefaultJWTSecret and DefaultIPHashSalt if they are not set and InsecureDev is true.
+func (c *Config) check() error {
+	if !entities.IsRedirectCode(c.RedirectCode) {
+		return fmt.Errorf("%d is not a redirect code, use 301, 302, 307 or 308", c.RedirectCode)
+	}
+	if c.BulkChunkSize <= 0 {
+		return fmt.Errorf("bulk chunk size has to be positive, got %d", c.BulkChunkSize)
+	}
+	if c.HealthInterval <= 0 {
+		return fmt.Errorf("health interval has to be positive, got %s", c.HealthInterval)
+	}
+	if c.RefreshTokenTTL <= 0 {
+		return fmt.Errorf("refresh token TTL has to be positive, got %s", c.RefreshTokenTTL)
+	}
+	//a known secret allows anyone to build tokens of any user, so it is used only if it was asked explicitly
+	if c.JWTSecret == "" && c.JWTSigningKey == "" {
+		if !c.InsecureDev {
+			return fmt.Errorf("JWT keys are not set, set JWT_SECRET or JWT_SIGNING_KEY (or use -insecure-dev flag for development only)")
+		}
+		c.JWTSecret = DefaultJWTSecret
+	}
+	if c.JWTSecret == DefaultJWTSecret && !c.InsecureDev {
+		return fmt.Errorf("default JWT secret can be used only with -insecure-dev flag")
+	}
+	//a salt is separated from JWT keys, so hashes of IPs can`t help to find a secret and don`t change after a keys rotation
+	if c.IPHashSalt == "" && !c.DisableClickIPs {
+		if !c.InsecureDev {
+			return fmt.Errorf("IP hash salt is not set, set IP_HASH_SALT (or use -disable-click-ips flag, or -insecure-dev flag for development only)")
+		}
+		c.IPHashSalt = DefaultIPHashSalt
+	}
+	if c.IPHashSalt == DefaultIPHashSalt && !c.InsecureDev {
+		return fmt.Errorf("default IP hash salt can be used only with -insecure-dev flag")
 	}
 	return nil
 }
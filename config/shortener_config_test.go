@@ -0,0 +1,128 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a Config with default values and test secrets which passes all checks.
+func validConfig() Config {
+	return Config{
+		RedirectCode:    DefaultRedirectCode,
+		BulkChunkSize:   DefaultBulkChunkSize,
+		HealthInterval:  DefaultHealthInterval,
+		RefreshTokenTTL: DefaultRefreshTokenTTL,
+		JWTSecret:       "test secret",
+		IPHashSalt:      "test salt",
+	}
+}
+
+func TestConfig_check(t *testing.T) {
+	tests := []struct {
+		name          string
+		jwtSecret     string
+		jwtSigningKey string
+		insecureDev   bool
+		wantErr       bool
+		wantSecret    string
+	}{
+		{
+			name:    "no JWT keys",
+			wantErr: true,
+		},
+		{
+			name:      "default secret",
+			jwtSecret: DefaultJWTSecret,
+			wantErr:   true,
+		},
+		{
+			name:        "no JWT keys in insecure dev mode",
+			insecureDev: true,
+			wantSecret:  DefaultJWTSecret,
+		},
+		{
+			name:        "default secret in insecure dev mode",
+			jwtSecret:   DefaultJWTSecret,
+			insecureDev: true,
+			wantSecret:  DefaultJWTSecret,
+		},
+		{
+			name:       "secret",
+			jwtSecret:  "some secret",
+			wantSecret: "some secret",
+		},
+		{
+			name:          "signing key only",
+			jwtSigningKey: "/keys/private.pem",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			c.JWTSecret = tt.jwtSecret
+			c.JWTSigningKey = tt.jwtSigningKey
+			c.InsecureDev = tt.insecureDev
+
+			err := c.check()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSecret, c.JWTSecret)
+		})
+	}
+}
+
+func TestConfig_checkIPHashSalt(t *testing.T) {
+	tests := []struct {
+		name            string
+		ipHashSalt      string
+		disableClickIPs bool
+		insecureDev     bool
+		wantErr         bool
+		wantSalt        string
+	}{
+		{
+			name:    "no salt",
+			wantErr: true,
+		},
+		{
+			name:       "default salt",
+			ipHashSalt: DefaultIPHashSalt,
+			wantErr:    true,
+		},
+		{
+			name:            "no salt without IPs",
+			disableClickIPs: true,
+		},
+		{
+			name:        "no salt in insecure dev mode",
+			insecureDev: true,
+			wantSalt:    DefaultIPHashSalt,
+		},
+		{
+			name:       "salt",
+			ipHashSalt: "some salt",
+			wantSalt:   "some salt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			c.IPHashSalt = tt.ipHashSalt
+			c.DisableClickIPs = tt.disableClickIPs
+			c.InsecureDev = tt.insecureDev
+
+			err := c.check()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSalt, c.IPHashSalt)
+		})
+	}
+}
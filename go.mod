@@ -14,9 +14,11 @@ require (
 	go.uber.org/zap v1.26.0
 	golang.org/x/crypto v0.28.0
 	golang.org/x/tools v0.26.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142
 	google.golang.org/grpc v1.67.1
 	google.golang.org/protobuf v1.35.1
 	honnef.co/go/tools v0.5.1
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -33,6 +35,5 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.26.0 // indirect
 	golang.org/x/text v0.19.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
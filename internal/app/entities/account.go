@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// Account is a registered user with a login and a password.
+// UserID is a usual user ID, so URLs of an account are kept like URLs of anonymous users.
+type Account struct {
+	UserID       int       `json:"user_id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "time"
+
+// APIKey is a personal API key of a user. Only a hash of a key is kept (KeyHash), a key itself is shown only once.
+// Empty Scopes mean all scopes. ExpiresAt is nil if a key never expires, LastUsedAt is nil if a key was never used.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	KeyHash    string     `json:"key_hash,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// IsExpiredAt returns true if a key has an expiration time and it is not after given moment.
+func (k *APIKey) IsExpiredAt(moment time.Time) bool {
+	return k.ExpiresAt != nil && !k.ExpiresAt.After(moment)
+}
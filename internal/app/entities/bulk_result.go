@@ -0,0 +1,11 @@
+package entities
+
+// BulkResult is a result of one line of a bulk shortening request. Line is a line number in a request (from 1).
+// ShortURL is empty if Error is not empty, except already shortened URLs (ShortURL is an existing short URL then).
+// Line is zero only for an error witch stopped a whole request.
+type BulkResult struct {
+	Line          int    `json:"line,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	ShortURL      string `json:"short_url,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
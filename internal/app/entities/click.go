@@ -0,0 +1,50 @@
+package entities
+
+import "time"
+
+// Click is a click event (one redirect using a short URL).
+// IPHash is empty if client IPs are not recorded. Variant is a name of a used variant (empty if URL has no variants).
+type Click struct {
+	ShortURL       string    `json:"short_url"`
+	Time           time.Time `json:"time"`
+	Referrer       string    `json:"referrer,omitempty"`
+	UserAgentClass string    `json:"user_agent_class,omitempty"`
+	IPHash         string    `json:"ip_hash,omitempty"`
+	Variant        string    `json:"variant,omitempty"`
+}
+
+// URLStats is an aggregated statistics of a short URL clicks.
+// Variants contains clicks of A/B variants (it is empty if URL has never had variants).
+type URLStats struct {
+	ShortURL       string           `json:"short_url"`
+	TotalClicks    int              `json:"total_clicks"`
+	UniqueVisitors int              `json:"unique_visitors"`
+	ClicksPerDay   []DayClicks      `json:"clicks_per_day"`
+	TopReferrers   []ReferrerClicks `json:"top_referrers"`
+	Variants       []VariantClicks  `json:"variants"`
+}
+
+// DayClicks is an amount of clicks during one day (UTC). Day format is "2006-01-02".
+type DayClicks struct {
+	Day    string `json:"day"`
+	Clicks int    `json:"clicks"`
+}
+
+// ReferrerClicks is an amount of clicks from one referrer.
+type ReferrerClicks struct {
+	Referrer string `json:"referrer"`
+	Clicks   int    `json:"clicks"`
+}
+
+// VariantClicks is an amount of clicks and unique visitors of one A/B variant.
+type VariantClicks struct {
+	Variant        string `json:"variant"`
+	Clicks         int    `json:"clicks"`
+	UniqueVisitors int    `json:"unique_visitors"`
+}
+
+// DirectReferrer is used in statistics for clicks without a referrer.
+const DirectReferrer = "(direct)"
+
+// DayFormat is a format of DayClicks.Day.
+const DayFormat = "2006-01-02"
@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// Session is a login session of a user. Only a hash of its refresh token is kept (RefreshTokenHash).
+// Revoked sessions (RevokedAt is not nil) form a revocation list: their tokens are not accepted anymore.
+type Session struct {
+	ID               string     `json:"id"`
+	UserID           int        `json:"user_id"`
+	RefreshTokenHash string     `json:"refresh_token_hash"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActiveAt returns true if a session was not revoked and has not expired at given moment.
+func (s *Session) IsActiveAt(moment time.Time) bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(moment)
+}
+
+// EndedBefore returns true if a session expired or was revoked before given moment.
+func (s *Session) EndedBefore(moment time.Time) bool {
+	return s.ExpiresAt.Before(moment) || (s.RevokedAt != nil && s.RevokedAt.Before(moment))
+}
@@ -1,11 +1,118 @@
 // Package entities contains main entities for all internal packages of the project.
 package entities
 
+import (
+	"net/http"
+	"time"
+)
+
 // URL is a URL struct with ShortURL and OriginalURL versions.
+// ExpiresAt is optional, URL without it never expires.
+// TTL is used only in requests (for example "24h"), it is converted to ExpiresAt before saving.
+// MaxClicks is optional too, URL without it (MaxClicks = 0) can be used any number of times.
+// ClicksLeft is nil if URL has no clicks limit.
+// Password is used only in requests, only its hash (PasswordHash) is saved.
+// UserID is an owner of a URL (0 if URL has no owner), it is filled only by storages.
+// CreatedAt, UpdatedAt (a time of the last change of a URL) and DeletedAt are set by storages.
+// Title, Notes and Tags are optional user`s data to find URLs.
+// RedirectCode is an HTTP status code of redirects (one of RedirectCodes), 0 means a default code from a config.
+// PassQuery enables merging of a redirect request query into an original URL, nil means a default value from a config.
+// UTM contains default "utm_*" params witch are added to an original URL on redirects.
+// Rules are optional redirect rules, the first matching rule sets a destination (an original URL is a fallback).
+// Variants are optional weighted destinations for A/B tests, they are used if no rule matches.
+// StickyVariants makes a visitor get the same variant every time (it is remembered in a cookie).
 type URL struct {
-	CorrelationID string `json:"correlation_id,omitempty"`
-	ShortURL      string `json:"short_url,omitempty"`
-	OriginalURL   string `json:"original_url,omitempty"`
+	CorrelationID  string            `json:"correlation_id,omitempty"`
+	ShortURL       string            `json:"short_url,omitempty"`
+	OriginalURL    string            `json:"original_url,omitempty"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	TTL            string            `json:"ttl,omitempty"`
+	IsExpired      bool              `json:"is_expired,omitempty"`
+	MaxClicks      int               `json:"max_clicks,omitempty"`
+	ClicksLeft     *int              `json:"clicks_left,omitempty"`
+	Password       string            `json:"password,omitempty"`
+	PasswordHash   string            `json:"-"`
+	HasPassword    bool              `json:"has_password,omitempty"`
+	IsDeleted      bool              `json:"is_deleted,omitempty"`
+	UserID         int               `json:"-"`
+	CreatedAt      *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time        `json:"updated_at,omitempty"`
+	DeletedAt      *time.Time        `json:"deleted_at,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	Notes          string            `json:"notes,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	RedirectCode   int               `json:"redirect_code,omitempty"`
+	PassQuery      *bool             `json:"pass_query,omitempty"`
+	UTM            map[string]string `json:"utm,omitempty"`
+	Rules          []RedirectRule    `json:"rules,omitempty"`
+	Variants       []Variant         `json:"variants,omitempty"`
+	StickyVariants bool              `json:"sticky_variants,omitempty"`
+}
+
+// Variant is one of weighted destinations of a URL. Name identifies a variant in cookies and in clicks statistics.
+// A chance of a variant is its Weight divided by a sum of weights of all variants (0 disables a variant).
+type Variant struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+	Weight      int    `json:"weight"`
+}
+
+// RedirectRule is a rule witch sends some clients to its own destination.
+// Device is a user agent class ("ios", "android", "desktop" or "bot"), Language is a language from an Accept-Language
+// header (like "en" or "en-US"). Empty fields match any client, but a rule has to have at least one of them.
+type RedirectRule struct {
+	Device      string `json:"device,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Destination string `json:"destination"`
+}
+
+// RedirectCodes are HTTP status codes witch can be used for redirects.
+var RedirectCodes = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// IsRedirectCode returns true if code is one of RedirectCodes.
+func IsRedirectCode(code int) bool {
+	for _, c := range RedirectCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag returns true if URL has given tag.
+func (u *URL) HasTag(tag string) bool {
+	for _, t := range u.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// URLUpdate contains changes of a URL. Nil fields are not changed.
+type URLUpdate struct {
+	OriginalURL    *string
+	Title          *string
+	Notes          *string
+	Tags           *[]string
+	Rules          *[]RedirectRule
+	Variants       *[]Variant
+	StickyVariants *bool
+}
+
+// IsExpiredAt returns true if URL has an expiration time and it is not after given moment.
+func (u *URL) IsExpiredAt(moment time.Time) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(moment)
+}
+
+// IsExhausted returns true if URL has a clicks limit and no clicks left.
+func (u *URL) IsExhausted() bool {
+	return u.ClicksLeft != nil && *u.ClicksLeft <= 0
 }
 
 //type URLGot struct {
@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// URLPreview is a public information about a short URL. It is shown before following a link.
+// OriginalURL is empty if URL is protected by a password.
+// Rules are redirect rules with their own destinations (original URL is a fallback), Variants are A/B variants
+// with their weights (they are used if no rule matches). They are hidden with OriginalURL.
+// TotalClicks is nil if it was not requested or if it can`t be shown to a user.
+type URLPreview struct {
+	ShortURL     string         `json:"short_url"`
+	OriginalURL  string         `json:"original_url,omitempty"`
+	Rules        []RedirectRule `json:"rules,omitempty"`
+	Variants     []Variant      `json:"variants,omitempty"`
+	CreatedAt    *time.Time     `json:"created_at,omitempty"`
+	ExpiresAt    *time.Time     `json:"expires_at,omitempty"`
+	IsDeleted    bool           `json:"is_deleted"`
+	IsExpired    bool           `json:"is_expired"`
+	NoClicksLeft bool           `json:"no_clicks_left"`
+	HasPassword  bool           `json:"has_password"`
+	TotalClicks  *int           `json:"total_clicks,omitempty"`
+}
+
+// IsActive returns true if URL can be used for redirects.
+func (p *URLPreview) IsActive() bool {
+	return !p.IsDeleted && !p.IsExpired && !p.NoClicksLeft
+}
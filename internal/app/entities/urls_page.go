@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// URLs sorting fields.
+const (
+	SortByCreated = "created"
+	SortByShort   = "short"
+)
+
+// URLsCursor is a position of the last URL of a previous page in user`s URLs listings.
+// URLs are sorted by CreatedAt (URLs created at the same time are sorted by ShortURL) or only by ShortURL.
+// It is a position, not a link to a URL, so a page can be found even if the last URL of a previous page was deleted.
+type URLsCursor struct {
+	CreatedAt time.Time
+	ShortURL  string
+}
+
+// URLsPageQuery describes a page of user`s URLs.
+// After is a position of the last URL of a previous page (nil for the first page),
+// storages return URLs witch go after it in a chosen order.
+// SortBy is SortByCreated or SortByShort.
+// Deleted URLs are returned only if IncludeDeleted is true.
+// OriginalContains filters URLs by a substring of an original URL (empty means no filter).
+// Tag filters URLs by a tag, TitleQuery filters URLs by words of a title (all words have to be in a title).
+type URLsPageQuery struct {
+	After            *URLsCursor
+	Limit            int
+	SortBy           string
+	Descending       bool
+	IncludeDeleted   bool
+	OriginalContains string
+	Tag              string
+	TitleQuery       string
+}
+
+// Words splits a text to lowercase words (letters and digits). It is used to search URLs by titles.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,63 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *ShortenerServer) SignUp(ctx context.Context, req *proto.CredentialsRequest) (*proto.AccountResponse, error) {
+	//an anonymous user of a request (if there is one) gives his URLs to a new account
+	anonymousUserID := logic.AnonymousUserID(ctx)
+
+	result, err := s.Accounts.SignUp(ctx, req.Login, req.Password, anonymousUserID)
+	if errors.Is(err, logic.ErrBadAccountParams()) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if errors.Is(err, databases.ErrLoginTaken()) {
+		return nil, status.Error(codes.AlreadyExists, "Login is already taken")
+	} else if err != nil {
+		s.Logger.Errorf("SignUp error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return s.accountResponse(ctx, result)
+}
+
+func (s *ShortenerServer) LogIn(ctx context.Context, req *proto.CredentialsRequest) (*proto.AccountResponse, error) {
+	//an anonymous user of a request (if there is one) gives his URLs to an account
+	anonymousUserID := logic.AnonymousUserID(ctx)
+
+	result, err := s.Accounts.LogIn(ctx, req.Login, req.Password, anonymousUserID, clientIP(ctx, s.Proxies))
+	if errors.Is(err, logic.ErrWrongCredentials()) {
+		return nil, status.Error(codes.Unauthenticated, "Wrong login or password")
+	} else if errors.Is(err, logic.ErrTooManyAttempts()) {
+		return nil, status.Error(codes.ResourceExhausted, "Too many failed attempts")
+	} else if err != nil {
+		s.Logger.Errorf("LogIn error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return s.accountResponse(ctx, result)
+}
+
+// accountResponse starts a new session of an account.
+func (s *ShortenerServer) accountResponse(ctx context.Context, result logic.AuthResult) (*proto.AccountResponse, error) {
+	tokens, err := s.Sessions.Start(ctx, result.Account.UserID)
+	if err != nil {
+		s.Logger.Errorf("err while starting a session: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return &proto.AccountResponse{
+		Token:            tokens.AccessToken,
+		UserId:           int64(result.Account.UserID),
+		Login:            result.Account.Login,
+		ExpiresAt:        timestamppb.New(tokens.AccessExpiresAt),
+		ClaimedUrls:      int64(result.ClaimedURLs),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: timestamppb.New(tokens.RefreshExpiresAt),
+	}, nil
+}
@@ -0,0 +1,38 @@
+package grpchandlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortenerServer_LogIn_APIKey(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+	_, err := server.client.SignUp(ctx, &proto.CredentialsRequest{Login: "user", Password: "secret password"})
+	require.NoError(t, err, "error while preparing an account")
+
+	ownerID, err := server.storage.CreateUser(ctx)
+	require.NoError(t, err, "error while preparing a storage")
+	err = server.storage.SaveWithUserID(ctx, ownerID, entities.URL{ShortURL: "owned", OriginalURL: "https://example.com/owned"})
+	require.NoError(t, err, "error while preparing a storage")
+	key, _, err := server.apiKeys.Create(ctx, ownerID, "read only", []string{logic.ScopeRead}, nil)
+	require.NoError(t, err, "error while preparing an API key")
+
+	//URLs of a key owner are not claimed by an account of a caller
+	loggedIn, err := server.client.LogIn(withToken(ctx, key), &proto.CredentialsRequest{Login: "user", Password: "secret password"})
+	require.NoError(t, err)
+	assert.Zero(t, loggedIn.ClaimedUrls)
+	signedUp, err := server.client.SignUp(withToken(ctx, key), &proto.CredentialsRequest{Login: "other", Password: "secret password"})
+	require.NoError(t, err)
+	assert.Zero(t, signedUp.ClaimedUrls)
+
+	URL, err := server.storage.GetURL(ctx, "owned")
+	require.NoError(t, err)
+	assert.Equal(t, ownerID, URL.UserID)
+}
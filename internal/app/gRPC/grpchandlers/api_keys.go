@@ -0,0 +1,80 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *ShortenerServer) CreateAPIKey(ctx context.Context, req *proto.CreateAPIKeyRequest) (*proto.CreateAPIKeyResponse, error) {
+	userID, err := keysOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, _ := expirationFromRequest(req.ExpiresAt, 0)
+	key, apiKey, err := s.APIKeys.Create(ctx, userID, req.Name, req.Scopes, expiresAt)
+	if errors.Is(err, logic.ErrBadAPIKeyParams()) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		s.Logger.Errorf("CreateAPIKey error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return &proto.CreateAPIKeyResponse{
+		Key:    key,
+		ApiKey: apiKeyToResponse(apiKey),
+	}, nil
+}
+
+func (s *ShortenerServer) ListAPIKeys(ctx context.Context, req *emptypb.Empty) (*proto.APIKeysResponse, error) {
+	userID, err := keysOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.APIKeys.List(ctx, userID)
+	if err != nil {
+		s.Logger.Errorf("ListAPIKeys error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	response := &proto.APIKeysResponse{ApiKeys: make([]*proto.APIKey, len(keys))}
+	for i, key := range keys {
+		response.ApiKeys[i] = apiKeyToResponse(key)
+	}
+	return response, nil
+}
+
+func (s *ShortenerServer) RevokeAPIKey(ctx context.Context, req *proto.RevokeAPIKeyRequest) (*emptypb.Empty, error) {
+	userID, err := keysOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.APIKeys.Revoke(ctx, userID, req.Id)
+	if errors.Is(err, databases.ErrAPIKeyNotFound()) {
+		return nil, status.Error(codes.NotFound, "API key not found")
+	} else if err != nil {
+		s.Logger.Errorf("RevokeAPIKey error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// keysOwner returns a user ID of a request witch manages API keys.
+// Returns an Unauthenticated error if there is no user and a PermissionDenied error if a request uses an API key.
+func keysOwner(ctx context.Context) (int, error) {
+	userID, ok := logic.UserIDFromContext(ctx)
+	if !ok {
+		return 0, status.Errorf(codes.Unauthenticated, "User ID not found")
+	}
+	if _, isKey := logic.APIKeyFromContext(ctx); isKey {
+		return 0, status.Error(codes.PermissionDenied, "API keys can be managed only with a JWT")
+	}
+	return userID, nil
+}
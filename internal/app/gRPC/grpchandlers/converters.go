@@ -0,0 +1,169 @@
+package grpchandlers
+
+import (
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// expirationFromRequest converts expiration params of a gRPC request to entities.URL`s ExpiresAt and TTL fields.
+func expirationFromRequest(expiresAt *timestamppb.Timestamp, ttlSeconds int64) (*time.Time, string) {
+	var expiresAtTime *time.Time
+	if expiresAt != nil {
+		t := expiresAt.AsTime()
+		expiresAtTime = &t
+	}
+	ttl := ""
+	if ttlSeconds != 0 {
+		ttl = (time.Duration(ttlSeconds) * time.Second).String()
+	}
+	return expiresAtTime, ttl
+}
+
+// rulesFromRequest converts redirect rules of a gRPC request to entities.RedirectRule.
+func rulesFromRequest(rules []*proto.RedirectRule) []entities.RedirectRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	converted := make([]entities.RedirectRule, len(rules))
+	for i, rule := range rules {
+		converted[i] = entities.RedirectRule{
+			Device:      rule.Device,
+			Language:    rule.Language,
+			Destination: rule.Destination,
+		}
+	}
+	return converted
+}
+
+// rulesToResponse converts redirect rules to a gRPC response format.
+func rulesToResponse(rules []entities.RedirectRule) []*proto.RedirectRule {
+	converted := make([]*proto.RedirectRule, len(rules))
+	for i, rule := range rules {
+		converted[i] = &proto.RedirectRule{
+			Device:      rule.Device,
+			Language:    rule.Language,
+			Destination: rule.Destination,
+		}
+	}
+	return converted
+}
+
+// variantsFromRequest converts A/B variants of a gRPC request to entities.Variant.
+func variantsFromRequest(variants []*proto.Variant) []entities.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	converted := make([]entities.Variant, len(variants))
+	for i, variant := range variants {
+		converted[i] = entities.Variant{
+			Name:        variant.Name,
+			Destination: variant.Destination,
+			Weight:      int(variant.Weight),
+		}
+	}
+	return converted
+}
+
+// variantsToResponse converts A/B variants to a gRPC response format.
+func variantsToResponse(variants []entities.Variant) []*proto.Variant {
+	converted := make([]*proto.Variant, len(variants))
+	for i, variant := range variants {
+		converted[i] = &proto.Variant{
+			Name:        variant.Name,
+			Destination: variant.Destination,
+			Weight:      int32(variant.Weight),
+		}
+	}
+	return converted
+}
+
+// batchURLFromRequest converts one URL of a batch gRPC request to entities.URL.
+func batchURLFromRequest(url *proto.ShortenBatchRequest_URL) entities.URL {
+	URL := entities.URL{
+		CorrelationID:  url.CorrelationId,
+		OriginalURL:    url.OriginalUrl,
+		MaxClicks:      int(url.MaxClicks),
+		Password:       url.Password,
+		Title:          url.Title,
+		Notes:          url.Notes,
+		Tags:           url.Tags,
+		RedirectCode:   int(url.RedirectCode),
+		PassQuery:      url.PassQuery,
+		UTM:            url.Utm,
+		Rules:          rulesFromRequest(url.Rules),
+		Variants:       variantsFromRequest(url.Variants),
+		StickyVariants: url.StickyVariants,
+	}
+	URL.ExpiresAt, URL.TTL = expirationFromRequest(url.ExpiresAt, url.TtlSeconds)
+	return URL
+}
+
+// usersURLsResponse converts a page of user`s URLs to a gRPC response.
+func usersURLsResponse(page logic.UserURLsPage) *proto.UsersURLsResponse {
+	response := &proto.UsersURLsResponse{
+		Urls:       make([]*proto.UsersURLsResponse_URL, len(page.URLs)),
+		NextCursor: page.NextCursor,
+	}
+	for i, u := range page.URLs {
+		response.Urls[i] = userURLToResponse(u)
+	}
+	return response
+}
+
+// userURLToResponse converts one user`s URL to a gRPC response format.
+func userURLToResponse(u entities.URL) *proto.UsersURLsResponse_URL {
+	response := &proto.UsersURLsResponse_URL{
+		Original:       u.OriginalURL,
+		Short:          u.ShortURL,
+		IsExpired:      u.IsExpired,
+		MaxClicks:      int32(u.MaxClicks),
+		HasPassword:    u.HasPassword,
+		IsDeleted:      u.IsDeleted,
+		Title:          u.Title,
+		Notes:          u.Notes,
+		Tags:           u.Tags,
+		RedirectCode:   int32(u.RedirectCode),
+		PassQuery:      u.PassQuery,
+		Utm:            u.UTM,
+		Rules:          rulesToResponse(u.Rules),
+		Variants:       variantsToResponse(u.Variants),
+		StickyVariants: u.StickyVariants,
+	}
+	if u.ClicksLeft != nil {
+		response.ClicksLeft = int32(*u.ClicksLeft)
+	}
+	if u.ExpiresAt != nil {
+		response.ExpiresAt = timestamppb.New(*u.ExpiresAt)
+	}
+	if u.CreatedAt != nil {
+		response.CreatedAt = timestamppb.New(*u.CreatedAt)
+	}
+	if u.UpdatedAt != nil {
+		response.UpdatedAt = timestamppb.New(*u.UpdatedAt)
+	}
+	if u.DeletedAt != nil {
+		response.DeletedAt = timestamppb.New(*u.DeletedAt)
+	}
+	return response
+}
+
+// apiKeyToResponse converts an API key description to a gRPC response format.
+func apiKeyToResponse(key entities.APIKey) *proto.APIKey {
+	response := &proto.APIKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if key.ExpiresAt != nil {
+		response.ExpiresAt = timestamppb.New(*key.ExpiresAt)
+	}
+	if key.LastUsedAt != nil {
+		response.LastUsedAt = timestamppb.New(*key.LastUsedAt)
+	}
+	return response
+}
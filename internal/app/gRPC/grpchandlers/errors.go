@@ -0,0 +1,21 @@
+package grpchandlers
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// alreadyExistsError returns an ALREADY_EXISTS error with an existing short URL in google.rpc.ResourceInfo details.
+func alreadyExistsError(shortURL string) error {
+	st := status.Newf(codes.AlreadyExists, "URL is already shortened: %s", shortURL)
+	withDetails, err := st.WithDetails(&errdetails.ResourceInfo{
+		ResourceType: "short_url",
+		ResourceName: shortURL,
+		Description:  "URL is already shortened",
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,35 @@
+package grpchandlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestAlreadyExistsError(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+	issued, err := server.client.IssueToken(ctx, &emptypb.Empty{})
+	require.NoError(t, err, "error while preparing a user")
+	ctx = withToken(ctx, issued.Token)
+
+	shortened, err := server.client.Shorten(ctx, &proto.ShortenRequest{OriginalUrl: "https://ya.ru/"})
+	require.NoError(t, err)
+
+	_, err = server.client.Shorten(ctx, &proto.ShortenRequest{OriginalUrl: "https://ya.ru/"})
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.AlreadyExists, st.Code())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ResourceInfo)
+	require.True(t, ok, "google.rpc.ResourceInfo expected, got %T", st.Details()[0])
+	assert.Equal(t, "short_url", info.ResourceType)
+	assert.Equal(t, shortened.Shorten, info.ResourceName)
+}
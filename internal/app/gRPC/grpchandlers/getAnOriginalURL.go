@@ -2,20 +2,72 @@ package grpchandlers
 
 import (
 	"context"
+	"errors"
 	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"net/url"
 )
 
 func (s *ShortenerServer) GetOriginalURL(ctx context.Context, req *proto.GetOriginalURLRequest) (*proto.GetAnOriginalURLResponse, error) {
-	url, err := logic.GetOriginalURL(ctx, req.ShortUrl, s.Storage)
+	query, err := url.ParseQuery(req.Query)
 	if err != nil {
-		s.Logger.Debugf("Original URL not found. Given short: %v", url)
+		return nil, status.Error(codes.InvalidArgument, "can`t parse query")
+	}
+	visit := logic.Visit{
+		Password: req.Password,
+		IP:       clientIP(ctx, s.Proxies),
+		Query:    query,
+		Variant:  req.Variant,
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		visit.Referrer = firstValue(md.Get("referer"))
+		visit.UserAgent = firstValue(md.Get("user-agent"))
+		visit.AcceptLanguage = firstValue(md.Get("accept-language"))
+	}
+
+	redirect, err := s.Redirector.Resolve(ctx, req.ShortUrl, visit)
+	switch {
+	case errors.Is(err, logic.ErrPasswordRequired()):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, logic.ErrWrongPassword()):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, logic.ErrTooManyAttempts()):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		s.Logger.Debugf("Original URL not found. Given short: %v", req.ShortUrl)
 		return nil, status.Error(codes.NotFound, err.Error())
 	}
 	res := &proto.GetAnOriginalURLResponse{
-		Url: url,
+		Url:           redirect.URL,
+		RedirectCode:  int32(redirect.Code),
+		Variant:       redirect.Variant,
+		StickyVariant: redirect.StickyVariant,
 	}
 	return res, nil
 }
+
+// clientIP returns an IP of a client from a peer address (or from "X-Real-IP" metadata of a trusted proxy).
+func clientIP(ctx context.Context, proxies logic.TrustedProxies) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	realIP := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		realIP = firstValue(md.Get("X-Real-IP"))
+	}
+	return proxies.ClientIP(p.Addr.String(), realIP)
+}
+
+// firstValue returns the first metadata value or an empty string.
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
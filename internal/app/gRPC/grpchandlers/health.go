@@ -0,0 +1,20 @@
+package grpchandlers
+
+import (
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthStatusSetter returns a func witch sets a serving status of the whole server ("" service)
+// and of URLShortenerService. Use it with logic.RunStorageHealthCheck.
+func HealthStatusSetter(healthServer *health.Server) func(serving bool) {
+	return func(serving bool) {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !serving {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(proto.URLShortenerService_ServiceDesc.ServiceName, status)
+	}
+}
@@ -0,0 +1,49 @@
+package grpchandlers
+
+import (
+	"context"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *ShortenerServer) IssueToken(ctx context.Context, req *emptypb.Empty) (*proto.IssueTokenResponse, error) {
+	//a user from a valid token keeps his ID, but API keys can`t be exchanged for tokens (a token has no scopes)
+	if _, isKey := logic.APIKeyFromContext(ctx); isKey {
+		return nil, status.Error(codes.PermissionDenied, "API keys can`t be exchanged for tokens")
+	}
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+
+	//new user creating
+	if !ok {
+		var err error
+		userIDInt, err = s.Storage.CreateUser(ctx)
+		if err != nil {
+			s.Logger.Errorf("err while creating new user: %v", err)
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+	}
+
+	//session starting
+	tokens, err := s.Sessions.Start(ctx, userIDInt)
+	if err != nil {
+		s.Logger.Errorf("err while starting a session: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return tokensToResponse(tokens), nil
+}
+
+// tokensToResponse converts tokens of a session to a proto.IssueTokenResponse.
+func tokensToResponse(tokens logic.SessionTokens) *proto.IssueTokenResponse {
+	return &proto.IssueTokenResponse{
+		Token:            tokens.AccessToken,
+		UserId:           int64(tokens.UserID),
+		ExpiresAt:        timestamppb.New(tokens.AccessExpiresAt),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: timestamppb.New(tokens.RefreshExpiresAt),
+	}
+}
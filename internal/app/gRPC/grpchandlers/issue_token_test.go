@@ -0,0 +1,52 @@
+package grpchandlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestShortenerServer_IssueToken(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+
+	//new user
+	issued, err := server.client.IssueToken(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.NotEmpty(t, issued.Token)
+	require.NotEmpty(t, issued.RefreshToken)
+	assert.True(t, issued.ExpiresAt.AsTime().Before(issued.RefreshExpiresAt.AsTime()))
+	auth, err := server.sessions.Authenticate(ctx, issued.Token)
+	require.NoError(t, err)
+	assert.Equal(t, int(issued.UserId), auth.UserID)
+
+	t.Run("valid token keeps a user", func(t *testing.T) {
+		reissued, err := server.client.IssueToken(withToken(ctx, issued.Token), &emptypb.Empty{})
+		require.NoError(t, err)
+		assert.Equal(t, issued.UserId, reissued.UserId)
+		assert.NotEqual(t, issued.RefreshToken, reissued.RefreshToken, "a new session expected")
+
+		reissued, err = server.client.IssueToken(metadata.AppendToOutgoingContext(ctx, "token", issued.Token), &emptypb.Empty{})
+		require.NoError(t, err)
+		assert.Equal(t, issued.UserId, reissued.UserId)
+	})
+
+	t.Run("not valid token", func(t *testing.T) {
+		_, err := server.client.IssueToken(withToken(ctx, "not a token"), &emptypb.Empty{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("API key", func(t *testing.T) {
+		key, _, err := server.apiKeys.Create(ctx, int(issued.UserId), "key", []string{logic.ScopeRead}, nil)
+		require.NoError(t, err, "error while preparing an API key")
+		_, err = server.client.IssueToken(withToken(ctx, key), &emptypb.Empty{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
@@ -0,0 +1,43 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *ShortenerServer) QRCode(ctx context.Context, req *proto.QRCodeRequest) (*proto.QRCodeResponse, error) {
+	params := logic.QRCodeParams{
+		Format: req.Format,
+		Size:   int(req.Size),
+		Level:  req.Level,
+	}
+	if req.Margin != nil {
+		margin := int(*req.Margin)
+		params.Margin = &margin
+	}
+
+	code, err := logic.BuildQRCode(ctx, s.Storage, s.Conf.BaseAddress, req.ShortUrl, params)
+	switch {
+	case errors.Is(err, logic.ErrBadURLParams()):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, databases.ErrURLNotFound()):
+		return nil, status.Error(codes.NotFound, "URL not found")
+	case errors.Is(err, databases.ErrURLWasDeleted()),
+		errors.Is(err, databases.ErrURLExpired()),
+		errors.Is(err, databases.ErrClicksLimitReached()):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		s.Logger.Errorf("QR code error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &proto.QRCodeResponse{
+		ContentType: code.ContentType,
+		Image:       code.Data,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *ShortenerServer) SearchURLs(ctx context.Context, req *proto.SearchURLsRequest) (*proto.UsersURLsResponse, error) {
+	//auth
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
+		s.Logger.Debug("UserID not found req ctx")
+		return nil, status.Errorf(codes.Unauthenticated, "User ID not found")
+	}
+
+	//search
+	query := entities.URLsPageQuery{
+		Limit:            int(req.Limit),
+		SortBy:           req.SortBy,
+		Descending:       req.Descending,
+		OriginalContains: req.OriginalContains,
+		Tag:              req.Tag,
+		TitleQuery:       req.TitleQuery,
+	}
+	page, err := logic.SearchUsersURLs(ctx, s.Storage, s.Conf.BaseAddress, userIDInt, req.Cursor, query)
+	if errors.Is(err, logic.ErrBadURLParams()) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		s.Logger.Errorf("SearchURLs error: %v", err)
+		return nil, status.Errorf(codes.Internal, "Internal server error")
+	}
+
+	return usersURLsResponse(page), nil
+}
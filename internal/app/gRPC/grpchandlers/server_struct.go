@@ -9,7 +9,12 @@ import (
 
 type ShortenerServer struct {
 	proto.UnimplementedURLShortenerServiceServer
-	Storage logic.URLStorageInterface
-	Logger  zap.SugaredLogger
-	Conf    *config.Config
+	Storage    logic.URLStorageInterface
+	Redirector *logic.Redirector
+	Accounts   *logic.Accounts
+	APIKeys    *logic.APIKeys
+	Logger     zap.SugaredLogger
+	Conf       *config.Config
+	Sessions   *logic.Sessions
+	Proxies    logic.TrustedProxies
 }
@@ -0,0 +1,87 @@
+package grpchandlers
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/interceptors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testBaseAddress = "http://localhost:8080"
+
+// testServer is a ShortenerServer with interceptors of a real server on an in-memory connection.
+type testServer struct {
+	client   proto.URLShortenerServiceClient
+	storage  *databases.JustAMap
+	sessions *logic.Sessions
+	apiKeys  *logic.APIKeys
+}
+
+// newTestServer starts a testServer. Bulk shortening saves URLs by chunks of 2 URLs.
+func newTestServer(t *testing.T) testServer {
+	storage := databases.NewJustAMap()
+	conf := &config.Config{BaseAddress: testBaseAddress, BulkChunkSize: 2}
+	sessions := logic.NewSessions(storage, secure.NewJWTHelper("testSecretKey", 5), *conf)
+	apiKeys := logic.NewAPIKeys(storage)
+	_, trustedSubnet, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			interceptors.NewIPInterceptor(trustedSubnet),
+			interceptors.NewUnaryAuthInterceptor(sessions, apiKeys),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.NewStreamIPInterceptor(trustedSubnet),
+			interceptors.NewStreamAuthInterceptor(sessions, apiKeys),
+		),
+	)
+	proto.RegisterURLShortenerServiceServer(server, &ShortenerServer{
+		Storage:  storage,
+		Accounts: logic.NewAccounts(storage, *conf),
+		APIKeys:  apiKeys,
+		Logger:   *zaptest.NewLogger(t).Sugar(),
+		Conf:     conf,
+		Sessions: sessions,
+	})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err, "error while connecting to a test server")
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return testServer{
+		client:   proto.NewURLShortenerServiceClient(conn),
+		storage:  storage,
+		sessions: sessions,
+		apiKeys:  apiKeys,
+	}
+}
+
+// withToken returns a context of a call with a bearer token.
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", secure.BearerScheme+" "+token)
+}
@@ -0,0 +1,38 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *ShortenerServer) RefreshToken(ctx context.Context, req *proto.RefreshTokenRequest) (*proto.IssueTokenResponse, error) {
+	tokens, err := s.Sessions.Refresh(ctx, req.RefreshToken)
+	if errors.Is(err, logic.ErrInvalidSession()) {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	} else if err != nil {
+		s.Logger.Errorf("RefreshToken error: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return tokensToResponse(tokens), nil
+}
+
+func (s *ShortenerServer) LogOut(ctx context.Context, req *emptypb.Empty) (*emptypb.Empty, error) {
+	//API keys have no sessions, they can be revoked by RevokeAPIKey
+	if _, isKey := logic.APIKeyFromContext(ctx); isKey {
+		return nil, status.Error(codes.PermissionDenied, "API keys have no sessions")
+	}
+	if sessionID, ok := logic.SessionIDFromContext(ctx); ok {
+		err := s.Sessions.Revoke(ctx, sessionID)
+		if err != nil {
+			s.Logger.Errorf("LogOut error: %v", err)
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -3,7 +3,7 @@ package grpchandlers
 import (
 	"context"
 	"errors"
-	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/interceptors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/pkg/databases"
@@ -13,23 +13,35 @@ import (
 
 func (s *ShortenerServer) Shorten(ctx context.Context, req *proto.ShortenRequest) (*proto.ShortenResponse, error) {
 	//auth
-	userID := ctx.Value(interceptors.UserIDContextKey)
-	if userID == nil || userID == -1 {
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
 		s.Logger.Debug("UserID not found req ctx")
 		return nil, status.Errorf(codes.Unauthenticated, "User ID not found")
 	}
-	userIDInt, ok := userID.(int)
-	if !ok {
-		s.Logger.Warnf("User ID is not an int, real type: `%T`, value: `%v`", userID, userID)
-		return nil, status.Error(codes.InvalidArgument, "User ID is not an int")
-	}
 
 	//shorten
-	short, err := logic.Shorten(ctx, []byte(req.OriginalUrl), s.Conf.BaseAddress, s.Storage, userIDInt)
+	URL := entities.URL{
+		OriginalURL:    req.OriginalUrl,
+		MaxClicks:      int(req.MaxClicks),
+		Password:       req.Password,
+		Title:          req.Title,
+		Notes:          req.Notes,
+		Tags:           req.Tags,
+		RedirectCode:   int(req.RedirectCode),
+		PassQuery:      req.PassQuery,
+		UTM:            req.Utm,
+		Rules:          rulesFromRequest(req.Rules),
+		Variants:       variantsFromRequest(req.Variants),
+		StickyVariants: req.StickyVariants,
+	}
+	URL.ExpiresAt, URL.TTL = expirationFromRequest(req.ExpiresAt, req.TtlSeconds)
+	short, err := logic.Shorten(ctx, URL, s.Conf.BaseAddress, s.Storage, userIDInt)
 	alrExistsErr := &databases.AlreadyExistsError{}
 	if errors.As(err, &alrExistsErr) {
-		short = alrExistsErr.ShortURL
-		return &proto.ShortenResponse{Shorten: short}, status.Error(codes.AlreadyExists, "Already exists")
+		return nil, alreadyExistsError(s.Conf.BaseAddress + "/" + alrExistsErr.ShortURL)
+	}
+	if errors.Is(err, logic.ErrBadURLParams()) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	if err != nil {
 		s.Logger.Errorf("Shorten err: %v", err)
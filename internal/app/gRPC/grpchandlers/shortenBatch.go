@@ -2,8 +2,8 @@ package grpchandlers
 
 import (
 	"context"
+	"errors"
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
-	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/interceptors"
 	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"google.golang.org/grpc/codes"
@@ -12,30 +12,22 @@ import (
 
 func (s *ShortenerServer) ShortenBatch(ctx context.Context, req *proto.ShortenBatchRequest) (*proto.ShortenBatchResponse, error) {
 	//auth
-	userID := ctx.Value(interceptors.UserIDContextKey)
-	userIDInt := -1
-	if userID == nil || userID == -1 {
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
 		s.Logger.Debug("UserID not found req ctx, will use `-1`")
-	} else {
-		var ok bool
-		userIDInt, ok = userID.(int)
-		if !ok {
-			s.Logger.Warnf("User ID is not an int, real type: `%T`, value: `%v`", userID, userID)
-			return nil, status.Error(codes.InvalidArgument, "User ID is not an int")
-		}
 	}
 
 	//parse request
 	URLs := make([]entities.URL, len(req.Urls))
 	for i, url := range req.Urls {
-		URLs[i] = entities.URL{
-			CorrelationID: url.CorrelationId,
-			OriginalURL:   url.OriginalUrl,
-		}
+		URLs[i] = batchURLFromRequest(url)
 	}
 
 	//shorten
 	URLs, err := logic.ShortenBatch(ctx, URLs, s.Conf.BaseAddress, s.Storage, userIDInt)
+	if errors.Is(err, logic.ErrBadURLParams()) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		s.Logger.Errorf("ShortenBatch error: %v", err)
 		return nil, status.Error(codes.Internal, "Internal Server Error")
@@ -0,0 +1,67 @@
+package grpchandlers
+
+import (
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamSource is a logic.BulkSource witch reads URLs from a ShortenStream call.
+type streamSource struct {
+	stream proto.URLShortenerService_ShortenStreamServer
+	index  int
+}
+
+// Next returns the next URL of a stream and its number. Returns io.EOF when a client closes sending.
+func (s *streamSource) Next() (entities.URL, int, error) {
+	req, err := s.stream.Recv()
+	if err != nil {
+		return entities.URL{}, s.index, err
+	}
+	s.index++
+	return batchURLFromRequest(req), s.index, nil
+}
+
+// ShortenStream saves URLs of a stream by chunks of Conf.BulkChunkSize URLs and sends a result of every URL.
+// Results of a chunk are sent when a chunk is full or a client closes sending.
+// URLs with bad params get results with an error, they don`t stop a stream.
+func (s *ShortenerServer) ShortenStream(stream proto.URLShortenerService_ShortenStreamServer) error {
+	ctx := stream.Context()
+
+	//auth
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
+		s.Logger.Debug("UserID not found req ctx, will use `-1`")
+	}
+
+	//shorten
+	source := &streamSource{stream: stream}
+	err := logic.ShortenBulk(ctx, source, s.Conf.BaseAddress, s.Storage, userIDInt, s.Conf.BulkChunkSize,
+		func(results []entities.BulkResult) error {
+			for _, result := range results {
+				err := stream.Send(&proto.ShortenStreamResponse{
+					Index:         int32(result.Line),
+					CorrelationId: result.CorrelationID,
+					ShortenUrl:    result.ShortURL,
+					Error:         result.Error,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if st, ok := status.FromError(err); ok {
+			return st.Err()
+		}
+		s.Logger.Errorf("ShortenStream error: %v", err)
+		return status.Error(codes.Internal, "Internal Server Error")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestShortenerServer_ShortenStream(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+	issued, err := server.client.IssueToken(ctx, &emptypb.Empty{})
+	require.NoError(t, err, "error while preparing a user")
+
+	stream, err := server.client.ShortenStream(withToken(ctx, issued.Token))
+	require.NoError(t, err)
+	urls := []*proto.ShortenBatchRequest_URL{
+		{CorrelationId: "1", OriginalUrl: "https://ya.ru/"},
+		{CorrelationId: "2", OriginalUrl: "https://example.com/", MaxClicks: -1},
+		{CorrelationId: "3", OriginalUrl: "https://go.dev/"},
+	}
+	for _, url := range urls {
+		require.NoError(t, stream.Send(url))
+	}
+	require.NoError(t, stream.CloseSend())
+
+	results := make(map[string]*proto.ShortenStreamResponse)
+	for {
+		result, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		results[result.CorrelationId] = result
+	}
+	require.Len(t, results, len(urls))
+	for i, url := range urls {
+		assert.Equal(t, int32(i+1), results[url.CorrelationId].Index)
+	}
+	assert.NotEmpty(t, results["1"].ShortenUrl)
+	assert.Empty(t, results["1"].Error)
+	assert.Empty(t, results["2"].ShortenUrl)
+	assert.NotEmpty(t, results["2"].Error, "a URL with bad params has to get an error")
+	assert.NotEmpty(t, results["3"].ShortenUrl)
+
+	//URLs are saved for a user of a stream
+	saved, err := server.client.UserURLs(withToken(ctx, issued.Token), &proto.UserURLsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, saved.Urls, 2)
+}
@@ -0,0 +1,54 @@
+package grpchandlers
+
+import (
+	"errors"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamUserURLs sends user`s URLs one by one. URLs are read from a storage by pages of req.Limit URLs,
+// so only one page is kept in memory. Req.Cursor can be used to continue an interrupted stream.
+func (s *ShortenerServer) StreamUserURLs(req *proto.UserURLsRequest, stream proto.URLShortenerService_StreamUserURLsServer) error {
+	ctx := stream.Context()
+
+	//auth
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
+		s.Logger.Debug("UserID not found req ctx")
+		return status.Errorf(codes.Unauthenticated, "User ID not found")
+	}
+
+	//send pages
+	query := entities.URLsPageQuery{
+		Limit:            int(req.Limit),
+		SortBy:           req.SortBy,
+		Descending:       req.Descending,
+		IncludeDeleted:   req.IncludeDeleted,
+		OriginalContains: req.OriginalContains,
+	}
+	cursor := req.Cursor
+	for {
+		page, err := logic.GetUsersURLsPage(ctx, s.Storage, s.Conf.BaseAddress, userIDInt, cursor, query)
+		if errors.Is(err, logic.ErrBadURLParams()) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		} else if err != nil {
+			s.Logger.Errorf("GetUserURLs error: %v", err)
+			return status.Errorf(codes.Internal, "Internal server error")
+		}
+
+		for _, u := range page.URLs {
+			err = stream.Send(userURLToResponse(u))
+			if err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,76 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// receiveURLs reads URLs of a StreamUserURLs call until its end.
+func receiveURLs(ctx context.Context, client proto.URLShortenerServiceClient, req *proto.UserURLsRequest) ([]string, error) {
+	stream, err := client.StreamUserURLs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	shorts := make([]string, 0)
+	for {
+		url, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return shorts, nil
+		} else if err != nil {
+			return nil, err
+		}
+		shorts = append(shorts, url.Short)
+	}
+}
+
+func TestShortenerServer_StreamUserURLs(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+	issued, err := server.client.IssueToken(ctx, &emptypb.Empty{})
+	require.NoError(t, err, "error while preparing a user")
+	userCtx := withToken(ctx, issued.Token)
+	for _, original := range []string{"https://ya.ru/", "https://example.com/", "https://go.dev/"} {
+		_, err = server.client.Shorten(userCtx, &proto.ShortenRequest{OriginalUrl: original})
+		require.NoError(t, err, "error while preparing URLs")
+	}
+	page, err := server.client.UserURLs(userCtx, &proto.UserURLsRequest{})
+	require.NoError(t, err)
+	require.Len(t, page.Urls, 3)
+	want := make([]string, 0, len(page.Urls))
+	for _, url := range page.Urls {
+		want = append(want, url.Short)
+	}
+
+	t.Run("pages are streamed one by one", func(t *testing.T) {
+		got, err := receiveURLs(userCtx, server.client, &proto.UserURLsRequest{Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("stream continues from a cursor", func(t *testing.T) {
+		first, err := server.client.UserURLs(userCtx, &proto.UserURLsRequest{Limit: 1})
+		require.NoError(t, err)
+		got, err := receiveURLs(userCtx, server.client, &proto.UserURLsRequest{Limit: 1, Cursor: first.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, want[1:], got)
+	})
+
+	t.Run("bad cursor", func(t *testing.T) {
+		_, err := receiveURLs(userCtx, server.client, &proto.UserURLsRequest{Cursor: "bad cursor"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("anonymous user", func(t *testing.T) {
+		_, err := receiveURLs(ctx, server.client, &proto.UserURLsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
@@ -0,0 +1,61 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *ShortenerServer) UpdateURL(ctx context.Context, req *proto.UpdateURLRequest) (*emptypb.Empty, error) {
+	//auth
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
+		s.Logger.Debug("UserID not found req ctx")
+		return nil, status.Errorf(codes.Unauthenticated, "User ID not found")
+	}
+
+	//update
+	update := entities.URLUpdate{
+		Title:          req.Title,
+		Notes:          req.Notes,
+		StickyVariants: req.StickyVariants,
+	}
+	if req.OriginalUrl != "" {
+		update.OriginalURL = &req.OriginalUrl
+	}
+	if req.SetTags {
+		tags := req.Tags
+		if tags == nil {
+			tags = make([]string, 0)
+		}
+		update.Tags = &tags
+	}
+	if req.SetRules {
+		rules := rulesFromRequest(req.Rules)
+		update.Rules = &rules
+	}
+	if req.SetVariants {
+		variants := variantsFromRequest(req.Variants)
+		update.Variants = &variants
+	}
+	err := logic.UpdateURL(ctx, s.Storage, userIDInt, req.ShortUrl, update)
+	alrExistsErr := &databases.AlreadyExistsError{}
+	switch {
+	case errors.As(err, &alrExistsErr):
+		return nil, alreadyExistsError(s.Conf.BaseAddress + "/" + alrExistsErr.ShortURL)
+	case errors.Is(err, logic.ErrBadURLParams()):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, databases.ErrURLNotFound()):
+		return nil, status.Error(codes.NotFound, "URL not found")
+	case err != nil:
+		s.Logger.Errorf("UpdateURL err: %v", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,58 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/gRPC/proto"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *ShortenerServer) URLStats(ctx context.Context, req *proto.URLStatsRequest) (*proto.URLStatsResponse, error) {
+	//auth
+	userIDInt, ok := logic.UserIDFromContext(ctx)
+	if !ok {
+		s.Logger.Debug("UserID not found req ctx")
+		return nil, status.Errorf(codes.Unauthenticated, "User ID not found")
+	}
+
+	//get stats
+	stats, err := logic.GetURLStats(ctx, s.Storage, req.ShortUrl, userIDInt)
+	if errors.Is(err, logic.ErrNotAnOwner()) {
+		return nil, status.Error(codes.NotFound, "URL not found")
+	} else if err != nil {
+		s.Logger.Debugf("URL stats error: %v", err)
+		return nil, status.Error(codes.NotFound, "URL not found")
+	}
+
+	//response
+	res := &proto.URLStatsResponse{
+		ShortUrl:       stats.ShortURL,
+		TotalClicks:    uint64(stats.TotalClicks),
+		UniqueVisitors: uint64(stats.UniqueVisitors),
+		ClicksPerDay:   make([]*proto.URLStatsResponse_DayClicks, 0, len(stats.ClicksPerDay)),
+		TopReferrers:   make([]*proto.URLStatsResponse_ReferrerClicks, 0, len(stats.TopReferrers)),
+		Variants:       make([]*proto.URLStatsResponse_VariantClicks, 0, len(stats.Variants)),
+	}
+	for _, day := range stats.ClicksPerDay {
+		res.ClicksPerDay = append(res.ClicksPerDay, &proto.URLStatsResponse_DayClicks{
+			Day:    day.Day,
+			Clicks: uint64(day.Clicks),
+		})
+	}
+	for _, referrer := range stats.TopReferrers {
+		res.TopReferrers = append(res.TopReferrers, &proto.URLStatsResponse_ReferrerClicks{
+			Referrer: referrer.Referrer,
+			Clicks:   uint64(referrer.Clicks),
+		})
+	}
+	for _, variant := range stats.Variants {
+		res.Variants = append(res.Variants, &proto.URLStatsResponse_VariantClicks{
+			Variant:        variant.Variant,
+			Clicks:         uint64(variant.Clicks),
+			UniqueVisitors: uint64(variant.UniqueVisitors),
+		})
+	}
+	return res, nil
+}
@@ -2,6 +2,8 @@ package interceptors
 
 import (
 	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -9,14 +11,39 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-type contextKey string
-
+// UserIDContextKey is a key to get a userID from context values. Use logic.UserIDFromContext to read it.
 const (
-	UserIDContextKey contextKey = "userID"
-	NoUserIDValue    int        = -1
+	UserIDContextKey     = logic.UserIDContextKey
+	NoUserIDValue    int = logic.NoUserID
 )
 
-func NewUnaryAuthInterceptor(jh *secure.JWTHelper) grpc.UnaryServerInterceptor {
+// AuthTokenMetadata is a response header metadata key with a new access token (JWT) of a user.
+// "authorization" is a request metadata, so tokens are not sent back in it (HTTP uses an "X-Auth-Token" header too).
+const AuthTokenMetadata = "x-auth-token"
+
+// APIKeyMetadata is a metadata key with an API key. API keys can be sent in an "authorization" ("Bearer <key>") metadata too.
+const APIKeyMetadata = "x-api-key"
+
+// methodScopes are API key scopes required by methods. Methods witch are absent here don`t need scopes.
+var methodScopes = map[string]string{
+	"/grpc_server.URLShortenerService/Shorten":        logic.ScopeShorten,
+	"/grpc_server.URLShortenerService/ShortenBatch":   logic.ScopeShorten,
+	"/grpc_server.URLShortenerService/ShortenStream":  logic.ScopeShorten,
+	"/grpc_server.URLShortenerService/UpdateURL":      logic.ScopeShorten,
+	"/grpc_server.URLShortenerService/UserURLs":       logic.ScopeRead,
+	"/grpc_server.URLShortenerService/StreamUserURLs": logic.ScopeRead,
+	"/grpc_server.URLShortenerService/SearchURLs":     logic.ScopeRead,
+	"/grpc_server.URLShortenerService/URLStats":       logic.ScopeRead,
+	"/grpc_server.URLShortenerService/DeleteURLs":     logic.ScopeDelete,
+}
+
+// publicMethods are methods witch don`t need authentication. Credentials sent to them are ignored
+// (RefreshToken is called with an expired token, so it can`t get Unauthenticated because of it).
+var publicMethods = map[string]struct{}{
+	"/grpc_server.URLShortenerService/RefreshToken": {},
+}
+
+func NewUnaryAuthInterceptor(sessions *logic.Sessions, keys *logic.APIKeys) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -24,19 +51,115 @@ func NewUnaryAuthInterceptor(jh *secure.JWTHelper) grpc.UnaryServerInterceptor {
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if ok {
-			values := md.Get("token")
-			if len(values) > 0 {
-				token := values[0]
-				userID, err := jh.GetUserID(token)
-				if err != nil {
-					return nil, status.Errorf(codes.Unauthenticated, "invalid token")
-				}
-				return handler(context.WithValue(ctx, UserIDContextKey, userID), req)
-			}
+		ctx, err := authContext(ctx, info.FullMethod, sessions, keys)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamAuthInterceptor works like NewUnaryAuthInterceptor, but for streaming calls.
+func NewStreamAuthInterceptor(sessions *logic.Sessions, keys *logic.APIKeys) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+
+		ctx, err := authContext(ss.Context(), info.FullMethod, sessions, keys)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authContext adds a user ID from an API key (an APIKeyMetadata or an "authorization" ("Bearer <key>") metadata)
+// or from a JWT (an "authorization" ("Bearer <jwt>") or a "token" metadata) to a context (NoUserIDValue if there is no token).
+// Returns an Unauthenticated error if a token is not valid (or its session was revoked)
+// and a PermissionDenied error if an API key has no scope for a method.
+// A JWT close to expiry is reissued in an AuthTokenMetadata header metadata of a response.
+// Calls of publicMethods are always anonymous.
+func authContext(ctx context.Context, method string, sessions *logic.Sessions, keys *logic.APIKeys) (context.Context, error) {
+	if _, ok := publicMethods[method]; ok {
+		return logic.ContextWithUserID(ctx, NoUserIDValue), nil
+	}
+	if key, ok := apiKeyFromMetadata(ctx); ok {
+		apiKey, err := keys.Check(ctx, key)
+		if errors.Is(err, logic.ErrInvalidAPIKey()) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid API key")
+		} else if err != nil {
+			return nil, status.Errorf(codes.Internal, "Internal server error")
+		}
+		ctx = logic.ContextWithAPIKey(ctx, apiKey)
+		if scope, ok := methodScopes[method]; ok && !logic.HasScope(ctx, scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key has no `%s` scope", scope)
+		}
+		return ctx, nil
+	}
+
+	token, ok := tokenFromMetadata(ctx)
+	if !ok {
+		return logic.ContextWithUserID(ctx, NoUserIDValue), nil
+	}
+	auth, err := sessions.Authenticate(ctx, token)
+	if errors.Is(err, logic.ErrInvalidSession()) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "Internal server error")
+	}
+	if auth.Reissued != nil {
+		//a call works with an old token anyway, so a failed header sending is not an error
+		_ = grpc.SetHeader(ctx, metadata.Pairs(AuthTokenMetadata, auth.Reissued.AccessToken))
+	}
+	return logic.ContextWithSession(ctx, auth.UserID, auth.SessionID), nil
+}
+
+// apiKeyFromMetadata returns an API key from an incoming metadata. An APIKeyMetadata wins over an "authorization" metadata.
+func apiKeyFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(APIKeyMetadata)
+	if len(values) > 0 && values[0] != "" {
+		return values[0], true
+	}
+	for _, authorization := range md.Get("authorization") {
+		if token, ok := secure.BearerToken(authorization); ok && logic.IsAPIKey(token) {
+			return token, true
 		}
+	}
+	return "", false
+}
 
-		return handler(context.WithValue(ctx, UserIDContextKey, -1), req)
+// tokenFromMetadata returns a JWT from an incoming metadata. An "authorization" metadata wins over a "token" one.
+func tokenFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, authorization := range md.Get("authorization") {
+		if token, ok := secure.BearerToken(authorization); ok {
+			return token, true
+		}
+	}
+	values := md.Get("token")
+	if len(values) > 0 {
+		return values[0], true
 	}
+	return "", false
+}
+
+// contextStream is a grpc.ServerStream with a changed context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns a changed context.
+func (s *contextStream) Context() context.Context {
+	return s.ctx
 }
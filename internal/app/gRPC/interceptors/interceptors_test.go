@@ -0,0 +1,246 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	statsMethod          = "/grpc_server.URLShortenerService/Stats"
+	streamUserURLsMethod = "/grpc_server.URLShortenerService/StreamUserURLs"
+	refreshTokenMethod   = "/grpc_server.URLShortenerService/RefreshToken"
+)
+
+// newStreamConn starts a gRPC server with stream interceptors on an in-memory connection and returns a client connection.
+// The server answers every streaming call with a user ID from a call context.
+func newStreamConn(t *testing.T, interceptors ...grpc.StreamServerInterceptor) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.ChainStreamInterceptor(interceptors...),
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			userID, ok := logic.UserIDFromContext(stream.Context())
+			if !ok {
+				userID = NoUserIDValue
+			}
+			return stream.SendMsg(wrapperspb.Int64(int64(userID)))
+		}),
+	)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err, "error while connecting to a test server")
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+// callStream makes a streaming call and returns a user ID sent by a server and response header metadata.
+func callStream(ctx context.Context, conn *grpc.ClientConn, method string) (int, metadata.MD, error) {
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, method)
+	if err != nil {
+		return 0, nil, err
+	}
+	err = stream.SendMsg(&emptypb.Empty{})
+	if err != nil {
+		return 0, nil, err
+	}
+	err = stream.CloseSend()
+	if err != nil {
+		return 0, nil, err
+	}
+	userID := &wrapperspb.Int64Value{}
+	err = stream.RecvMsg(userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	header, err := stream.Header()
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(userID.Value), header, nil
+}
+
+func TestNewStreamIPInterceptor(t *testing.T) {
+	_, trustedSubnet, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+	conn := newStreamConn(t, NewStreamIPInterceptor(trustedSubnet))
+
+	tests := []struct {
+		name     string
+		method   string
+		ip       string
+		wantCode codes.Code
+	}{
+		{
+			name:     "trusted IP",
+			method:   statsMethod,
+			ip:       "192.168.1.10",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "not trusted IP",
+			method:   statsMethod,
+			ip:       "10.0.0.1",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "no IP",
+			method:   statsMethod,
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "bad IP",
+			method:   statsMethod,
+			ip:       "not an IP",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "not trusted method",
+			method:   streamUserURLsMethod,
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.ip != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "X-Real-IP", tt.ip)
+			}
+			_, _, err := callStream(ctx, conn, tt.method)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestNewStreamAuthInterceptor(t *testing.T) {
+	ctx := context.Background()
+	storage := databases.NewJustAMap()
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	sessions := logic.NewSessions(storage, jh, config.Config{})
+	keys := logic.NewAPIKeys(storage)
+	conn := newStreamConn(t, NewStreamAuthInterceptor(sessions, keys))
+
+	userID, err := storage.CreateUser(ctx)
+	require.NoError(t, err, "error while preparing a storage")
+	tokens, err := sessions.Start(ctx, userID)
+	require.NoError(t, err, "error while preparing a session")
+	readKey, _, err := keys.Create(ctx, userID, "read", []string{logic.ScopeRead}, nil)
+	require.NoError(t, err, "error while preparing an API key")
+	shortenKey, _, err := keys.Create(ctx, userID, "shorten", []string{logic.ScopeShorten}, nil)
+	require.NoError(t, err, "error while preparing an API key")
+
+	//a token with less than a half of its lifetime left
+	shortJH := secure.NewJWTHelper("testSecretKey", 5)
+	shortJH.TokenExp = time.Minute
+	oldToken, err := shortJH.BuildSessionJWTString(userID, tokens.SessionID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		md         []string
+		wantCode   codes.Code
+		wantUserID int
+	}{
+		{
+			name:       "anonymous",
+			method:     streamUserURLsMethod,
+			wantUserID: NoUserIDValue,
+		},
+		{
+			name:       "bearer token",
+			method:     streamUserURLsMethod,
+			md:         []string{"authorization", "Bearer " + tokens.AccessToken},
+			wantUserID: userID,
+		},
+		{
+			name:       "token metadata",
+			method:     streamUserURLsMethod,
+			md:         []string{"token", tokens.AccessToken},
+			wantUserID: userID,
+		},
+		{
+			name:     "not valid token",
+			method:   streamUserURLsMethod,
+			md:       []string{"authorization", "Bearer not a token"},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:       "API key with a scope",
+			method:     streamUserURLsMethod,
+			md:         []string{APIKeyMetadata, readKey},
+			wantUserID: userID,
+		},
+		{
+			name:     "API key without a scope",
+			method:   streamUserURLsMethod,
+			md:       []string{"authorization", "Bearer " + shortenKey},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "not valid API key",
+			method:   streamUserURLsMethod,
+			md:       []string{APIKeyMetadata, logic.APIKeyPrefix + "unknown"},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:       "public method ignores a bad token",
+			method:     refreshTokenMethod,
+			md:         []string{"authorization", "Bearer not a token"},
+			wantUserID: NoUserIDValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCtx := metadata.AppendToOutgoingContext(ctx, tt.md...)
+			gotUserID, _, err := callStream(callCtx, conn, tt.method)
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				return
+			}
+			assert.Equal(t, tt.wantUserID, gotUserID)
+		})
+	}
+
+	t.Run("token close to expiry is reissued", func(t *testing.T) {
+		callCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+oldToken)
+		gotUserID, header, err := callStream(callCtx, conn, streamUserURLsMethod)
+		require.NoError(t, err)
+		assert.Equal(t, userID, gotUserID)
+
+		assert.Empty(t, header.Get("authorization"), "a token must not be sent in a request metadata")
+		values := header.Get(AuthTokenMetadata)
+		require.Len(t, values, 1, "a new token expected")
+		newToken := values[0]
+		assert.NotEqual(t, oldToken, newToken)
+		claims, err := jh.GetClaims(newToken)
+		require.NoError(t, err)
+		assert.Equal(t, tokens.SessionID, claims.SessionID)
+	})
+}
@@ -9,6 +9,11 @@ import (
 	"net"
 )
 
+// trustedMethods can be called only from a trusted subnet.
+var trustedMethods = map[string]struct{}{
+	"/grpc_server.URLShortenerService/Stats": {},
+}
+
 func NewIPInterceptor(allowedNet *net.IPNet) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -17,27 +22,55 @@ func NewIPInterceptor(allowedNet *net.IPNet) grpc.UnaryServerInterceptor {
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
 
-		if info.FullMethod == "/grpc_server.URLShortenerService/Stats" {
-			md, ok := metadata.FromIncomingContext(ctx)
-			if !ok {
-				return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
-			}
-
-			values := md.Get("X-Real-IP")
-			if len(values) == 0 {
-				return nil, status.Errorf(codes.Unauthenticated, "missing IP address")
-			}
-
-			ip := net.ParseIP(values[0])
-			if ip == nil {
-				return nil, status.Errorf(codes.InvalidArgument, "invalid IP address")
-			}
-
-			if !allowedNet.Contains(ip) {
-				return nil, status.Errorf(codes.PermissionDenied, "access denied")
-			}
+		err := checkIP(ctx, info.FullMethod, allowedNet)
+		if err != nil {
+			return nil, err
 		}
-
 		return handler(ctx, req)
 	}
 }
+
+// NewStreamIPInterceptor works like NewIPInterceptor, but for streaming calls.
+func NewStreamIPInterceptor(allowedNet *net.IPNet) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+
+		err := checkIP(ss.Context(), info.FullMethod, allowedNet)
+		if err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// checkIP returns an error if a method can be called only from a trusted subnet
+// and an IP from "X-Real-IP" metadata is not in allowedNet.
+func checkIP(ctx context.Context, fullMethod string, allowedNet *net.IPNet) error {
+	if _, ok := trustedMethods[fullMethod]; !ok {
+		return nil
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get("X-Real-IP")
+	if len(values) == 0 {
+		return status.Errorf(codes.Unauthenticated, "missing IP address")
+	}
+
+	ip := net.ParseIP(values[0])
+	if ip == nil {
+		return status.Errorf(codes.InvalidArgument, "invalid IP address")
+	}
+
+	if !allowedNet.Contains(ip) {
+		return status.Errorf(codes.PermissionDenied, "access denied")
+	}
+	return nil
+}
@@ -27,6 +27,20 @@ const (
 	URLShortenerService_ShortenBatch_FullMethodName   = "/grpc_server.URLShortenerService/ShortenBatch"
 	URLShortenerService_Stats_FullMethodName          = "/grpc_server.URLShortenerService/Stats"
 	URLShortenerService_UserURLs_FullMethodName       = "/grpc_server.URLShortenerService/UserURLs"
+	URLShortenerService_URLStats_FullMethodName       = "/grpc_server.URLShortenerService/URLStats"
+	URLShortenerService_UpdateURL_FullMethodName      = "/grpc_server.URLShortenerService/UpdateURL"
+	URLShortenerService_SearchURLs_FullMethodName     = "/grpc_server.URLShortenerService/SearchURLs"
+	URLShortenerService_QRCode_FullMethodName         = "/grpc_server.URLShortenerService/QRCode"
+	URLShortenerService_ShortenStream_FullMethodName  = "/grpc_server.URLShortenerService/ShortenStream"
+	URLShortenerService_StreamUserURLs_FullMethodName = "/grpc_server.URLShortenerService/StreamUserURLs"
+	URLShortenerService_IssueToken_FullMethodName     = "/grpc_server.URLShortenerService/IssueToken"
+	URLShortenerService_RefreshToken_FullMethodName   = "/grpc_server.URLShortenerService/RefreshToken"
+	URLShortenerService_LogOut_FullMethodName         = "/grpc_server.URLShortenerService/LogOut"
+	URLShortenerService_SignUp_FullMethodName         = "/grpc_server.URLShortenerService/SignUp"
+	URLShortenerService_LogIn_FullMethodName          = "/grpc_server.URLShortenerService/LogIn"
+	URLShortenerService_CreateAPIKey_FullMethodName   = "/grpc_server.URLShortenerService/CreateAPIKey"
+	URLShortenerService_ListAPIKeys_FullMethodName    = "/grpc_server.URLShortenerService/ListAPIKeys"
+	URLShortenerService_RevokeAPIKey_FullMethodName   = "/grpc_server.URLShortenerService/RevokeAPIKey"
 )
 
 // URLShortenerServiceClient is the client API for URLShortenerService service.
@@ -39,7 +53,21 @@ type URLShortenerServiceClient interface {
 	Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error)
 	ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error)
 	Stats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StatsResponse, error)
-	UserURLs(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*UsersURLsResponse, error)
+	UserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (*UsersURLsResponse, error)
+	URLStats(ctx context.Context, in *URLStatsRequest, opts ...grpc.CallOption) (*URLStatsResponse, error)
+	UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	SearchURLs(ctx context.Context, in *SearchURLsRequest, opts ...grpc.CallOption) (*UsersURLsResponse, error)
+	QRCode(ctx context.Context, in *QRCodeRequest, opts ...grpc.CallOption) (*QRCodeResponse, error)
+	ShortenStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ShortenBatchRequest_URL, ShortenStreamResponse], error)
+	StreamUserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UsersURLsResponse_URL], error)
+	IssueToken(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*IssueTokenResponse, error)
+	RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*IssueTokenResponse, error)
+	LogOut(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	SignUp(ctx context.Context, in *CredentialsRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	LogIn(ctx context.Context, in *CredentialsRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*APIKeysResponse, error)
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
 type uRLShortenerServiceClient struct {
@@ -110,7 +138,7 @@ func (c *uRLShortenerServiceClient) Stats(ctx context.Context, in *empty.Empty,
 	return out, nil
 }
 
-func (c *uRLShortenerServiceClient) UserURLs(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*UsersURLsResponse, error) {
+func (c *uRLShortenerServiceClient) UserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (*UsersURLsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(UsersURLsResponse)
 	err := c.cc.Invoke(ctx, URLShortenerService_UserURLs_FullMethodName, in, out, cOpts...)
@@ -120,6 +148,158 @@ func (c *uRLShortenerServiceClient) UserURLs(ctx context.Context, in *empty.Empt
 	return out, nil
 }
 
+func (c *uRLShortenerServiceClient) URLStats(ctx context.Context, in *URLStatsRequest, opts ...grpc.CallOption) (*URLStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(URLStatsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_URLStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, URLShortenerService_UpdateURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) SearchURLs(ctx context.Context, in *SearchURLsRequest, opts ...grpc.CallOption) (*UsersURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsersURLsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_SearchURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) QRCode(ctx context.Context, in *QRCodeRequest, opts ...grpc.CallOption) (*QRCodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QRCodeResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_QRCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ShortenStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ShortenBatchRequest_URL, ShortenStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortenerService_ServiceDesc.Streams[0], URLShortenerService_ShortenStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ShortenBatchRequest_URL, ShortenStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_ShortenStreamClient = grpc.BidiStreamingClient[ShortenBatchRequest_URL, ShortenStreamResponse]
+
+func (c *uRLShortenerServiceClient) StreamUserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UsersURLsResponse_URL], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortenerService_ServiceDesc.Streams[1], URLShortenerService_StreamUserURLs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UserURLsRequest, UsersURLsResponse_URL]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_StreamUserURLsClient = grpc.ServerStreamingClient[UsersURLsResponse_URL]
+
+func (c *uRLShortenerServiceClient) IssueToken(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*IssueTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueTokenResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_IssueToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*IssueTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueTokenResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_RefreshToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) LogOut(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, URLShortenerService_LogOut_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) SignUp(ctx context.Context, in *CredentialsRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_SignUp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) LogIn(ctx context.Context, in *CredentialsRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_LogIn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ListAPIKeys(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*APIKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(APIKeysResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ListAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, URLShortenerService_RevokeAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLShortenerServiceServer is the server API for URLShortenerService service.
 // All implementations must embed UnimplementedURLShortenerServiceServer
 // for forward compatibility.
@@ -130,7 +310,21 @@ type URLShortenerServiceServer interface {
 	Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error)
 	ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error)
 	Stats(context.Context, *empty.Empty) (*StatsResponse, error)
-	UserURLs(context.Context, *empty.Empty) (*UsersURLsResponse, error)
+	UserURLs(context.Context, *UserURLsRequest) (*UsersURLsResponse, error)
+	URLStats(context.Context, *URLStatsRequest) (*URLStatsResponse, error)
+	UpdateURL(context.Context, *UpdateURLRequest) (*empty.Empty, error)
+	SearchURLs(context.Context, *SearchURLsRequest) (*UsersURLsResponse, error)
+	QRCode(context.Context, *QRCodeRequest) (*QRCodeResponse, error)
+	ShortenStream(grpc.BidiStreamingServer[ShortenBatchRequest_URL, ShortenStreamResponse]) error
+	StreamUserURLs(*UserURLsRequest, grpc.ServerStreamingServer[UsersURLsResponse_URL]) error
+	IssueToken(context.Context, *empty.Empty) (*IssueTokenResponse, error)
+	RefreshToken(context.Context, *RefreshTokenRequest) (*IssueTokenResponse, error)
+	LogOut(context.Context, *empty.Empty) (*empty.Empty, error)
+	SignUp(context.Context, *CredentialsRequest) (*AccountResponse, error)
+	LogIn(context.Context, *CredentialsRequest) (*AccountResponse, error)
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(context.Context, *empty.Empty) (*APIKeysResponse, error)
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*empty.Empty, error)
 	mustEmbedUnimplementedURLShortenerServiceServer()
 }
 
@@ -159,9 +353,51 @@ func (UnimplementedURLShortenerServiceServer) ShortenBatch(context.Context, *Sho
 func (UnimplementedURLShortenerServiceServer) Stats(context.Context, *empty.Empty) (*StatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
 }
-func (UnimplementedURLShortenerServiceServer) UserURLs(context.Context, *empty.Empty) (*UsersURLsResponse, error) {
+func (UnimplementedURLShortenerServiceServer) UserURLs(context.Context, *UserURLsRequest) (*UsersURLsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UserURLs not implemented")
 }
+func (UnimplementedURLShortenerServiceServer) URLStats(context.Context, *URLStatsRequest) (*URLStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method URLStats not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) UpdateURL(context.Context, *UpdateURLRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) SearchURLs(context.Context, *SearchURLsRequest) (*UsersURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchURLs not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) QRCode(context.Context, *QRCodeRequest) (*QRCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QRCode not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ShortenStream(grpc.BidiStreamingServer[ShortenBatchRequest_URL, ShortenStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ShortenStream not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) StreamUserURLs(*UserURLsRequest, grpc.ServerStreamingServer[UsersURLsResponse_URL]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamUserURLs not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) IssueToken(context.Context, *empty.Empty) (*IssueTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueToken not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) RefreshToken(context.Context, *RefreshTokenRequest) (*IssueTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshToken not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) LogOut(context.Context, *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogOut not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) SignUp(context.Context, *CredentialsRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignUp not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) LogIn(context.Context, *CredentialsRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogIn not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ListAPIKeys(context.Context, *empty.Empty) (*APIKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPIKeys not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
 func (UnimplementedURLShortenerServiceServer) mustEmbedUnimplementedURLShortenerServiceServer() {}
 func (UnimplementedURLShortenerServiceServer) testEmbeddedByValue()                             {}
 
@@ -292,7 +528,7 @@ func _URLShortenerService_Stats_Handler(srv interface{}, ctx context.Context, de
 }
 
 func _URLShortenerService_UserURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(UserURLsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -304,7 +540,241 @@ func _URLShortenerService_UserURLs_Handler(srv interface{}, ctx context.Context,
 		FullMethod: URLShortenerService_UserURLs_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(URLShortenerServiceServer).UserURLs(ctx, req.(*empty.Empty))
+		return srv.(URLShortenerServiceServer).UserURLs(ctx, req.(*UserURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_URLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(URLStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).URLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_URLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).URLStats(ctx, req.(*URLStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_UpdateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).UpdateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_UpdateURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).UpdateURL(ctx, req.(*UpdateURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_SearchURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).SearchURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_SearchURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).SearchURLs(ctx, req.(*SearchURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_QRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QRCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).QRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_QRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).QRCode(ctx, req.(*QRCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ShortenStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(URLShortenerServiceServer).ShortenStream(&grpc.GenericServerStream[ShortenBatchRequest_URL, ShortenStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_ShortenStreamServer = grpc.BidiStreamingServer[ShortenBatchRequest_URL, ShortenStreamResponse]
+
+func _URLShortenerService_StreamUserURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(UserURLsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(URLShortenerServiceServer).StreamUserURLs(m, &grpc.GenericServerStream[UserURLsRequest, UsersURLsResponse_URL]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_StreamUserURLsServer = grpc.ServerStreamingServer[UsersURLsResponse_URL]
+
+func _URLShortenerService_IssueToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).IssueToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_IssueToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).IssueToken(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_RefreshToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).RefreshToken(ctx, req.(*RefreshTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_LogOut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).LogOut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_LogOut_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).LogOut(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_SignUp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).SignUp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_SignUp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).SignUp(ctx, req.(*CredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_LogIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).LogIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_LogIn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).LogIn(ctx, req.(*CredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ListAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ListAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ListAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ListAPIKeys(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_RevokeAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -344,7 +814,67 @@ var URLShortenerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UserURLs",
 			Handler:    _URLShortenerService_UserURLs_Handler,
 		},
+		{
+			MethodName: "URLStats",
+			Handler:    _URLShortenerService_URLStats_Handler,
+		},
+		{
+			MethodName: "UpdateURL",
+			Handler:    _URLShortenerService_UpdateURL_Handler,
+		},
+		{
+			MethodName: "SearchURLs",
+			Handler:    _URLShortenerService_SearchURLs_Handler,
+		},
+		{
+			MethodName: "QRCode",
+			Handler:    _URLShortenerService_QRCode_Handler,
+		},
+		{
+			MethodName: "IssueToken",
+			Handler:    _URLShortenerService_IssueToken_Handler,
+		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _URLShortenerService_RefreshToken_Handler,
+		},
+		{
+			MethodName: "LogOut",
+			Handler:    _URLShortenerService_LogOut_Handler,
+		},
+		{
+			MethodName: "SignUp",
+			Handler:    _URLShortenerService_SignUp_Handler,
+		},
+		{
+			MethodName: "LogIn",
+			Handler:    _URLShortenerService_LogIn_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _URLShortenerService_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAPIKeys",
+			Handler:    _URLShortenerService_ListAPIKeys_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _URLShortenerService_RevokeAPIKey_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ShortenStream",
+			Handler:       _URLShortenerService_ShortenStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamUserURLs",
+			Handler:       _URLShortenerService_StreamUserURLs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/grpcServer.proto",
 }
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"go.uber.org/zap"
+)
+
+// APIKeysHandler is a handler struct. Use it`s ServeHTTP func.
+type APIKeysHandler struct {
+	APIKeys *logic.APIKeys
+	Log     zap.SugaredLogger
+}
+
+// ServeHTTP returns a JSON array with all API keys of a user (keys themselves and their hashes are never returned).
+// API keys can`t be managed using API keys, such requests get http.StatusForbidden.
+func (h *APIKeysHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	userID, ok := keysOwner(res, req)
+	if !ok {
+		return
+	}
+
+	keys, err := h.APIKeys.List(req.Context(), userID)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while getting API keys: %v", err)
+		return
+	}
+
+	resp, err := json.Marshal(keys)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while marshalling response: %v", err)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	_, err = res.Write(resp)
+	if err != nil {
+		h.Log.Errorf("Error while writing response: %v", err)
+	}
+}
+
+// keysOwner returns a user ID of a request witch manages API keys.
+// Writes http.StatusUnauthorized if there is no user and http.StatusForbidden if a request uses an API key.
+func keysOwner(res http.ResponseWriter, req *http.Request) (int, bool) {
+	userID, ok := logic.UserIDFromContext(req.Context())
+	if !ok {
+		res.WriteHeader(http.StatusUnauthorized)
+		return 0, false
+	}
+	if _, isKey := logic.APIKeyFromContext(req.Context()); isKey {
+		res.WriteHeader(http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
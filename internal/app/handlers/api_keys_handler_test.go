@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAPIKeyHandlers(t *testing.T) {
+	//test server building
+	conf := config.Config{
+		BaseAddress:   "http://localhost:8080",
+		ServerAddress: "localhost:8080",
+	}
+	URLStore := databases.NewJustAMap()
+	sugar := zaptest.NewLogger(t).Sugar()
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	sessions := logic.NewSessions(URLStore, jh, config.Config{})
+	r, err := NewRouter(conf, URLStore, *sugar, sessions, logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}),
+		logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
+	require.NoError(t, err, "error while creating a router in test")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	userID := 7
+	tokens, err := sessions.Start(context.Background(), userID)
+	require.NoError(t, err)
+	token := tokens.AccessToken
+
+	do := func(t *testing.T, method string, path string, body string, header string, value string) (int, []byte) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err, "Error while creating a request")
+		req.Header.Set(header, value)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "Error while making a request")
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, respBody
+	}
+	withJWT := func(t *testing.T, method string, path string, body string) (int, []byte) {
+		return do(t, method, path, body, "Authorization", "Bearer "+token)
+	}
+
+	//create a key
+	code, body := withJWT(t, http.MethodPost, "/api/user/keys", `{"name": "CI", "scopes": ["shorten", "read"]}`)
+	require.Equal(t, http.StatusCreated, code)
+	created := struct {
+		Key string `json:"key"`
+		entities.APIKey
+	}{}
+	require.NoError(t, json.Unmarshal(body, &created))
+	assert.True(t, logic.IsAPIKey(created.Key))
+	assert.Equal(t, "CI", created.Name)
+	assert.Empty(t, created.KeyHash)
+
+	t.Run("bad params", func(t *testing.T) {
+		code, _ := withJWT(t, http.MethodPost, "/api/user/keys", `{"scopes": ["admin"]}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("key with scopes", func(t *testing.T) {
+		code, _ := do(t, http.MethodPost, "/api/shorten", `{"url": "https://example.com"}`, middlewares.APIKeyHeader, created.Key)
+		assert.Equal(t, http.StatusCreated, code)
+		code, _ = do(t, http.MethodGet, "/api/user/urls", "", "Authorization", "Bearer "+created.Key)
+		assert.Equal(t, http.StatusOK, code, "URLs shortened with a key belong to a key owner")
+		code, _ = do(t, http.MethodDelete, "/api/user/urls", `["abc"]`, middlewares.APIKeyHeader, created.Key)
+		assert.Equal(t, http.StatusForbidden, code, "key has no delete scope")
+		code, _ = do(t, http.MethodGet, "/api/user/keys", "", middlewares.APIKeyHeader, created.Key)
+		assert.Equal(t, http.StatusForbidden, code, "keys can`t be managed with keys")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		code, body := withJWT(t, http.MethodGet, "/api/user/keys", "")
+		require.Equal(t, http.StatusOK, code)
+		keys := make([]entities.APIKey, 0)
+		require.NoError(t, json.Unmarshal(body, &keys))
+		require.Len(t, keys, 1)
+		assert.Equal(t, created.ID, keys[0].ID)
+		assert.NotNil(t, keys[0].LastUsedAt)
+		assert.NotContains(t, string(body), created.Key)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		code, _ := withJWT(t, http.MethodDelete, "/api/user/keys/unknown", "")
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = withJWT(t, http.MethodDelete, "/api/user/keys/"+created.ID, "")
+		assert.Equal(t, http.StatusNoContent, code)
+		code, _ = do(t, http.MethodPost, "/api/shorten", `{"url": "https://example.com/2"}`, middlewares.APIKeyHeader, created.Key)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"go.uber.org/zap"
+)
+
+// CreateAPIKeyHandler is a handler struct. Use it`s ServeHTTP func.
+type CreateAPIKeyHandler struct {
+	APIKeys *logic.APIKeys
+	Log     zap.SugaredLogger
+}
+
+// ServeHTTP creates a new API key of a user. Request body is a JSON with optional "name", "scopes"
+// (logic.ScopeShorten, logic.ScopeRead, logic.ScopeDelete, all scopes if empty) and "expires_at" (RFC 3339) fields.
+// Response is a JSON with a description of a key and a "key" field with a key itself (it is shown only once).
+// API keys can`t be managed using API keys, such requests get http.StatusForbidden.
+func (h *CreateAPIKeyHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	userID, ok := keysOwner(res, req)
+	if !ok {
+		return
+	}
+
+	reqData := struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}{}
+	err := json.NewDecoder(req.Body).Decode(&reqData)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Error while decoding req body: %v", err)
+		return
+	}
+
+	key, apiKey, err := h.APIKeys.Create(req.Context(), userID, reqData.Name, reqData.Scopes, reqData.ExpiresAt)
+	if errors.Is(err, logic.ErrBadAPIKeyParams()) {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Bad API key params: %v", err)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while creating API key: %v", err)
+		return
+	}
+
+	resData := struct {
+		Key string `json:"key"`
+		entities.APIKey
+	}{
+		Key:    key,
+		APIKey: apiKey,
+	}
+	resp, err := json.Marshal(resData)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while marshalling response: %v", err)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusCreated)
+	_, err = res.Write(resp)
+	if err != nil {
+		h.Log.Errorf("Error while writing response: %v", err)
+	}
+}
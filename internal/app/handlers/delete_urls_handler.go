@@ -7,7 +7,6 @@ import (
 	"net/http"
 
 	"github.com/Lesnoi3283/url_shortener/config"
-	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
 	"go.uber.org/zap"
 )
 
@@ -33,15 +32,14 @@ func (h *DeleteURLsHandler) ServeHTTP(res http.ResponseWriter, req *http.Request
 		return
 	}
 
-	userIDFromContext := req.Context().Value(middlewares.UserIDContextKey)
-	userID, ok := (userIDFromContext).(int)
-	if userIDFromContext == nil || !ok {
+	userID, ok := logic.UserIDFromContext(req.Context())
+	if !ok {
 		res.WriteHeader(http.StatusUnauthorized)
 		h.Log.Error("UserID is nil")
 		return
 	}
 
-	err = logic.DeleteURLs(userID, shortURLs, h.URLStorage)
+	err = logic.DeleteURLs(userID, shortURLs, h.URLStorage, h.Log)
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
 		h.Log.Error("Error while deleting urls", zap.Error(err))
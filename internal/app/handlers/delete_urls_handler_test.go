@@ -58,7 +58,7 @@ func TestDeleteURLsHandler_ServeHTTP(t *testing.T) {
 				URLStorage: func() logic.URLStorageInterface {
 					URLsToDeleteChan := make(chan string)
 					storage := mocks.NewMockURLStorageInterface(c)
-					storage.EXPECT().DeleteBatchWithUserID(coorectUserID).Return(URLsToDeleteChan, nil)
+					storage.EXPECT().DeleteBatchWithUserID(coorectUserID).Return(URLsToDeleteChan, make(chan error, 1), nil)
 					return storage
 				}(),
 				Log: *sugar,
@@ -126,7 +126,7 @@ func BenchmarkDeleteURLsHandler_ServeHTTP(b *testing.B) {
 	defer c.Finish()
 
 	storage := mocks.NewMockURLStorageInterface(c)
-	storage.EXPECT().DeleteBatchWithUserID(coorectUserID).Return(make(chan string), nil).AnyTimes()
+	storage.EXPECT().DeleteBatchWithUserID(coorectUserID).Return(make(chan string), make(chan error, 1), nil).AnyTimes()
 
 	//prepare handler
 	h := DeleteURLsHandler{
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"go.uber.org/zap"
+)
+
+// LogInHandler is a handler struct. Use it`s ServeHTTP func.
+type LogInHandler struct {
+	Accounts *logic.Accounts
+	Sessions *logic.Sessions
+	Proxies  logic.TrustedProxies
+	Log      zap.SugaredLogger
+}
+
+// ServeHTTP logs a user in. Request body is a JSON with "login" and "password" fields.
+// URLs of an anonymous user (from a cookie or a bearer token) are claimed by an account.
+// Response is a JSON with tokens of a new session of an account (see writeAuthResult). Wrong credentials get http.StatusUnauthorized,
+// too many failed attempts (of a login from a client IP or of a client IP) get http.StatusTooManyRequests.
+func (h *LogInHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	reqData := credentials{}
+	err := json.NewDecoder(req.Body).Decode(&reqData)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Error while decoding req body: %v", err)
+		return
+	}
+
+	result, err := h.Accounts.LogIn(req.Context(), reqData.Login, reqData.Password, logic.AnonymousUserID(req.Context()), clientIP(req, h.Proxies))
+	if errors.Is(err, logic.ErrWrongCredentials()) {
+		res.WriteHeader(http.StatusUnauthorized)
+		return
+	} else if errors.Is(err, logic.ErrTooManyAttempts()) {
+		res.WriteHeader(http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while logging in: %v", err)
+		return
+	}
+
+	writeAuthResult(res, req, http.StatusOK, h.Sessions, result, h.Log)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"go.uber.org/zap"
+)
+
+// LogOutHandler is a handler struct. Use it`s ServeHTTP func.
+type LogOutHandler struct {
+	Sessions *logic.Sessions
+	Log      zap.SugaredLogger
+}
+
+// ServeHTTP revokes a session of a request (its access and refresh tokens are not accepted anymore)
+// and deletes session cookies. Returns http.StatusNoContent.
+// Requests with API keys get http.StatusForbidden, because API keys have no sessions (revoke a key instead).
+func (h *LogOutHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if _, isKey := logic.APIKeyFromContext(req.Context()); isKey {
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if sessionID, ok := logic.SessionIDFromContext(req.Context()); ok {
+		err := h.Sessions.Revoke(req.Context(), sessionID)
+		if err != nil {
+			res.WriteHeader(http.StatusInternalServerError)
+			h.Log.Errorf("Error while revoking a session: %v", err)
+			return
+		}
+	}
+
+	middlewares.ClearSessionCookies(res, req)
+	res.WriteHeader(http.StatusNoContent)
+}
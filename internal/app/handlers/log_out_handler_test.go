@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSessionHandlers(t *testing.T) {
+	//test server building
+	conf := config.Config{
+		BaseAddress:   "http://localhost:8080",
+		ServerAddress: "localhost:8080",
+	}
+	URLStore := databases.NewJustAMap()
+	sugar := zaptest.NewLogger(t).Sugar()
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	r, err := NewRouter(conf, URLStore, *sugar, logic.NewSessions(URLStore, jh, config.Config{}), logic.NewRedirector(URLStore, nil, conf),
+		logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
+	require.NoError(t, err, "error while creating a router in test")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	do := func(t *testing.T, method string, path string, body string, token string) (*http.Response, sessionResponse) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err, "Error while creating a request")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "Error while making a request")
+		defer resp.Body.Close()
+		data := sessionResponse{}
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+		}
+		return resp, data
+	}
+
+	//sign up
+	resp, signedUp := do(t, http.MethodPost, "/api/user/register", `{"login": "user", "password": "secret password"}`, "")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, signedUp.RefreshToken)
+	for _, cookie := range resp.Cookies() {
+		assert.True(t, cookie.HttpOnly, "cookie `%s` has to be HttpOnly", cookie.Name)
+		assert.Positive(t, cookie.MaxAge, "cookie `%s` has to have Max-Age", cookie.Name)
+	}
+
+	t.Run("refresh", func(t *testing.T) {
+		resp, refreshed := do(t, http.MethodPost, "/api/user/refresh", `{"refresh_token": "`+signedUp.RefreshToken+`"}`, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, signedUp.UserID, refreshed.UserID)
+		assert.Equal(t, signedUp.RefreshToken, refreshed.RefreshToken)
+		userID, err := jh.GetUserID(refreshed.Token)
+		require.NoError(t, err)
+		assert.Equal(t, signedUp.UserID, userID)
+
+		resp, _ = do(t, http.MethodPost, "/api/user/refresh", `{"refresh_token": "wrong.token"}`, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("refresh with an expired access token", func(t *testing.T) {
+		expiredJH := secure.NewJWTHelper("testSecretKey", 5)
+		expiredJH.TokenExp = -time.Minute
+		sessionID, _, _ := strings.Cut(signedUp.RefreshToken, ".")
+		expired, err := expiredJH.BuildSessionJWTString(signedUp.UserID, sessionID)
+		require.NoError(t, err)
+
+		resp, refreshed := do(t, http.MethodPost, "/api/user/refresh", `{"refresh_token": "`+signedUp.RefreshToken+`"}`, expired)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, signedUp.UserID, refreshed.UserID)
+	})
+
+	t.Run("log out", func(t *testing.T) {
+		resp, _ := do(t, http.MethodPost, "/api/user/logout", "", signedUp.Token)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		cleared := 0
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == middlewares.JwtCookieName || cookie.Name == middlewares.RefreshCookieName {
+				assert.Negative(t, cookie.MaxAge)
+				cleared++
+			}
+		}
+		assert.Equal(t, 2, cleared, "session cookies have to be deleted")
+
+		//tokens of a revoked session are not accepted
+		resp, _ = do(t, http.MethodGet, "/api/user/urls", "", signedUp.Token)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp, _ = do(t, http.MethodPost, "/api/user/refresh", `{"refresh_token": "`+signedUp.RefreshToken+`"}`, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var passwordFormTemplate = template.Must(template.New("passwordForm").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Password required</title>
+</head>
+<body>
+<form method="post">
+{{if .}}<p>{{.}}</p>{{end}}
+<label>This link is protected. Password: <input type="password" name="password" autofocus></label>
+<button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
+// writePasswordForm writes a status and an HTML password form (with an optional message).
+// API clients (witch have sent a password in LinkPasswordHeader) get only a status.
+func (h *ShortURLRedirectHandler) writePasswordForm(res http.ResponseWriter, status int, message string, apiClient bool) {
+	if apiClient {
+		res.WriteHeader(status)
+		return
+	}
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.WriteHeader(status)
+	err := passwordFormTemplate.Execute(res, message)
+	if err != nil {
+		h.Log.Errorf("error while writing a password form: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// PreviewHandler is a handler struct. Use it`s ServeHTTP func.
+type PreviewHandler struct {
+	URLStorage logic.URLStorageInterface
+	Conf       config.Config
+	Log        zap.SugaredLogger
+}
+
+// ServeHTTP shows where a short URL goes without a redirect (a click is not counted).
+// Short URL is read from "short" URL param, or from "url" URL param with a "+" suffix (like "/abc+").
+// Response is a JSON by default and an HTML page if "format" query param is "html" or if a client accepts "text/html".
+// Owner of a URL can get a total amount of clicks using "clicks=true" query param.
+func (h *PreviewHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	shortURL := chi.URLParam(req, "short")
+	if shortURL == "" {
+		shortURL = strings.TrimSuffix(chi.URLParam(req, "url"), "+")
+	}
+	userID, _ := logic.UserIDFromContext(req.Context())
+	withClicks := req.URL.Query().Get("clicks") == "true"
+
+	preview, err := logic.PreviewURL(req.Context(), h.URLStorage, h.Conf.BaseAddress, shortURL, userID, withClicks)
+	if errors.Is(err, databases.ErrURLNotFound()) {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.Log.Errorf("error while getting a URL preview: %v", err)
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	//HTML page
+	format := req.URL.Query().Get("format")
+	if format == "html" || (format == "" && strings.Contains(req.Header.Get("Accept"), "text/html")) {
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		res.WriteHeader(http.StatusOK)
+		err = previewPageTemplate.Execute(res, preview)
+		if err != nil {
+			h.Log.Errorf("error while writing a preview page: %v", err)
+		}
+		return
+	}
+
+	//JSON
+	jsonResp, err := json.Marshal(preview)
+	if err != nil {
+		h.Log.Error("Error while marshalling a URL preview", zap.Error(err))
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	res.Write(jsonResp)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPreviewHandler_ServeHTTP(t *testing.T) {
+	//prepare storage
+	ownerID := 7
+	URLStore := databases.NewJustAMap()
+	past := time.Now().Add(-time.Hour)
+	rules := []entities.RedirectRule{
+		{Device: "ios", Destination: "https://apps.apple.com/app"},
+		{Language: "de", Destination: "https://example.de/"},
+	}
+	variants := []entities.Variant{
+		{Name: "a", Destination: "https://a.example.com/", Weight: 90},
+		{Name: "b", Destination: "https://b.example.com/", Weight: 10},
+	}
+	urls := []entities.URL{
+		{ShortURL: "active", OriginalURL: "https://practicum.yandex.ru/"},
+		{ShortURL: "expired", OriginalURL: "https://ya.ru/", ExpiresAt: &past},
+		{ShortURL: "secret", OriginalURL: "https://secret.example.com/", PasswordHash: "hash", Rules: rules},
+		{ShortURL: "ruled", OriginalURL: "https://example.com/", Rules: rules},
+		{ShortURL: "split", OriginalURL: "https://example.com/", Variants: variants},
+		{ShortURL: "secret-split", OriginalURL: "https://example.com/", PasswordHash: "hash", Variants: variants},
+	}
+	for _, url := range urls {
+		err := URLStore.SaveWithUserID(context.Background(), ownerID, url)
+		require.NoError(t, err, "error while preparing a storage")
+	}
+	err := URLStore.SaveClicks(context.Background(), []entities.Click{{ShortURL: "active"}, {ShortURL: "active"}})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare router
+	conf := config.Config{BaseAddress: "http://localhost:8080"}
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	sessions := logic.NewSessions(URLStore, jh, config.Config{})
+	ownerTokens, err := sessions.Start(context.Background(), ownerID)
+	require.NoError(t, err, "error while starting a session in test")
+	ownerJWT := ownerTokens.AccessToken
+	logger := *zaptest.NewLogger(t).Sugar()
+	r, err := NewRouter(conf, URLStore, logger, sessions, logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}), logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
+	require.NoError(t, err, "error while creating a router in test")
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		htmlWant    []string
+		owner       bool
+		statusWant  int
+		previewWant entities.URLPreview
+		clicksWant  *int
+	}{
+		{
+			name:       "plus suffix",
+			target:     "/active+",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/active",
+				OriginalURL: "https://practicum.yandex.ru/",
+			},
+		},
+		{
+			name:       "api route",
+			target:     "/api/info/expired",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/expired",
+				OriginalURL: "https://ya.ru/",
+				IsExpired:   true,
+			},
+		},
+		{
+			name:       "protected url",
+			target:     "/api/info/secret",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/secret",
+				HasPassword: true,
+			},
+		},
+		{
+			name:       "rules",
+			target:     "/api/info/ruled",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/ruled",
+				OriginalURL: "https://example.com/",
+				Rules:       rules,
+			},
+		},
+		{
+			name:       "variants",
+			target:     "/api/info/split",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/split",
+				OriginalURL: "https://example.com/",
+				Variants:    variants,
+			},
+		},
+		{
+			name:       "protected variants",
+			target:     "/api/info/secret-split",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/secret-split",
+				HasPassword: true,
+			},
+		},
+		{
+			name:       "clicks for not an owner",
+			target:     "/api/info/active?clicks=true",
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/active",
+				OriginalURL: "https://practicum.yandex.ru/",
+			},
+		},
+		{
+			name:       "clicks for an owner",
+			target:     "/api/info/active?clicks=true",
+			owner:      true,
+			statusWant: http.StatusOK,
+			previewWant: entities.URLPreview{
+				ShortURL:    conf.BaseAddress + "/active",
+				OriginalURL: "https://practicum.yandex.ru/",
+			},
+			clicksWant: func() *int { c := 2; return &c }(),
+		},
+		{
+			name:       "unknown url",
+			target:     "/unknown+",
+			statusWant: http.StatusNotFound,
+		},
+		{
+			name:       "html page",
+			target:     "/active+",
+			accept:     "text/html,application/xhtml+xml",
+			htmlWant:   []string{"https://practicum.yandex.ru/"},
+			statusWant: http.StatusOK,
+		},
+		{
+			name:       "html page with rules",
+			target:     "/ruled+",
+			accept:     "text/html",
+			htmlWant:   []string{"https://example.com/", "device: ios", "https://apps.apple.com/app", "language: de", "https://example.de/"},
+			statusWant: http.StatusOK,
+		},
+		{
+			name:       "html page with variants",
+			target:     "/split+",
+			accept:     "text/html",
+			htmlWant:   []string{"a (weight 90)", "https://a.example.com/", "b (weight 10)", "https://b.example.com/"},
+			statusWant: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.owner {
+				req.AddCookie(&http.Cookie{Name: middlewares.JwtCookieName, Value: ownerJWT})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, tt.statusWant, w.Code)
+			assert.Empty(t, w.Header().Get("Location"), "preview must not redirect")
+			if tt.statusWant != http.StatusOK {
+				return
+			}
+
+			if tt.accept != "" {
+				assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+				for _, want := range tt.htmlWant {
+					assert.Contains(t, w.Body.String(), want)
+				}
+				return
+			}
+			preview := entities.URLPreview{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+			assert.NotNil(t, preview.CreatedAt)
+			assert.Equal(t, tt.clicksWant, preview.TotalClicks)
+			preview.CreatedAt = nil
+			preview.ExpiresAt = nil
+			preview.TotalClicks = nil
+			assert.Equal(t, tt.previewWant, preview)
+		})
+	}
+
+	//preview doesn`t count clicks
+	stats, err := URLStore.GetURLStats(context.Background(), "active", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalClicks)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import "html/template"
+
+var previewPageTemplate = template.Must(template.New("previewPage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Link preview</title>
+</head>
+<body>
+<h1>Link preview</h1>
+<p>Short link: {{.ShortURL}}</p>
+{{if .HasPassword}}<p>This link is protected by a password, its destination is hidden.</p>
+{{else}}<p>Destination: <a href="{{.OriginalURL}}" rel="noopener noreferrer nofollow">{{.OriginalURL}}</a></p>
+{{if .Rules}}<p>Some visitors are sent to other destinations:</p>
+<ul>
+{{range .Rules}}<li>{{if .Device}}device: {{.Device}} {{end}}{{if .Language}}language: {{.Language}} {{end}}&rarr; <a href="{{.Destination}}" rel="noopener noreferrer nofollow">{{.Destination}}</a></li>
+{{end}}</ul>
+{{end}}{{if .Variants}}<p>Visitors are split between destinations:</p>
+<ul>
+{{range .Variants}}<li>{{.Name}} (weight {{.Weight}}) &rarr; <a href="{{.Destination}}" rel="noopener noreferrer nofollow">{{.Destination}}</a></li>
+{{end}}</ul>
+{{end}}{{end}}{{if .CreatedAt}}<p>Created: {{.CreatedAt.UTC.Format "2006-01-02 15:04:05 MST"}}</p>
+{{end}}{{if .ExpiresAt}}<p>Expires: {{.ExpiresAt.UTC.Format "2006-01-02 15:04:05 MST"}}</p>
+{{end}}{{if .IsDeleted}}<p>Status: deleted</p>
+{{else if .IsExpired}}<p>Status: expired</p>
+{{else if .NoClicksLeft}}<p>Status: no clicks left</p>
+{{else}}<p>Status: active</p>
+{{end}}{{if .TotalClicks}}<p>Clicks: {{.TotalClicks}}</p>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// QRCodeHandler is a handler struct. Use it`s ServeHTTP func.
+type QRCodeHandler struct {
+	URLStorage logic.URLStorageInterface
+	Conf       config.Config
+	Log        zap.SugaredLogger
+}
+
+// ServeHTTP returns a QR code of a full short URL.
+// Query params: "format" ("png" or "svg"), "size" (in pixels), "margin" (in QR code modules)
+// and "level" (error correction level: "L", "M", "Q" or "H").
+// Clicks are not counted.
+func (h *QRCodeHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	shortURL := chi.URLParam(req, "url")
+
+	//read params
+	values := req.URL.Query()
+	params := logic.QRCodeParams{
+		Format: values.Get("format"),
+		Level:  values.Get("level"),
+	}
+	if size := values.Get("size"); size != "" {
+		parsed, err := strconv.Atoi(size)
+		if err != nil {
+			h.Log.Debugf("can`t parse size: %v", err)
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		params.Size = parsed
+	}
+	if margin := values.Get("margin"); margin != "" {
+		parsed, err := strconv.Atoi(margin)
+		if err != nil {
+			h.Log.Debugf("can`t parse margin: %v", err)
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		params.Margin = &parsed
+	}
+
+	//build a QR code
+	code, err := logic.BuildQRCode(req.Context(), h.URLStorage, h.Conf.BaseAddress, shortURL, params)
+	switch {
+	case errors.Is(err, logic.ErrBadURLParams()):
+		h.Log.Debugf("bad QR code params: %v", err)
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	case errors.Is(err, databases.ErrURLNotFound()):
+		res.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, databases.ErrURLWasDeleted()),
+		errors.Is(err, databases.ErrURLExpired()),
+		errors.Is(err, databases.ErrClicksLimitReached()):
+		res.WriteHeader(http.StatusGone)
+		return
+	case err != nil:
+		h.Log.Errorf("error while building a QR code: %v", err)
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", code.ContentType)
+	res.WriteHeader(http.StatusOK)
+	res.Write(code.Data)
+}
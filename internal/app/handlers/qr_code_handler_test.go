@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQRCodeHandler_ServeHTTP(t *testing.T) {
+	//prepare storage
+	URLStore := databases.NewJustAMap()
+	err := URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "poster",
+		OriginalURL: "https://practicum.yandex.ru/",
+	})
+	require.NoError(t, err, "error while preparing a storage")
+	err = URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "deleted",
+		OriginalURL: "https://ya.ru/",
+		IsDeleted:   true,
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare router
+	h := QRCodeHandler{
+		URLStorage: URLStore,
+		Conf:       config.Config{BaseAddress: "http://localhost:8080"},
+		Log:        *zaptest.NewLogger(t).Sugar(),
+	}
+	r := chi.NewRouter()
+	r.Get("/{url}/qr", h.ServeHTTP)
+
+	tests := []struct {
+		name            string
+		target          string
+		statusWant      int
+		contentTypeWant string
+		sizeWant        int
+	}{
+		{
+			name:            "default png",
+			target:          "/poster/qr",
+			statusWant:      http.StatusOK,
+			contentTypeWant: "image/png",
+			sizeWant:        logic.DefaultQRSize,
+		},
+		{
+			name:            "png with params",
+			target:          "/poster/qr?size=100&margin=0&level=h",
+			statusWant:      http.StatusOK,
+			contentTypeWant: "image/png",
+			sizeWant:        100,
+		},
+		{
+			name:            "svg",
+			target:          "/poster/qr?format=svg&size=300",
+			statusWant:      http.StatusOK,
+			contentTypeWant: "image/svg+xml",
+		},
+		{
+			name:       "unknown url",
+			target:     "/unknown/qr",
+			statusWant: http.StatusNotFound,
+		},
+		{
+			name:       "deleted url",
+			target:     "/deleted/qr",
+			statusWant: http.StatusGone,
+		},
+		{
+			name:       "unknown format",
+			target:     "/poster/qr?format=gif",
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "too big",
+			target:     "/poster/qr?size=100000",
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "too small",
+			target:     "/poster/qr?size=10",
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "bad margin",
+			target:     "/poster/qr?margin=wide",
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown level",
+			target:     "/poster/qr?level=X",
+			statusWant: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.statusWant, w.Code)
+			if tt.statusWant != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.contentTypeWant, w.Header().Get("Content-Type"))
+
+			switch tt.contentTypeWant {
+			case "image/png":
+				img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+				require.NoError(t, err)
+				assert.Equal(t, tt.sizeWant, img.Bounds().Dx())
+				assert.Equal(t, tt.sizeWant, img.Bounds().Dy())
+			case "image/svg+xml":
+				assert.True(t, strings.HasPrefix(w.Body.String(), "<svg"))
+				assert.Contains(t, w.Body.String(), `width="300"`)
+			}
+		})
+	}
+}
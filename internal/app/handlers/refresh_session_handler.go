@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"go.uber.org/zap"
+)
+
+// RefreshSessionHandler is a handler struct. Use it`s ServeHTTP func.
+type RefreshSessionHandler struct {
+	Sessions *logic.Sessions
+	Log      zap.SugaredLogger
+}
+
+// ServeHTTP prolongs a session and returns a new access token of it. A refresh token is read from a JSON body
+// with a "refresh_token" field or (if there is no body) from a middlewares.RefreshCookieName cookie.
+// Response is a JSON with tokens of a session (see writeSession), http.StatusUnauthorized is returned
+// if a refresh token is not valid or its session was revoked or has expired.
+func (h *RefreshSessionHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	reqData := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&reqData)
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			h.Log.Debugf("Error while decoding req body: %v", err)
+			return
+		}
+	}
+	if reqData.RefreshToken == "" {
+		cookie, err := req.Cookie(middlewares.RefreshCookieName)
+		if err != nil {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reqData.RefreshToken = cookie.Value
+	}
+
+	tokens, err := h.Sessions.Refresh(req.Context(), reqData.RefreshToken)
+	if errors.Is(err, logic.ErrInvalidSession()) {
+		res.WriteHeader(http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while refreshing a session: %v", err)
+		return
+	}
+
+	writeSession(res, req, http.StatusOK, tokens, newSessionResponse(tokens), h.Log)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// RevokeAPIKeyHandler is a handler struct. Use it`s ServeHTTP func.
+type RevokeAPIKeyHandler struct {
+	APIKeys *logic.APIKeys
+	Log     zap.SugaredLogger
+}
+
+// ServeHTTP revokes (deletes) an API key of a user, a key can`t be used anymore. Returns http.StatusNoContent if it is done
+// and http.StatusNotFound if user has no such key.
+// API keys can`t be managed using API keys, such requests get http.StatusForbidden.
+func (h *RevokeAPIKeyHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	userID, ok := keysOwner(res, req)
+	if !ok {
+		return
+	}
+
+	err := h.APIKeys.Revoke(req.Context(), userID, chi.URLParam(req, "id"))
+	if errors.Is(err, databases.ErrAPIKeyNotFound()) {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while revoking API key: %v", err)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
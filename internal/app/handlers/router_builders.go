@@ -5,15 +5,22 @@ import (
 	"github.com/Lesnoi3283/url_shortener/config"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
-	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 	"net"
 )
 
 // NewRouter builds new chi.Router with handlers. User just have to run it with http.ListenAndServe or something else.
-func NewRouter(conf config.Config, store logic.URLStorageInterface, logger zap.SugaredLogger, JWTHelper *secure.JWTHelper) (chi.Router, error) {
+// Sessions, redirector, accounts and API keys have to use the same store.
+// Requests authenticated by API keys can use only endpoints allowed by scopes of keys.
+// Anonymous users are created only by endpoints witch shorten URLs (see middlewares.NewUserMW).
+func NewRouter(conf config.Config, store logic.URLStorageInterface, logger zap.SugaredLogger, sessions *logic.Sessions, redirector *logic.Redirector,
+	accounts *logic.Accounts, apiKeys *logic.APIKeys) (chi.Router, error) {
 	r := chi.NewRouter()
+	proxies, err := logic.NewTrustedProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
 	//handlers building
 	URLShortener := URLShortenerHandler{
@@ -22,7 +29,8 @@ func NewRouter(conf config.Config, store logic.URLStorageInterface, logger zap.S
 		Log:        logger,
 	}
 	shortURLRedirect := ShortURLRedirectHandler{
-		URLStorage: store,
+		Redirector: redirector,
+		Proxies:    proxies,
 		Log:        logger,
 	}
 	shortener := ShortenHandler{
@@ -35,6 +43,11 @@ func NewRouter(conf config.Config, store logic.URLStorageInterface, logger zap.S
 		Conf:       conf,
 		Log:        logger,
 	}
+	shortenBulk := ShortenBulkHandler{
+		URLStorage: store,
+		Conf:       conf,
+		Log:        logger,
+	}
 	userURLs := UserURLsHandler{
 		URLStorage: store,
 		Conf:       conf,
@@ -49,6 +62,61 @@ func NewRouter(conf config.Config, store logic.URLStorageInterface, logger zap.S
 		DB:  store,
 		log: logger,
 	}
+	searchURLs := SearchURLsHandler{
+		URLStorage: store,
+		Conf:       conf,
+		Log:        logger,
+	}
+	updateURL := UpdateURLHandler{
+		URLStorage: store,
+		Conf:       conf,
+		Log:        logger,
+	}
+	QRCode := QRCodeHandler{
+		URLStorage: store,
+		Conf:       conf,
+		Log:        logger,
+	}
+	preview := PreviewHandler{
+		URLStorage: store,
+		Conf:       conf,
+		Log:        logger,
+	}
+	URLStats := URLStatsHandler{
+		URLStorage: store,
+		Log:        logger,
+	}
+	signUp := SignUpHandler{
+		Accounts: accounts,
+		Sessions: sessions,
+		Log:      logger,
+	}
+	logIn := LogInHandler{
+		Accounts: accounts,
+		Sessions: sessions,
+		Proxies:  proxies,
+		Log:      logger,
+	}
+	refreshSession := RefreshSessionHandler{
+		Sessions: sessions,
+		Log:      logger,
+	}
+	logOut := LogOutHandler{
+		Sessions: sessions,
+		Log:      logger,
+	}
+	createAPIKey := CreateAPIKeyHandler{
+		APIKeys: apiKeys,
+		Log:     logger,
+	}
+	listAPIKeys := APIKeysHandler{
+		APIKeys: apiKeys,
+		Log:     logger,
+	}
+	revokeAPIKey := RevokeAPIKeyHandler{
+		APIKeys: apiKeys,
+		Log:     logger,
+	}
 	stats := StatsHandler{
 		log:     logger,
 		storage: store,
@@ -66,17 +134,48 @@ func NewRouter(conf config.Config, store logic.URLStorageInterface, logger zap.S
 
 	r.Use(middlewares.LoggerMW(logger))
 	r.Use(middlewares.CompressionMW(logger))
-	r.Use(middlewares.AuthMW(store, logger, JWTHelper))
 	r.Use(middlewares.SubnetFilterMW(trustedSubnet, logger))
 
-	r.Get("/api/user/urls", userURLs.ServeHTTP)
-	r.Post("/", URLShortener.ServeHTTP)
+	//public endpoints never create users and never set cookies (most of their visitors are bots)
 	r.Get("/{url}", shortURLRedirect.ServeHTTP)
-	r.Post("/api/shorten", shortener.ServeHTTP)
-	r.Post("/api/shorten/batch", shortenBatch.ServeHTTP)
-	r.Delete("/api/user/urls", deleteURLs.ServeHTTP)
+	r.Post("/{url}", shortURLRedirect.ServeHTTP)
+	r.Get("/{url}/qr", QRCode.ServeHTTP)
 	r.Get("/ping", pingDB.ServeHTTP)
 	r.Get("/api/internal/stats", stats.ServeHTTP)
 
+	//a refresh token is read by a handler itself, so an expired access token doesn`t get 401 here
+	r.Post("/api/user/refresh", refreshSession.ServeHTTP)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.PassiveAuthMW(logger, sessions, apiKeys))
+		r.Get(`/{url:[^/]+\+}`, preview.ServeHTTP)
+		r.Get("/api/info/{short}", preview.ServeHTTP)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.AuthMW(logger, sessions, apiKeys))
+
+		canShorten := middlewares.ScopeMW(logic.ScopeShorten, logger)
+		canRead := middlewares.ScopeMW(logic.ScopeRead, logger)
+		canDelete := middlewares.ScopeMW(logic.ScopeDelete, logger)
+		newUser := middlewares.NewUserMW(store, logger, sessions)
+
+		r.Post("/api/user/register", signUp.ServeHTTP)
+		r.Post("/api/user/login", logIn.ServeHTTP)
+		r.Post("/api/user/logout", logOut.ServeHTTP)
+		r.Post("/api/user/keys", createAPIKey.ServeHTTP)
+		r.Get("/api/user/keys", listAPIKeys.ServeHTTP)
+		r.Delete("/api/user/keys/{id}", revokeAPIKey.ServeHTTP)
+		r.With(canRead).Get("/api/user/urls", userURLs.ServeHTTP)
+		r.With(canRead).Get("/api/user/urls/search", searchURLs.ServeHTTP)
+		r.With(canRead).Get("/api/user/urls/{short}/stats", URLStats.ServeHTTP)
+		r.With(canShorten).Patch("/api/user/urls/{short}", updateURL.ServeHTTP)
+		r.With(canShorten, newUser).Post("/", URLShortener.ServeHTTP)
+		r.With(canShorten, newUser).Post("/api/shorten", shortener.ServeHTTP)
+		r.With(canShorten, newUser).Post("/api/shorten/batch", shortenBatch.ServeHTTP)
+		r.With(canShorten, newUser).Post("/api/shorten/bulk", shortenBulk.ServeHTTP)
+		r.With(canDelete).Delete("/api/user/urls", deleteURLs.ServeHTTP)
+	})
+
 	return r, nil
 }
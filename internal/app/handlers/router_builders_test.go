@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// userCountingStore counts created users.
+type userCountingStore struct {
+	*databases.JustAMap
+	created atomic.Int32
+}
+
+func (s *userCountingStore) CreateUser(ctx context.Context) (int, error) {
+	s.created.Add(1)
+	return s.JustAMap.CreateUser(ctx)
+}
+
+func TestNewRouter_LazyUsers(t *testing.T) {
+	//prepare storage
+	URLStore := &userCountingStore{JustAMap: databases.NewJustAMap()}
+	err := URLStore.SaveWithUserID(context.Background(), 7, entities.URL{ShortURL: "abc", OriginalURL: "https://practicum.yandex.ru/"})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare router
+	conf := config.Config{BaseAddress: "http://localhost:8080"}
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	logger := *zaptest.NewLogger(t).Sugar()
+	r, err := NewRouter(conf, URLStore, logger, logic.NewSessions(URLStore, jh, config.Config{}), logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}), logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
+	require.NoError(t, err, "error while creating a router in test")
+
+	//public endpoints
+	publicTargets := map[string]int{
+		"/abc":          http.StatusTemporaryRedirect,
+		"/abc+":         http.StatusOK,
+		"/api/info/abc": http.StatusOK,
+		"/abc/qr":       http.StatusOK,
+		"/ping":         http.StatusOK,
+	}
+	for target, statusWant := range publicTargets {
+		t.Run("GET "+target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, statusWant, w.Code)
+			assert.Empty(t, w.Result().Cookies(), "public endpoint must not set cookies")
+			assert.Empty(t, w.Header().Get(middlewares.AuthTokenHeader))
+		})
+	}
+	assert.Zero(t, URLStore.created.Load(), "public endpoints must not create users")
+
+	//endpoints for existing users
+	req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	assert.Zero(t, URLStore.created.Load(), "user endpoints must not create users")
+
+	//shortening creates a user
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://ya.ru/"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEmpty(t, w.Result().Cookies(), "new user has to get cookies")
+	assert.Equal(t, int32(1), URLStore.created.Load())
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"go.uber.org/zap"
+)
+
+// SearchURLsHandler is a handler struct. Use it`s ServeHTTP func.
+type SearchURLsHandler struct {
+	URLStorage logic.URLStorageInterface
+	Conf       config.Config
+	Log        zap.SugaredLogger
+}
+
+// ServeHTTP returns a JSON array with user`s URLs found by a "tag", a title words ("q") or a substring of an original URL ("contains").
+// At least one of these params is required. Other params are the same as in UserURLsHandler.
+func (h *SearchURLsHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	userID, ok := logic.UserIDFromContext(req.Context())
+	if !ok {
+		res.WriteHeader(http.StatusUnauthorized)
+		h.Log.Error("UserID is nil")
+		return
+	}
+
+	//read search params
+	values := req.URL.Query()
+	query, onePage, err := urlsPageQueryFromValues(values)
+	if err != nil {
+		h.Log.Debugf("SearchURLsHandler bad params: %v", err)
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	query.Tag = values.Get("tag")
+	query.TitleQuery = values.Get("q")
+	cursor := values.Get("cursor")
+
+	//search
+	page, err := logic.SearchUsersURLs(req.Context(), h.URLStorage, h.Conf.BaseAddress, userID, cursor, query)
+	if errors.Is(err, logic.ErrBadURLParams()) {
+		h.Log.Debugf("SearchURLsHandler bad params: %v", err)
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil {
+		h.Log.Error("SearchURLsHandler search err", zap.Error(err))
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(page.URLs) == 0 {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	streamURLsPages(res, h.Log, page, onePage, func(cursor string) (logic.UserURLsPage, error) {
+		return logic.SearchUsersURLs(req.Context(), h.URLStorage, h.Conf.BaseAddress, userID, cursor, query)
+	})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSearchURLsHandler_ServeHTTP(t *testing.T) {
+	//prepare storage
+	userID := 1
+	URLStore := databases.NewJustAMap()
+	urls := []entities.URL{
+		{ShortURL: "news", OriginalURL: "http://news.example.com", Title: "Morning news", Tags: []string{"daily", "work"}},
+		{ShortURL: "docs", OriginalURL: "http://docs.example.com", Title: "Go docs", Tags: []string{"work"}},
+		{ShortURL: "music", OriginalURL: "http://music.example.org", Title: "Morning playlist"},
+	}
+	for _, url := range urls {
+		err := URLStore.SaveWithUserID(context.Background(), userID, url)
+		require.NoError(t, err, "error while preparing a storage")
+	}
+	err := URLStore.SaveWithUserID(context.Background(), 2, entities.URL{
+		ShortURL:    "alien",
+		OriginalURL: "http://alien.example.com",
+		Title:       "Morning alien",
+		Tags:        []string{"work"},
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	h := &SearchURLsHandler{
+		URLStorage: URLStore,
+		Conf:       config.Config{BaseAddress: "http://baseAddress"},
+		Log:        *zaptest.NewLogger(t).Sugar(),
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		userID     interface{}
+		statusWant int
+		shortsWant []string
+	}{
+		{
+			name:       "no user",
+			query:      "tag=work",
+			userID:     nil,
+			statusWant: http.StatusUnauthorized,
+		},
+		{
+			name:       "no filters",
+			query:      "limit=10",
+			userID:     userID,
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "by tag",
+			query:      "tag=Work&sort=short",
+			userID:     userID,
+			statusWant: http.StatusOK,
+			shortsWant: []string{"http://baseAddress/docs", "http://baseAddress/news"},
+		},
+		{
+			name:       "by title",
+			query:      "q=morning&sort=short",
+			userID:     userID,
+			statusWant: http.StatusOK,
+			shortsWant: []string{"http://baseAddress/music", "http://baseAddress/news"},
+		},
+		{
+			name:       "by tag and title",
+			query:      "tag=work&q=morning",
+			userID:     userID,
+			statusWant: http.StatusOK,
+			shortsWant: []string{"http://baseAddress/news"},
+		},
+		{
+			name:       "by original url",
+			query:      "contains=example.org",
+			userID:     userID,
+			statusWant: http.StatusOK,
+			shortsWant: []string{"http://baseAddress/music"},
+		},
+		{
+			name:       "nothing found",
+			query:      "tag=unknown",
+			userID:     userID,
+			statusWant: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls/search?"+tt.query, nil)
+			if tt.userID != nil {
+				req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDContextKey, tt.userID))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.statusWant, w.Code)
+			if tt.shortsWant == nil {
+				return
+			}
+
+			found := make([]entities.URL, 0)
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+			shorts := make([]string, len(found))
+			for i, url := range found {
+				shorts[i] = url.ShortURL
+			}
+			assert.Equal(t, tt.shortsWant, shorts)
+		})
+	}
+}
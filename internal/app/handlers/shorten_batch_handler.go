@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
-	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
 	"io"
 	"log"
 	"net/http"
@@ -21,6 +21,8 @@ type ShortenBatchHandler struct {
 }
 
 // ServeHTTP shorts all given URLS (in JSON) and saves them in a storage.
+// Every URL can have optional "expires_at", "ttl", "max_clicks", "password", "title", "notes", "tags",
+// "redirect_code", "pass_query", "utm", "rules", "variants" and "sticky_variants" fields.
 // Returns a JSON array with short versions of given URLs.
 func (h *ShortenBatchHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	//read request params
@@ -42,12 +44,12 @@ func (h *ShortenBatchHandler) ServeHTTP(res http.ResponseWriter, req *http.Reque
 	}
 
 	//get userID and short URLs
-	userIDFromContext := req.Context().Value(middlewares.UserIDContextKey)
-	userID, ok := (userIDFromContext).(int)
-	if ok {
-		URLs, err = logic.ShortenBatch(req.Context(), URLs, h.Conf.BaseAddress, h.URLStorage, userID)
-	} else {
-		URLs, err = logic.ShortenBatch(req.Context(), URLs, h.Conf.BaseAddress, h.URLStorage, -1)
+	userID, _ := logic.UserIDFromContext(req.Context())
+	URLs, err = logic.ShortenBatch(req.Context(), URLs, h.Conf.BaseAddress, h.URLStorage, userID)
+	if errors.Is(err, logic.ErrBadURLParams()) {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Bad URL params in a batch: %v", err)
+		return
 	}
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
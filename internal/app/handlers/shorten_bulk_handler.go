@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"go.uber.org/zap"
+)
+
+// MaxBulkLineLength is a max length of one line of a bulk shortening request (in bytes).
+// Longer NDJSON lines and CSV records get errors, other lines are still shortened.
+const MaxBulkLineLength = 64 * 1024
+
+// Content types of bulk shortening requests and responses.
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+	ContentTypeCSV    = "text/csv"
+)
+
+// csvColumns are columns witch CSV bulk shortening requests can have (and "utm_*" columns).
+var csvColumns = map[string]struct{}{
+	"correlation_id": {},
+	"original_url":   {},
+	"password":       {},
+	"ttl":            {},
+	"title":          {},
+	"notes":          {},
+	"tags":           {},
+	"expires_at":     {},
+	"max_clicks":     {},
+	"redirect_code":  {},
+	"pass_query":     {},
+}
+
+// ShortenBulkHandler is a handler struct. Use it`s ServeHTTP func.
+type ShortenBulkHandler struct {
+	URLStorage logic.URLStorageInterface
+	Conf       config.Config
+	Log        zap.SugaredLogger
+}
+
+// ServeHTTP shortens URLs of a big request and saves them by chunks of Conf.BulkChunkSize lines.
+// Request body is a newline-delimited JSON (one URL object per line with the same fields as in a batch request)
+// or a CSV with a header (if Content-Type is "text/csv"). CSV columns are "original_url", "correlation_id", "password"
+// and query params of URLShortenerHandler ("ttl", "tags", "utm_source" and others).
+// Response is a newline-delimited JSON with a result of every line (see entities.BulkResult), it is sent chunk by chunk.
+// Status code is 200 even if some lines have errors. If processing stops in the middle,
+// the last line of a response contains an error without a line number.
+func (h *ShortenBulkHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	//choose a source
+	var source logic.BulkSource
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	switch mediaType {
+	case ContentTypeCSV:
+		source, err = newCSVSource(req.Body)
+		if err != nil {
+			h.Log.Debugf("bad CSV header: %v", err)
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case "", ContentTypeNDJSON, "application/jsonl", "application/json", "text/plain":
+		source = newNDJSONSource(req.Body)
+	default:
+		res.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	//get userID
+	userID, _ := logic.UserIDFromContext(req.Context())
+
+	//results are written while a request body is still being read
+	controller := http.NewResponseController(res)
+	err = controller.EnableFullDuplex()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.Log.Warnf("can`t enable full duplex: %v", err)
+	}
+	res.Header().Set("Content-Type", ContentTypeNDJSON)
+	res.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(res)
+
+	err = logic.ShortenBulk(req.Context(), source, h.Conf.BaseAddress, h.URLStorage, userID, h.Conf.BulkChunkSize,
+		func(results []entities.BulkResult) error {
+			for _, result := range results {
+				err := encoder.Encode(result)
+				if err != nil {
+					return err
+				}
+			}
+			err := controller.Flush()
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+			return nil
+		})
+	if err != nil {
+		h.Log.Debugf("bulk shortening stopped: %v", err)
+		encoder.Encode(entities.BulkResult{Error: "processing stopped: " + err.Error()})
+	}
+}
+
+// ndjsonSource reads URLs from a newline-delimited JSON. Empty lines are skipped.
+type ndjsonSource struct {
+	reader *bufio.Reader
+	line   int
+}
+
+// newNDJSONSource builds a new ndjsonSource.
+func newNDJSONSource(r io.Reader) *ndjsonSource {
+	//one more byte for a line break
+	return &ndjsonSource{reader: bufio.NewReaderSize(r, MaxBulkLineLength+1)}
+}
+
+// Next returns the next URL and its line number.
+// A line longer than MaxBulkLineLength is skipped, it gets an error like a line with a bad JSON.
+func (s *ndjsonSource) Next() (entities.URL, int, error) {
+	for {
+		line, err := s.reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			s.line++
+			err = s.skipLine()
+			if err != nil {
+				return entities.URL{}, s.line, err
+			}
+			return entities.URL{}, s.line, fmt.Errorf("%w: line is longer than %d bytes", logic.ErrBadURLParams(), MaxBulkLineLength)
+		}
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return entities.URL{}, s.line, io.EOF
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return entities.URL{}, s.line + 1, err
+		}
+
+		s.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		URL := entities.URL{}
+		err = json.Unmarshal(line, &URL)
+		if err != nil {
+			return entities.URL{}, s.line, fmt.Errorf("%w: can`t parse JSON: %v", logic.ErrBadURLParams(), err)
+		}
+		return URL, s.line, nil
+	}
+}
+
+// skipLine reads the rest of a current line without keeping it.
+func (s *ndjsonSource) skipLine() error {
+	for {
+		_, err := s.reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+}
+
+// csvSource reads URLs from a CSV with a header.
+type csvSource struct {
+	reader  *csv.Reader
+	limiter *csvRecordLimiter
+	header  []string
+	line    int
+}
+
+// newCSVSource reads a header of a CSV and builds a new csvSource.
+// Returns an error if a header is not correct.
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	limiter := &csvRecordLimiter{r: r, allowed: csvReadLimit}
+	reader := csv.NewReader(bufio.NewReaderSize(limiter, MaxBulkLineLength))
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("can`t read CSV header: %w", err)
+	}
+	limiter.allowed = reader.InputOffset() + csvReadLimit
+
+	hasOriginalURL := false
+	columns := make([]string, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		_, known := csvColumns[column]
+		if !known && !strings.HasPrefix(column, logic.UTMPrefix) {
+			return nil, fmt.Errorf("unknown CSV column `%s`", column)
+		}
+		if column == "original_url" {
+			hasOriginalURL = true
+		}
+		columns[i] = column
+	}
+	if !hasOriginalURL {
+		return nil, errors.New("CSV header has to contain `original_url` column")
+	}
+	return &csvSource{reader: reader, limiter: limiter, header: columns}, nil
+}
+
+// Next returns the next URL and its line number.
+// A record longer than MaxBulkLineLength gets an error like a broken record. A record witch is much longer
+// (it doesn`t fit into csvReadLimit) stops reading, so a huge quoted field can`t take all memory.
+func (s *csvSource) Next() (entities.URL, int, error) {
+	start := s.reader.InputOffset()
+	record, err := s.reader.Read()
+	s.limiter.allowed = s.reader.InputOffset() + csvReadLimit
+	if errors.Is(err, io.EOF) {
+		return entities.URL{}, 0, io.EOF
+	}
+	parseErr := &csv.ParseError{}
+	if errors.As(err, &parseErr) {
+		s.line = parseErr.Line
+		return entities.URL{}, parseErr.StartLine, fmt.Errorf("%w: %v", logic.ErrBadURLParams(), parseErr.Err)
+	}
+	if err != nil {
+		return entities.URL{}, s.line + 1, err
+	}
+	line, _ := s.reader.FieldPos(0)
+	s.line, _ = s.reader.FieldPos(len(record) - 1)
+	if s.reader.InputOffset()-start > MaxBulkLineLength {
+		return entities.URL{}, line, fmt.Errorf("%w: record is longer than %d bytes", logic.ErrBadURLParams(), MaxBulkLineLength)
+	}
+
+	values := url.Values{}
+	for i, value := range record {
+		if value != "" {
+			values.Set(s.header[i], value)
+		}
+	}
+	URL, err := urlParamsFromQuery(values)
+	if err != nil {
+		return entities.URL{}, line, fmt.Errorf("%w: %v", logic.ErrBadURLParams(), err)
+	}
+	URL.CorrelationID = values.Get("correlation_id")
+	URL.OriginalURL = values.Get("original_url")
+	URL.Password = values.Get("password")
+	return URL, line, nil
+}
+
+// csvReadLimit is an amount of bytes witch can be read after the end of the last CSV record.
+// A csv.Reader reads ahead by its buffer, so it is more than MaxBulkLineLength.
+const csvReadLimit = 2 * MaxBulkLineLength
+
+var errCSVRecordTooLong = fmt.Errorf("CSV record is longer than %d bytes", MaxBulkLineLength)
+
+// csvRecordLimiter limits a length of CSV records: it returns an error if more than `allowed` bytes are read
+// (a source moves `allowed` after every record).
+type csvRecordLimiter struct {
+	r       io.Reader
+	read    int64
+	allowed int64
+}
+
+// Read reads from an underlying reader until a limit is reached.
+func (l *csvRecordLimiter) Read(p []byte) (int, error) {
+	if l.read >= l.allowed {
+		return 0, errCSVRecordTooLong
+	}
+	if int64(len(p)) > l.allowed-l.read {
+		p = p[:l.allowed-l.read]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
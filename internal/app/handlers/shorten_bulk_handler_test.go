@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestShortenBulkHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		reqBody     string
+		statusWant  int
+		errorsWant  []bool // one value per result line, true means that a line has an error
+		stopWant    bool   // processing has to stop, the last result line has an error without a line number
+	}{
+		{
+			name:        "NDJSON",
+			contentType: ContentTypeNDJSON,
+			reqBody: `{"correlation_id": "1", "original_url": "https://example.com/1"}
+{"correlation_id": "2", "original_url": "https://example.com/2", "tags": ["a", "b"]}
+
+not a JSON
+{"correlation_id": "5", "original_url": "https://example.com/5", "max_clicks": -1}
+{"correlation_id": "6", "original_url": "https://example.com/6"}
+`,
+			statusWant: http.StatusOK,
+			errorsWant: []bool{false, false, true, true, false},
+		},
+		{
+			name:        "CSV",
+			contentType: ContentTypeCSV + "; charset=utf-8",
+			reqBody: `correlation_id,original_url,tags,utm_source
+1,https://example.org/1,"a,b",newsletter
+2,,,
+3,https://example.org/3,,
+4,https://example.org/4
+`,
+			statusWant: http.StatusOK,
+			errorsWant: []bool{false, true, false, true},
+		},
+		{
+			name:        "NDJSON with a too long line",
+			contentType: ContentTypeNDJSON,
+			reqBody: `{"correlation_id": "1", "original_url": "https://example.com/short"}
+{"correlation_id": "2", "original_url": "https://example.com/long?q=` + strings.Repeat("a", MaxBulkLineLength) + `"}
+{"correlation_id": "3", "original_url": "https://example.com/after-long"}`,
+			statusWant: http.StatusOK,
+			errorsWant: []bool{false, true, false},
+		},
+		{
+			name:        "CSV with a too long record",
+			contentType: ContentTypeCSV,
+			reqBody: "correlation_id,original_url,notes\n" +
+				"1,https://example.org/short,\n" +
+				`2,https://example.org/long,"` + strings.Repeat("a\n", MaxBulkLineLength/2+10) + `"` + "\n" +
+				"3,https://example.org/after-long,\n",
+			statusWant: http.StatusOK,
+			errorsWant: []bool{false, true, false},
+		},
+		{
+			name:        "CSV with a huge record",
+			contentType: ContentTypeCSV,
+			reqBody: "correlation_id,original_url,notes\n" +
+				"1,https://example.org/before-huge,\n" +
+				`2,https://example.org/huge,"` + strings.Repeat("a", 10*MaxBulkLineLength) + `"` + "\n" +
+				"3,https://example.org/after-huge,\n",
+			statusWant: http.StatusOK,
+			errorsWant: []bool{}, //the first line waits for a full chunk, so it is not saved
+			stopWant:   true,
+		},
+		{
+			name:        "CSV with unknown column",
+			contentType: ContentTypeCSV,
+			reqBody:     "original_url,unknown\nhttps://example.org/1,a\n",
+			statusWant:  http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/xml",
+			reqBody:     "<urls/>",
+			statusWant:  http.StatusUnsupportedMediaType,
+		},
+	}
+
+	//test server building
+	conf := config.Config{
+		BaseAddress:   "http://localhost:8080",
+		ServerAddress: "localhost:8080",
+		BulkChunkSize: 2,
+	}
+	URLStore := databases.NewJustAMap()
+	sugar := zaptest.NewLogger(t).Sugar()
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	r, err := NewRouter(conf, URLStore, *sugar, logic.NewSessions(URLStore, jh, config.Config{}), logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}), logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
+	require.NoError(t, err, "error while creating a router in test")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/shorten/bulk", strings.NewReader(tt.reqBody))
+			require.NoError(t, err, "Error while creating a request")
+			req.Header.Set("Content-Type", tt.contentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, "Error while making a request")
+			defer resp.Body.Close()
+			require.Equal(t, tt.statusWant, resp.StatusCode)
+			if tt.statusWant != http.StatusOK {
+				return
+			}
+			assert.Equal(t, ContentTypeNDJSON, resp.Header.Get("Content-Type"))
+
+			results := make([]entities.BulkResult, 0)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				result := entities.BulkResult{}
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+				results = append(results, result)
+			}
+			require.NoError(t, scanner.Err())
+			if tt.stopWant {
+				require.NotEmpty(t, results)
+				last := results[len(results)-1]
+				assert.Zero(t, last.Line)
+				assert.Contains(t, last.Error, "processing stopped")
+				results = results[:len(results)-1]
+			}
+			require.Len(t, results, len(tt.errorsWant))
+			for i, result := range results {
+				assert.NotZero(t, result.Line)
+				if tt.errorsWant[i] {
+					assert.NotEmpty(t, result.Error, "line %d", result.Line)
+					continue
+				}
+				assert.Empty(t, result.Error, "line %d", result.Line)
+				short := strings.TrimPrefix(result.ShortURL, conf.BaseAddress+"/")
+				_, err := URLStore.GetURL(context.Background(), short)
+				assert.NoError(t, err, "URL of line %d was not saved", result.Line)
+			}
+		})
+	}
+
+	//CSV fields are saved
+	short := string(logic.ShortenURL([]byte("https://example.org/1")))
+	URL, err := URLStore.GetURL(context.Background(), short)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, URL.Tags)
+	assert.Equal(t, map[string]string{"utm_source": "newsletter"}, URL.UTM)
+}
@@ -8,9 +8,10 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/Lesnoi3283/url_shortener/config"
-	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 	"go.uber.org/zap"
 )
 
@@ -22,6 +23,15 @@ type ShortenHandler struct {
 }
 
 // ServeHTTP shorts given url (JSON), saves it in a storage and return a short version.
+// Optional "expires_at" (RFC3339) or "ttl" (for example "24h") fields set an expiration of a short URL,
+// optional "max_clicks" field sets a clicks limit, optional "password" field protects a short URL.
+// Optional "title", "notes" and "tags" fields help to find a URL later.
+// Optional "redirect_code" field sets a status code of redirects (301, 302, 307 or 308).
+// Optional "pass_query" field enables or disables passing of redirect requests queries to an original URL,
+// optional "utm" object contains "utm_*" params witch are added to an original URL on redirects.
+// Optional "rules" array contains redirect rules (objects with "device", "language" and "destination" fields).
+// Optional "variants" array contains weighted A/B destinations (objects with "name", "destination" and "weight" fields),
+// optional "sticky_variants" field makes visitors keep their variants.
 func (h *ShortenHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	//this var is using for changing status to 409 if url already exists
 	successStatus := http.StatusCreated
@@ -37,7 +47,20 @@ func (h *ShortenHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 
 	//unmarshalling JSON
 	realURL := struct {
-		Val string `json:"url"`
+		Val            string                  `json:"url"`
+		ExpiresAt      *time.Time              `json:"expires_at"`
+		TTL            string                  `json:"ttl"`
+		MaxClicks      int                     `json:"max_clicks"`
+		Password       string                  `json:"password"`
+		Title          string                  `json:"title"`
+		Notes          string                  `json:"notes"`
+		Tags           []string                `json:"tags"`
+		RedirectCode   int                     `json:"redirect_code"`
+		PassQuery      *bool                   `json:"pass_query"`
+		UTM            map[string]string       `json:"utm"`
+		Rules          []entities.RedirectRule `json:"rules"`
+		Variants       []entities.Variant      `json:"variants"`
+		StickyVariants bool                    `json:"sticky_variants"`
 	}{}
 
 	err = json.Unmarshal(bodyBytes, &realURL)
@@ -48,18 +71,32 @@ func (h *ShortenHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	}
 
 	//get userID and short the URL
-	userIDFromContext := req.Context().Value(middlewares.UserIDContextKey)
-	userID, ok := (userIDFromContext).(int)
-	var urlShort string
-	if ok {
-		urlShort, err = logic.Shorten(req.Context(), []byte(realURL.Val), h.Conf.BaseAddress, h.URLStorage, userID)
-	} else {
-		urlShort, err = logic.Shorten(req.Context(), []byte(realURL.Val), h.Conf.BaseAddress, h.URLStorage, -1)
+	userID, _ := logic.UserIDFromContext(req.Context())
+	URL := entities.URL{
+		OriginalURL:    realURL.Val,
+		ExpiresAt:      realURL.ExpiresAt,
+		TTL:            realURL.TTL,
+		MaxClicks:      realURL.MaxClicks,
+		Password:       realURL.Password,
+		Title:          realURL.Title,
+		Notes:          realURL.Notes,
+		Tags:           realURL.Tags,
+		RedirectCode:   realURL.RedirectCode,
+		PassQuery:      realURL.PassQuery,
+		UTM:            realURL.UTM,
+		Rules:          realURL.Rules,
+		Variants:       realURL.Variants,
+		StickyVariants: realURL.StickyVariants,
 	}
+	urlShort, err := logic.Shorten(req.Context(), URL, h.Conf.BaseAddress, h.URLStorage, userID)
 	var alrExErr *databases.AlreadyExistsError
 	if errors.As(err, &alrExErr) {
 		urlShort = alrExErr.ShortURL
 		successStatus = http.StatusConflict
+	} else if errors.Is(err, logic.ErrBadURLParams()) {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Bad URL params: %v", err)
+		return
 	} else if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
 		h.Log.Errorf("Error while shortening URL '%s': %v", realURL.Val, err)
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic/mocks"
 	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Lesnoi3283/url_shortener/config"
 	"github.com/Lesnoi3283/url_shortener/pkg/databases"
@@ -53,7 +55,7 @@ func TestURLShortenHandler_ServeHTTP(t *testing.T) {
 
 	jh := secure.NewJWTHelper("testSecretKey", 5)
 
-	r, err := NewRouter(conf, URLStore, *sugar, jh)
+	r, err := NewRouter(conf, URLStore, *sugar, logic.NewSessions(URLStore, jh, config.Config{}), logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}), logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
 	require.NoError(t, err, "error while creating a router in test")
 	ts := httptest.NewServer(r)
 
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"go.uber.org/zap"
+)
+
+// credentials is a request body of SignUpHandler and LogInHandler.
+type credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// SignUpHandler is a handler struct. Use it`s ServeHTTP func.
+type SignUpHandler struct {
+	Accounts *logic.Accounts
+	Sessions *logic.Sessions
+	Log      zap.SugaredLogger
+}
+
+// ServeHTTP creates a new account. Request body is a JSON with "login" and "password" fields.
+// URLs of an anonymous user (from a cookie or a bearer token) are claimed by a new account.
+// Response is a JSON with tokens of a new session of an account (see writeAuthResult), http.StatusConflict is returned if a login is taken.
+func (h *SignUpHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	reqData := credentials{}
+	err := json.NewDecoder(req.Body).Decode(&reqData)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Error while decoding req body: %v", err)
+		return
+	}
+
+	result, err := h.Accounts.SignUp(req.Context(), reqData.Login, reqData.Password, logic.AnonymousUserID(req.Context()))
+	if errors.Is(err, logic.ErrBadAccountParams()) {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Bad account params: %v", err)
+		return
+	} else if errors.Is(err, databases.ErrLoginTaken()) {
+		res.WriteHeader(http.StatusConflict)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while signing up: %v", err)
+		return
+	}
+
+	writeAuthResult(res, req, http.StatusCreated, h.Sessions, result, h.Log)
+}
+
+// sessionResponse is a JSON with tokens of a session.
+type sessionResponse struct {
+	Token            string    `json:"token"`
+	UserID           int       `json:"user_id"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// newSessionResponse builds a sessionResponse from tokens.
+func newSessionResponse(tokens logic.SessionTokens) sessionResponse {
+	return sessionResponse{
+		Token:            tokens.AccessToken,
+		UserID:           tokens.UserID,
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+// writeAuthResult starts a new session of an account and writes its tokens (see writeSession)
+// with "login" and "claimed_urls" fields.
+func writeAuthResult(res http.ResponseWriter, req *http.Request, code int, sessions *logic.Sessions, result logic.AuthResult, log zap.SugaredLogger) {
+	tokens, err := sessions.Start(req.Context(), result.Account.UserID)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		log.Errorf("Error while starting a session: %v", err)
+		return
+	}
+
+	resData := struct {
+		sessionResponse
+		Login       string `json:"login"`
+		ClaimedURLs int    `json:"claimed_urls"`
+	}{
+		sessionResponse: newSessionResponse(tokens),
+		Login:           result.Account.Login,
+		ClaimedURLs:     result.ClaimedURLs,
+	}
+	writeSession(res, req, code, tokens, resData, log)
+}
+
+// writeSession sends tokens of a session in cookies (see middlewares.SetSessionCookies), an access token
+// in a middlewares.AuthTokenHeader header and resData in a JSON body.
+func writeSession(res http.ResponseWriter, req *http.Request, code int, tokens logic.SessionTokens, resData any, log zap.SugaredLogger) {
+	resp, err := json.Marshal(resData)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		log.Errorf("Error while marshalling response: %v", err)
+		return
+	}
+
+	middlewares.SetSessionCookies(res, req, tokens)
+	res.Header().Set(middlewares.AuthTokenHeader, tokens.AccessToken)
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(code)
+	_, err = res.Write(resp)
+	if err != nil {
+		log.Errorf("Error while writing response: %v", err)
+	}
+}
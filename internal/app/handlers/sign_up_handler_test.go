@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAccountHandlers(t *testing.T) {
+	//test server building
+	conf := config.Config{
+		BaseAddress:   "http://localhost:8080",
+		ServerAddress: "localhost:8080",
+	}
+	URLStore := databases.NewJustAMap()
+	sugar := zaptest.NewLogger(t).Sugar()
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	accounts := logic.NewAccounts(URLStore, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute})
+	r, err := NewRouter(conf, URLStore, *sugar, logic.NewSessions(URLStore, jh, config.Config{}), logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}), accounts, logic.NewAPIKeys(URLStore))
+	require.NoError(t, err, "error while creating a router in test")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	type authResponse struct {
+		Token       string `json:"token"`
+		UserID      int    `json:"user_id"`
+		Login       string `json:"login"`
+		ClaimedURLs int    `json:"claimed_urls"`
+	}
+	post := func(t *testing.T, path string, body string, token string) (*http.Response, authResponse) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err, "Error while creating a request")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "Error while making a request")
+		defer resp.Body.Close()
+		data := authResponse{}
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+		}
+		return resp, data
+	}
+
+	//an anonymous user shortens a URL
+	resp, err := http.Post(ts.URL+"/api/shorten", "application/json", strings.NewReader(`{"url": "https://example.com"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	anonymousToken := resp.Header.Get(middlewares.AuthTokenHeader)
+	require.NotEmpty(t, anonymousToken)
+
+	//sign up
+	resp, signedUp := post(t, "/api/user/register", `{"login": "User", "password": "secret password"}`, anonymousToken)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "user", signedUp.Login)
+	assert.Equal(t, 1, signedUp.ClaimedURLs)
+	userID, err := jh.GetUserID(signedUp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, signedUp.UserID, userID)
+	assert.Equal(t, signedUp.Token, resp.Header.Get(middlewares.AuthTokenHeader))
+
+	t.Run("login taken", func(t *testing.T) {
+		resp, _ := post(t, "/api/user/register", `{"login": "user", "password": "other password"}`, "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("bad params", func(t *testing.T) {
+		resp, _ := post(t, "/api/user/register", `{"login": "new user", "password": "short"}`, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = post(t, "/api/user/register", `not a JSON`, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("log in", func(t *testing.T) {
+		resp, loggedIn := post(t, "/api/user/login", `{"login": "user", "password": "secret password"}`, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, signedUp.UserID, loggedIn.UserID)
+		assert.Equal(t, 0, loggedIn.ClaimedURLs)
+
+		//account URLs are available with a new token
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/user/urls", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+loggedIn.Token)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("API key URLs are not claimed", func(t *testing.T) {
+		ctx := context.Background()
+		ownerID, err := URLStore.CreateUser(ctx)
+		require.NoError(t, err, "error while preparing a storage")
+		err = URLStore.SaveWithUserID(ctx, ownerID, entities.URL{ShortURL: "owned", OriginalURL: "https://example.com/owned"})
+		require.NoError(t, err, "error while preparing a storage")
+		key, _, err := logic.NewAPIKeys(URLStore).Create(ctx, ownerID, "read only", []string{logic.ScopeRead}, nil)
+		require.NoError(t, err, "error while preparing an API key")
+
+		resp, loggedIn := post(t, "/api/user/login", `{"login": "user", "password": "secret password"}`, key)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 0, loggedIn.ClaimedURLs)
+		resp, signedUp := post(t, "/api/user/register", `{"login": "key-user", "password": "secret password"}`, key)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, 0, signedUp.ClaimedURLs)
+
+		URL, err := URLStore.GetURL(ctx, "owned")
+		require.NoError(t, err)
+		assert.Equal(t, ownerID, URL.UserID)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		resp, _ := post(t, "/api/user/login", `{"login": "user", "password": "wrong password"}`, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+	t.Run("X-Real-IP doesn`t reset attempts", func(t *testing.T) {
+		attempt := func(password string, ip string) int {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/user/login", strings.NewReader(`{"login": "user", "password": "`+password+`"}`))
+			require.NoError(t, err, "Error while creating a request")
+			req.Header.Set("X-Real-IP", ip)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, "Error while making a request")
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		for i := 0; i < 5; i++ {
+			attempt("wrong password", fmt.Sprintf("203.0.113.%d", i))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, attempt("secret password", "203.0.113.100"))
+	})
+}
@@ -58,7 +58,7 @@ func TestStatsHandler_ServeHTTP(t *testing.T) {
 				storage: func() logic.URLStorageInterface {
 					storage := mocks.NewMockURLStorageInterface(c)
 					storage.EXPECT().GetShortURLCount(gomock.Any()).Return(correctData.URLs, nil)
-					storage.EXPECT().GetUserCount(gomock.Any()).Return(correctData.Users, nil)
+					storage.EXPECT().GetUsersCount(gomock.Any()).Return(correctData.Users, nil)
 					return storage
 				}(),
 			},
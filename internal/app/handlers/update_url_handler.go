@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// UpdateURLHandler is a handler struct. Use it`s ServeHTTP func.
+type UpdateURLHandler struct {
+	URLStorage logic.URLStorageInterface
+	Conf       config.Config
+	Log        zap.SugaredLogger
+}
+
+// ServeHTTP changes an original URL, a title, notes, tags, redirect rules or A/B variants of a short URL.
+// Only for an owner of a URL. Request body is a JSON with optional "url", "title", "notes", "tags", "rules", "variants"
+// and "sticky_variants" fields (missing fields are not changed). Response is a JSON with a short URL and the same fields.
+// If given original URL is already shortened, http.StatusConflict is returned with an existing short URL.
+func (h *UpdateURLHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	shortURL := chi.URLParam(req, "short")
+
+	//read request params
+	reqData := struct {
+		URL            *string                  `json:"url"`
+		Title          *string                  `json:"title"`
+		Notes          *string                  `json:"notes"`
+		Tags           *[]string                `json:"tags"`
+		Rules          *[]entities.RedirectRule `json:"rules"`
+		Variants       *[]entities.Variant      `json:"variants"`
+		StickyVariants *bool                    `json:"sticky_variants"`
+	}{}
+	err := json.NewDecoder(req.Body).Decode(&reqData)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Error while decoding req body: %v", err)
+		return
+	}
+
+	userID, ok := logic.UserIDFromContext(req.Context())
+	if !ok {
+		res.WriteHeader(http.StatusUnauthorized)
+		h.Log.Error("UserID is nil")
+		return
+	}
+
+	//update
+	update := entities.URLUpdate{
+		OriginalURL:    reqData.URL,
+		Title:          reqData.Title,
+		Notes:          reqData.Notes,
+		Tags:           reqData.Tags,
+		Rules:          reqData.Rules,
+		Variants:       reqData.Variants,
+		StickyVariants: reqData.StickyVariants,
+	}
+	err = logic.UpdateURL(req.Context(), h.URLStorage, userID, shortURL, update)
+	var alrExErr *databases.AlreadyExistsError
+	if errors.As(err, &alrExErr) {
+		h.writeURL(res, http.StatusConflict, entities.URL{ShortURL: alrExErr.ShortURL, OriginalURL: *reqData.URL})
+		return
+	} else if errors.Is(err, logic.ErrBadURLParams()) {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Bad URL params: %v", err)
+		return
+	} else if errors.Is(err, databases.ErrURLNotFound()) {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while updating URL '%s': %v", shortURL, err)
+		return
+	}
+
+	//response making
+	updated, err := h.URLStorage.GetURL(req.Context(), shortURL)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Errorf("Error while getting updated URL '%s': %v", shortURL, err)
+		return
+	}
+	h.writeURL(res, http.StatusOK, entities.URL{
+		ShortURL:       updated.ShortURL,
+		OriginalURL:    updated.OriginalURL,
+		Title:          updated.Title,
+		Notes:          updated.Notes,
+		Tags:           updated.Tags,
+		Rules:          updated.Rules,
+		Variants:       updated.Variants,
+		StickyVariants: updated.StickyVariants,
+	})
+}
+
+// writeURL writes a URL (with a base address) as a JSON response.
+func (h *UpdateURLHandler) writeURL(res http.ResponseWriter, status int, url entities.URL) {
+	url.ShortURL = h.Conf.BaseAddress + "/" + url.ShortURL
+	jsonResp, err := json.Marshal(url)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Error("Error while marshalling response", zap.Error(err))
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	res.Write(jsonResp)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestUpdateURLHandler_ServeHTTP(t *testing.T) {
+	//prepare storage
+	ownerID := 1
+	URLStore := databases.NewJustAMap()
+	err := URLStore.SaveWithUserID(context.Background(), ownerID, entities.URL{
+		ShortURL:    "typo",
+		OriginalURL: "https://practicum.yandx.ru/",
+	})
+	require.NoError(t, err, "error while preparing a storage")
+	err = URLStore.SaveWithUserID(context.Background(), ownerID, entities.URL{
+		ShortURL:    "other",
+		OriginalURL: "https://ya.ru/",
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare router
+	logger := zaptest.NewLogger(t)
+	conf := config.Config{BaseAddress: "http://localhost:8080"}
+	h := UpdateURLHandler{
+		URLStorage: URLStore,
+		Conf:       conf,
+		Log:        *logger.Sugar(),
+	}
+	r := chi.NewRouter()
+	r.Patch("/api/user/urls/{short}", h.ServeHTTP)
+
+	tests := []struct {
+		name       string
+		short      string
+		body       string
+		userID     interface{}
+		statusWant int
+		shortWant  string
+	}{
+		{
+			name:       "not an owner",
+			short:      "typo",
+			body:       `{"url":"https://practicum.yandex.ru/"}`,
+			userID:     2,
+			statusWant: http.StatusNotFound,
+		},
+		{
+			name:       "no user",
+			short:      "typo",
+			body:       `{"url":"https://practicum.yandex.ru/"}`,
+			userID:     nil,
+			statusWant: http.StatusUnauthorized,
+		},
+		{
+			name:       "bad url",
+			short:      "typo",
+			body:       `{"url":"not a url"}`,
+			userID:     ownerID,
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "already shortened url",
+			short:      "typo",
+			body:       `{"url":"https://ya.ru/"}`,
+			userID:     ownerID,
+			statusWant: http.StatusConflict,
+			shortWant:  conf.BaseAddress + "/other",
+		},
+		{
+			name:       "too many tags",
+			short:      "other",
+			body:       `{"tags":["1","2","3","4","5","6","7","8","9","10","11","12","13","14","15","16","17","18","19","20","21"]}`,
+			userID:     ownerID,
+			statusWant: http.StatusBadRequest,
+		},
+		{
+			name:       "title and tags",
+			short:      "other",
+			body:       `{"title":" Search engine ","tags":["Search","search"]}`,
+			userID:     ownerID,
+			statusWant: http.StatusOK,
+			shortWant:  conf.BaseAddress + "/other",
+		},
+		{
+			name:       "ok",
+			short:      "typo",
+			body:       `{"url":"https://practicum.yandex.ru/"}`,
+			userID:     ownerID,
+			statusWant: http.StatusOK,
+			shortWant:  conf.BaseAddress + "/typo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/"+tt.short, strings.NewReader(tt.body))
+			if tt.userID != nil {
+				req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDContextKey, tt.userID))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.statusWant, w.Code)
+			if tt.shortWant == "" {
+				return
+			}
+			resp := entities.URL{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.shortWant, resp.ShortURL)
+		})
+	}
+
+	//a new original URL is used
+	full, err := URLStore.Get(context.Background(), "typo")
+	require.NoError(t, err)
+	assert.Equal(t, "https://practicum.yandex.ru/", full)
+
+	//meta is normalized and other fields are not changed
+	other, err := URLStore.GetURL(context.Background(), "other")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ya.ru/", other.OriginalURL)
+	assert.Equal(t, "Search engine", other.Title)
+	assert.Equal(t, []string{"search"}, other.Tags)
+}
+
+func TestUpdateURLHandler_Rules(t *testing.T) {
+	//prepare storage
+	ownerID := 1
+	URLStore := databases.NewJustAMap()
+	err := URLStore.SaveWithUserID(context.Background(), ownerID, entities.URL{
+		ShortURL:    "app",
+		OriginalURL: "https://example.com/",
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare router
+	logger := zaptest.NewLogger(t)
+	conf := config.Config{BaseAddress: "http://localhost:8080", PasswordAttempts: 5, PasswordWindow: time.Minute}
+	updateHandler := UpdateURLHandler{
+		URLStorage: URLStore,
+		Conf:       conf,
+		Log:        *logger.Sugar(),
+	}
+	redirectHandler := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, nil, conf),
+		Log:        *logger.Sugar(),
+	}
+	r := chi.NewRouter()
+	r.Patch("/api/user/urls/{short}", updateHandler.ServeHTTP)
+	r.Get("/{url}", redirectHandler.ServeHTTP)
+
+	redirect := func(userAgent string) string {
+		req := httptest.NewRequest(http.MethodGet, "/app", nil)
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+		return w.Header().Get("Location")
+	}
+	setRules := func(rules string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/app", strings.NewReader(`{"rules":`+rules+`}`))
+		req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDContextKey, ownerID))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	iPhone := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"
+	desktop := "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"
+
+	//set rules
+	assert.Equal(t, http.StatusBadRequest, setRules(`[{"device":"tv","destination":"https://example.com/tv"}]`))
+	assert.Equal(t, http.StatusOK, setRules(`[{"device":"ios","destination":"https://apps.apple.com/app"}]`))
+	assert.Equal(t, "https://apps.apple.com/app", redirect(iPhone))
+	assert.Equal(t, "https://example.com/", redirect(desktop))
+
+	//clear rules
+	assert.Equal(t, http.StatusOK, setRules(`[]`))
+	assert.Equal(t, "https://example.com/", redirect(iPhone))
+}
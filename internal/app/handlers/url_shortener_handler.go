@@ -2,43 +2,113 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"go.uber.org/zap"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Lesnoi3283/url_shortener/config"
-	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
 	"github.com/Lesnoi3283/url_shortener/pkg/databases"
 	"github.com/go-chi/chi"
 )
 
 // ShortURLRedirectHandler is a handler struct. Use it`s ServeHTTP func.
 type ShortURLRedirectHandler struct {
-	URLStorage logic.URLStorageInterface
+	Redirector *logic.Redirector
+	Proxies    logic.TrustedProxies
 	Log        zap.SugaredLogger
 }
 
+// LinkPasswordHeader is a header witch API clients can use to send a password of a protected URL.
+const LinkPasswordHeader = "X-Link-Password"
+
+// VariantCookieName is a name of a cookie witch keeps an A/B variant of a visitor.
+// Every short URL has its own cookie (cookie path is a short URL).
+const VariantCookieName = "ab_variant"
+
+// VariantCookieMaxAge is a time while a visitor keeps the same A/B variant (in seconds).
+const VariantCookieMaxAge = 30 * 24 * 60 * 60
+
 // ServeHTTP reads short URL from given URLParam and redirects user to an original URL.
+// Status code is a redirect code of a URL (or a default one from a config).
+// Redirect rules of a URL are checked using User-Agent and Accept-Language headers,
+// a request query is added to a destination if query passing is enabled (see logic.Redirector.Resolve).
+// A/B variant of a visitor is kept in VariantCookieName cookie if URL has sticky variants.
+// If URL is protected by a password, it has to be sent in LinkPasswordHeader or in a "password" form value (POST).
+// Browsers get a password form in this case.
 func (h *ShortURLRedirectHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	//reading data from request
 	shorted := chi.URLParam(req, "url")
+	password := req.Header.Get(LinkPasswordHeader)
+	fromHeader := password != ""
+	if !fromHeader && req.Method == http.MethodPost {
+		password = req.PostFormValue("password")
+	}
+
+	visit := logic.Visit{
+		Password:       password,
+		Referrer:       req.Referer(),
+		UserAgent:      req.UserAgent(),
+		AcceptLanguage: req.Header.Get("Accept-Language"),
+		IP:             clientIP(req, h.Proxies),
+		Query:          req.URL.Query(),
+	}
+	if cookie, err := req.Cookie(VariantCookieName); err == nil {
+		visit.Variant = cookie.Value
+	}
 
 	//reading from DB
-	fullURL, err := logic.GetOriginalURL(req.Context(), shorted, h.URLStorage)
-	if errors.Is(err, databases.ErrURLWasDeleted()) {
+	redirect, err := h.Redirector.Resolve(req.Context(), shorted, visit)
+	switch {
+	case errors.Is(err, databases.ErrURLWasDeleted()),
+		errors.Is(err, databases.ErrURLExpired()),
+		errors.Is(err, databases.ErrClicksLimitReached()):
 		res.WriteHeader(http.StatusGone)
 		return
-	}
-	if err != nil {
+	case errors.Is(err, logic.ErrPasswordRequired()):
+		h.writePasswordForm(res, http.StatusUnauthorized, "", fromHeader)
+		return
+	case errors.Is(err, logic.ErrWrongPassword()):
+		h.writePasswordForm(res, http.StatusForbidden, "Wrong password", fromHeader)
+		return
+	case errors.Is(err, logic.ErrTooManyAttempts()):
+		res.WriteHeader(http.StatusTooManyRequests)
+		return
+	case err != nil:
 		res.WriteHeader(http.StatusBadRequest)
 		h.Log.Warnf("error while getting an original URL: %v", err)
 		return
 	}
 
 	//response preparing
-	res.Header().Set("Location", fullURL)
-	res.WriteHeader(http.StatusTemporaryRedirect)
+	if redirect.StickyVariant {
+		http.SetCookie(res, &http.Cookie{
+			Name:     VariantCookieName,
+			Value:    redirect.Variant,
+			Path:     "/" + shorted,
+			MaxAge:   VariantCookieMaxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	res.Header().Set("Location", redirect.URL)
+	if req.Method == http.MethodPost {
+		//after a password form browser have to make a GET request
+		res.WriteHeader(http.StatusSeeOther)
+		return
+	}
+	res.WriteHeader(redirect.Code)
+}
+
+// clientIP returns an IP of a client from a remote address (or from X-Real-IP header of a trusted proxy).
+func clientIP(req *http.Request, proxies logic.TrustedProxies) string {
+	return proxies.ClientIP(req.RemoteAddr, req.Header.Get("X-Real-IP"))
 }
 
 // URLShortenerHandler is a handler struct. Use it`s ServeHTTP func.
@@ -49,6 +119,13 @@ type URLShortenerHandler struct {
 }
 
 // ServeHTTP shorts a given URL (plain text), saves it in a storage and returns a short version.
+// Expiration can be set using "expires_at" (RFC3339) or "ttl" (for example "24h") query params,
+// clicks limit can be set using "max_clicks" query param.
+// "title", "notes" and "tags" (comma separated) query params help to find a URL later.
+// "redirect_code" query param sets a status code of redirects (301, 302, 307 or 308).
+// "pass_query" query param enables or disables passing of redirect requests queries to an original URL,
+// "utm_*" query params are added to an original URL on redirects.
+// Password can be set using LinkPasswordHeader (it is not read from a query, because queries are often logged).
 func (h *URLShortenerHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	//this var is necessary. Because it helps to change status code to 409 if url already exists
 	successStatus := http.StatusCreated
@@ -60,22 +137,28 @@ func (h *URLShortenerHandler) ServeHTTP(res http.ResponseWriter, req *http.Reque
 		h.Log.Errorf("Error while reading reqBody: %v", err)
 		return
 	}
+	URL, err := urlParamsFromQuery(req.URL.Query())
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		h.Log.Debugf("Error while parsing URL params from query: %v", err)
+		return
+	}
+	URL.OriginalURL = string(realURLBytes)
+	URL.Password = req.Header.Get(LinkPasswordHeader)
 
 	//url saving
-	userIDFromContext := req.Context().Value(middlewares.UserIDContextKey)
-	userID, ok := (userIDFromContext).(int)
-	var shortURL string
-	if (userIDFromContext != nil) && (ok) {
-		shortURL, err = logic.Shorten(req.Context(), realURLBytes, h.Conf.BaseAddress, h.URLStorage, userID)
-	} else {
-		shortURL, err = logic.Shorten(req.Context(), realURLBytes, h.Conf.BaseAddress, h.URLStorage, -1)
-	}
+	userID, _ := logic.UserIDFromContext(req.Context())
+	shortURL, err := logic.Shorten(req.Context(), URL, h.Conf.BaseAddress, h.URLStorage, userID)
 
 	if err != nil {
 		var alrExErr *databases.AlreadyExistsError
 		if errors.As(err, &alrExErr) {
 			shortURL = alrExErr.ShortURL
 			successStatus = http.StatusConflict
+		} else if errors.Is(err, logic.ErrBadURLParams()) {
+			res.WriteHeader(http.StatusBadRequest)
+			h.Log.Debugf("Bad URL params: %v", err)
+			return
 		} else {
 			res.WriteHeader(http.StatusInternalServerError)
 			h.Log.Errorf("Error while shortening URL: %v\n", err)
@@ -88,3 +171,52 @@ func (h *URLShortenerHandler) ServeHTTP(res http.ResponseWriter, req *http.Reque
 	res.WriteHeader(successStatus)
 	res.Write([]byte(h.Conf.BaseAddress + "/" + shortURL))
 }
+
+// urlParamsFromQuery reads optional URL params from query values.
+func urlParamsFromQuery(query url.Values) (entities.URL, error) {
+	URL := entities.URL{
+		TTL:   query.Get("ttl"),
+		Title: query.Get("title"),
+		Notes: query.Get("notes"),
+	}
+	if tags := query.Get("tags"); tags != "" {
+		URL.Tags = strings.Split(tags, ",")
+	}
+	if expiresAt := query.Get("expires_at"); expiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return entities.URL{}, fmt.Errorf("can`t parse expires_at: %w", err)
+		}
+		URL.ExpiresAt = &parsed
+	}
+	if maxClicks := query.Get("max_clicks"); maxClicks != "" {
+		parsed, err := strconv.Atoi(maxClicks)
+		if err != nil {
+			return entities.URL{}, fmt.Errorf("can`t parse max_clicks: %w", err)
+		}
+		URL.MaxClicks = parsed
+	}
+	if redirectCode := query.Get("redirect_code"); redirectCode != "" {
+		parsed, err := strconv.Atoi(redirectCode)
+		if err != nil {
+			return entities.URL{}, fmt.Errorf("can`t parse redirect_code: %w", err)
+		}
+		URL.RedirectCode = parsed
+	}
+	if passQuery := query.Get("pass_query"); passQuery != "" {
+		parsed, err := strconv.ParseBool(passQuery)
+		if err != nil {
+			return entities.URL{}, fmt.Errorf("can`t parse pass_query: %w", err)
+		}
+		URL.PassQuery = &parsed
+	}
+	for key := range query {
+		if strings.HasPrefix(key, logic.UTMPrefix) {
+			if URL.UTM == nil {
+				URL.UTM = make(map[string]string)
+			}
+			URL.UTM[key] = query.Get(key)
+		}
+	}
+	return URL, nil
+}
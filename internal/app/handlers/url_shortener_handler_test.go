@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic/mocks"
 	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Lesnoi3283/url_shortener/config"
 	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestURLShortenerHandler(t *testing.T) {
@@ -66,7 +74,7 @@ func TestURLShortenerHandler(t *testing.T) {
 
 	jh := secure.NewJWTHelper("testSecretKey", 5)
 
-	r, err := NewRouter(conf, URLStore, *sugar, jh)
+	r, err := NewRouter(conf, URLStore, *sugar, logic.NewSessions(URLStore, jh, config.Config{}), logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}), logic.NewAccounts(URLStore, config.Config{}), logic.NewAPIKeys(URLStore))
 	require.NoError(t, err, "error while creating a router in test")
 	ts := httptest.NewServer(r)
 
@@ -136,3 +144,367 @@ func BenchmarkURLShortenerHandler_ServeHTTP(b *testing.B) {
 		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", strings.NewReader(reqBody)))
 	}
 }
+
+func TestShortURLRedirectHandler_Expired(t *testing.T) {
+	//prepare storage
+	URLStore := databases.NewJustAMap()
+	expiredAt := time.Now().Add(-time.Minute)
+	err := URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "expired",
+		OriginalURL: "https://practicum.yandex.ru/",
+		ExpiresAt:   &expiredAt,
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router
+	r := chi.NewRouter()
+	h := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}),
+		Log:        *sugar,
+	}
+	r.Get("/{url}", h.ServeHTTP)
+
+	//test
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/expired", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+}
+
+func TestShortURLRedirectHandler_ClicksLimit(t *testing.T) {
+	//prepare storage
+	maxClicks := 5
+	URLStore := databases.NewJustAMap()
+	err := URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "limited",
+		OriginalURL: "https://practicum.yandex.ru/",
+		MaxClicks:   maxClicks,
+		ClicksLeft:  &maxClicks,
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router
+	r := chi.NewRouter()
+	h := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}),
+		Log:        *sugar,
+	}
+	r.Get("/{url}", h.ServeHTTP)
+
+	//make concurrent redirects
+	requestsAmount := 20
+	codes := make(chan int, requestsAmount)
+	wg := sync.WaitGroup{}
+	for i := 0; i < requestsAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	//check that limit was not exceeded
+	redirects := 0
+	for code := range codes {
+		if code == http.StatusTemporaryRedirect {
+			redirects++
+		} else {
+			assert.Equal(t, http.StatusGone, code)
+		}
+	}
+	assert.Equal(t, maxClicks, redirects)
+}
+
+func TestShortURLRedirectHandler_RedirectCode(t *testing.T) {
+	//prepare storage
+	URLStore := databases.NewJustAMap()
+	conf := config.Config{BaseAddress: "http://localhost:8080", PasswordAttempts: 5, PasswordWindow: time.Minute, RedirectCode: http.StatusPermanentRedirect}
+	permanent, err := logic.Shorten(context.Background(), entities.URL{
+		OriginalURL:  "https://practicum.yandex.ru/",
+		RedirectCode: http.StatusMovedPermanently,
+	}, conf.BaseAddress, URLStore, -1)
+	require.NoError(t, err, "error while preparing a storage")
+	byDefault, err := logic.Shorten(context.Background(), entities.URL{
+		OriginalURL: "https://ya.ru/",
+	}, conf.BaseAddress, URLStore, -1)
+	require.NoError(t, err, "error while preparing a storage")
+	_, err = logic.Shorten(context.Background(), entities.URL{
+		OriginalURL:  "https://go.dev/",
+		RedirectCode: http.StatusOK,
+	}, conf.BaseAddress, URLStore, -1)
+	assert.True(t, errors.Is(err, logic.ErrBadURLParams()), "not a redirect code have to be rejected")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router
+	r := chi.NewRouter()
+	h := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, nil, conf),
+		Log:        *sugar,
+	}
+	r.Get("/{url}", h.ServeHTTP)
+
+	//test
+	tests := []struct {
+		name         string
+		short        string
+		statusWant   int
+		locationWant string
+	}{
+		{
+			name:         "code of a URL",
+			short:        strings.TrimPrefix(permanent, conf.BaseAddress+"/"),
+			statusWant:   http.StatusMovedPermanently,
+			locationWant: "https://practicum.yandex.ru/",
+		},
+		{
+			name:         "default code",
+			short:        strings.TrimPrefix(byDefault, conf.BaseAddress+"/"),
+			statusWant:   http.StatusPermanentRedirect,
+			locationWant: "https://ya.ru/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+tt.short, nil))
+			assert.Equal(t, tt.statusWant, w.Code)
+			assert.Equal(t, tt.locationWant, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestShortURLRedirectHandler_Query(t *testing.T) {
+	//prepare storage
+	URLStore := databases.NewJustAMap()
+	passQuery := true
+	err := URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "campaign",
+		OriginalURL: "https://practicum.yandex.ru/?ref=partner",
+		PassQuery:   &passQuery,
+		UTM:         map[string]string{"utm_medium": "poster"},
+	})
+	require.NoError(t, err, "error while preparing a storage")
+	err = URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "plain",
+		OriginalURL: "https://ya.ru/",
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router (query passing is disabled by default)
+	r := chi.NewRouter()
+	h := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, nil, config.Config{
+			PasswordAttempts: 5,
+			PasswordWindow:   time.Minute,
+			UTMParams:        "utm_source=shortener",
+		}),
+		Log: *sugar,
+	}
+	r.Get("/{url}", h.ServeHTTP)
+
+	//test
+	tests := []struct {
+		name         string
+		target       string
+		locationWant string
+	}{
+		{
+			name:         "query passing enabled for a URL",
+			target:       "/campaign?ref=visitor&utm_source=newsletter",
+			locationWant: "https://practicum.yandex.ru/?ref=partner&utm_medium=poster&utm_source=newsletter",
+		},
+		{
+			name:         "query passing disabled",
+			target:       "/plain?ref=visitor",
+			locationWant: "https://ya.ru/?utm_source=shortener",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+			assert.Equal(t, tt.locationWant, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestShortURLRedirectHandler_Variants(t *testing.T) {
+	//prepare storage
+	URLStore := databases.NewJustAMap()
+	err := URLStore.Save(context.Background(), entities.URL{
+		ShortURL:    "experiment",
+		OriginalURL: "https://ya.ru/",
+		Variants: []entities.Variant{
+			{Name: "a", Destination: "https://a.example.com/", Weight: 1},
+			{Name: "b", Destination: "https://b.example.com/", Weight: 1},
+		},
+		StickyVariants: true,
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router
+	r := chi.NewRouter()
+	h := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, &syncClickRecorder{t: t, storage: URLStore},
+			config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute}),
+		Log: *sugar,
+	}
+	r.Get("/{url}", h.ServeHTTP)
+
+	//the first visit assigns a variant
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/experiment", nil))
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1, "variant cookie expected")
+	assert.Equal(t, VariantCookieName, cookies[0].Name)
+	assert.Equal(t, "/experiment", cookies[0].Path)
+	assert.True(t, cookies[0].HttpOnly)
+	variant := cookies[0].Value
+	require.Contains(t, []string{"a", "b"}, variant)
+	location := w.Header().Get("Location")
+	assert.Equal(t, "https://"+variant+".example.com/", location)
+
+	//next visits keep the variant
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/experiment", nil)
+		req.AddCookie(&http.Cookie{Name: VariantCookieName, Value: variant})
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, location, w.Header().Get("Location"))
+	}
+
+	//stats are split by variants
+	stats, err := URLStore.GetURLStats(context.Background(), "experiment", 5)
+	require.NoError(t, err)
+	require.Len(t, stats.Variants, 1)
+	assert.Equal(t, variant, stats.Variants[0].Variant)
+	assert.Equal(t, 11, stats.Variants[0].Clicks)
+}
+
+func TestShortURLRedirectHandler_Password(t *testing.T) {
+	//prepare storage
+	URLStore := databases.NewJustAMap()
+	originalURL := "https://practicum.yandex.ru/"
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct"), bcrypt.MinCost)
+	require.NoError(t, err, "error while preparing a password hash")
+	err = URLStore.Save(context.Background(), entities.URL{
+		ShortURL:     "protected",
+		OriginalURL:  originalURL,
+		PasswordHash: string(hash),
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router
+	r := chi.NewRouter()
+	h := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, nil, config.Config{PasswordAttempts: 2, PasswordWindow: time.Minute}),
+		Log:        *sugar,
+	}
+	r.Get("/{url}", h.ServeHTTP)
+	r.Post("/{url}", h.ServeHTTP)
+
+	tests := []struct {
+		name         string
+		req          *http.Request
+		statusWant   int
+		locationWant string
+		wantForm     bool
+	}{
+		{
+			name:       "no password (form)",
+			req:        httptest.NewRequest(http.MethodGet, "/protected", nil),
+			statusWant: http.StatusUnauthorized,
+			wantForm:   true,
+		},
+		{
+			name: "correct password in a header",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+				req.Header.Set(LinkPasswordHeader, "correct")
+				return req
+			}(),
+			statusWant:   http.StatusTemporaryRedirect,
+			locationWant: originalURL,
+		},
+		{
+			name: "correct password in a form",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/protected", strings.NewReader("password=correct"))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			}(),
+			statusWant:   http.StatusSeeOther,
+			locationWant: originalURL,
+		},
+		{
+			name: "wrong password in a header",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+				req.Header.Set(LinkPasswordHeader, "wrong")
+				return req
+			}(),
+			statusWant: http.StatusForbidden,
+		},
+		{
+			name: "wrong password in a form",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/protected", strings.NewReader("password=wrong"))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			}(),
+			statusWant: http.StatusForbidden,
+			wantForm:   true,
+		},
+		{
+			name: "too many attempts",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+				req.Header.Set(LinkPasswordHeader, "correct")
+				return req
+			}(),
+			statusWant: http.StatusTooManyRequests,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, tt.req)
+			assert.Equal(t, tt.statusWant, w.Code)
+			assert.Equal(t, tt.locationWant, w.Header().Get("Location"))
+			if tt.wantForm {
+				assert.Contains(t, w.Body.String(), "<form")
+			} else {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
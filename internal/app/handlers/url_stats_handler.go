@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// URLStatsHandler is a handler struct. Use it`s ServeHTTP func.
+type URLStatsHandler struct {
+	URLStorage logic.URLStorageInterface
+	Log        zap.SugaredLogger
+}
+
+// ServeHTTP returns a clicks statistics of one short URL in JSON. Only for an owner of a URL.
+// Other users get http.StatusNotFound (the same as for unknown URLs).
+func (h *URLStatsHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	shortURL := chi.URLParam(req, "short")
+
+	userID, ok := logic.UserIDFromContext(req.Context())
+	if !ok {
+		res.WriteHeader(http.StatusUnauthorized)
+		h.Log.Error("UserID is nil")
+		return
+	}
+
+	stats, err := logic.GetURLStats(req.Context(), h.URLStorage, shortURL, userID)
+	if errors.Is(err, logic.ErrNotAnOwner()) {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusNotFound)
+		h.Log.Debugf("error while getting url stats: %v", err)
+		return
+	}
+
+	jsonResp, err := json.Marshal(stats)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		h.Log.Error("Error while marshalling url stats", zap.Error(err))
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	res.Write(jsonResp)
+}
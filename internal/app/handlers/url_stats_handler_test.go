@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// syncClickRecorder is a logic.ClickRecorder witch saves every click to a storage at once, so stats can be checked right after a redirect.
+type syncClickRecorder struct {
+	t       *testing.T
+	storage logic.URLStorageInterface
+}
+
+func (s *syncClickRecorder) RecordClick(ctx context.Context, click entities.Click) {
+	require.NoError(s.t, s.storage.SaveClicks(ctx, []entities.Click{click}), "error while saving a click")
+}
+
+func TestURLStatsHandler_ServeHTTP(t *testing.T) {
+	//prepare storage
+	ownerID := 1
+	URLStore := databases.NewJustAMap()
+	err := URLStore.SaveWithUserID(context.Background(), ownerID, entities.URL{
+		ShortURL:    "tracked",
+		OriginalURL: "https://practicum.yandex.ru/",
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare router
+	recorder := &syncClickRecorder{t: t, storage: URLStore}
+	redirectHandler := ShortURLRedirectHandler{
+		Redirector: logic.NewRedirector(URLStore, recorder, config.Config{IPHashSalt: "salt"}),
+		Log:        *sugar,
+	}
+	statsHandler := URLStatsHandler{
+		URLStorage: URLStore,
+		Log:        *sugar,
+	}
+	r := chi.NewRouter()
+	r.Get("/{url}", redirectHandler.ServeHTTP)
+	r.Get("/api/user/urls/{short}/stats", statsHandler.ServeHTTP)
+
+	//make redirects
+	visits := []struct {
+		referrer string
+		ip       string
+	}{
+		{referrer: "https://ya.ru/search?text=secret", ip: "10.0.0.1"},
+		{referrer: "https://ya.ru/", ip: "10.0.0.1"},
+		{referrer: "", ip: "10.0.0.2"},
+	}
+	for _, visit := range visits {
+		req := httptest.NewRequest(http.MethodGet, "/tracked", nil)
+		req.Header.Set("Referer", visit.referrer)
+		req.RemoteAddr = visit.ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	}
+
+	tests := []struct {
+		name       string
+		userID     interface{}
+		statusWant int
+	}{
+		{
+			name:       "owner",
+			userID:     ownerID,
+			statusWant: http.StatusOK,
+		},
+		{
+			name:       "not an owner",
+			userID:     2,
+			statusWant: http.StatusNotFound,
+		},
+		{
+			name:       "no user",
+			userID:     nil,
+			statusWant: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls/tracked/stats", nil)
+			if tt.userID != nil {
+				req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDContextKey, tt.userID))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.statusWant, w.Code)
+			if tt.statusWant != http.StatusOK {
+				return
+			}
+
+			stats := entities.URLStats{}
+			err := json.Unmarshal(w.Body.Bytes(), &stats)
+			require.NoError(t, err, "error while unmarshalling a response")
+			assert.Equal(t, 3, stats.TotalClicks)
+			assert.Equal(t, 2, stats.UniqueVisitors)
+			require.Len(t, stats.ClicksPerDay, 1)
+			assert.Equal(t, time.Now().UTC().Format(entities.DayFormat), stats.ClicksPerDay[0].Day)
+			require.Len(t, stats.TopReferrers, 2)
+			assert.Equal(t, entities.ReferrerClicks{Referrer: "ya.ru", Clicks: 2}, stats.TopReferrers[0])
+			assert.Equal(t, entities.ReferrerClicks{Referrer: entities.DirectReferrer, Clicks: 1}, stats.TopReferrers[1])
+		})
+	}
+}
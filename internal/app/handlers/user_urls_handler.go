@@ -2,64 +2,146 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
-	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/Lesnoi3283/url_shortener/config"
-	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
 	"go.uber.org/zap"
 )
 
+// NextCursorHeader is a header with a cursor of the next page of user`s URLs.
+const NextCursorHeader = "X-Next-Cursor"
+
 // UserURLsHandler is a handler struct. Use it`s ServeHTTP func.
 type UserURLsHandler struct {
 	URLStorage logic.URLStorageInterface
 	Conf       config.Config
 	Logger     zap.SugaredLogger
-	JWTHelper  *secure.JWTHelper
 }
 
-// ServeHTTP returns a JSON array with all users`s urls.
+// ServeHTTP returns a JSON array with users`s urls. The array is streamed page by page.
+// Optional query params:
+// "limit" returns only one page of URLs (a cursor of the next page is sent in NextCursorHeader),
+// "cursor" is a cursor of a page, "sort" is "created" (default) or "short", "order" is "asc" (default) or "desc",
+// "include_deleted" adds deleted URLs, "contains" filters URLs by a substring of an original URL.
+// All URLs are returned if there is no "limit" param.
 func (h *UserURLsHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 
-	cookie, err := req.Cookie(middlewares.JwtCookieName)
-	if err != nil {
-		h.Logger.Error("UserURLsHandler cookie get err", zap.Error(err))
+	//a user MUST be authorised BEFORE this request, a new user (without a token) gets 401
+	userID, ok := logic.UserIDFromContext(req.Context())
+	if !ok || logic.IsNewUser(req.Context()) {
+		h.Logger.Debug("UserURLsHandler got a request without a valid token")
 		res.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
-	//CTX is not needed! Because user MUST be authorised BEFORE this request! GitHub tests sayed me that...
-	token := cookie.Value
-	userID, err := h.JWTHelper.GetUserID(token)
-	if userID == -1 || err != nil {
-		h.Logger.Errorf("UserURLsHandler just got user id `-1` somehow. Probably JWT is not valid. Err: %v", err)
-		res.WriteHeader(http.StatusUnauthorized)
+	//read listing params
+	query, onePage, err := urlsPageQueryFromValues(req.URL.Query())
+	if err != nil {
+		h.Logger.Debugf("UserURLsHandler bad params: %v", err)
+		res.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	cursor := req.URL.Query().Get("cursor")
 
-	//get URLs
-	usersURLs, err := logic.GetUsersURLs(req.Context(), h.URLStorage, h.Conf.BaseAddress, userID)
-	if err != nil {
+	//get the first page
+	page, err := logic.GetUsersURLsPage(req.Context(), h.URLStorage, h.Conf.BaseAddress, userID, cursor, query)
+	if errors.Is(err, logic.ErrBadURLParams()) {
+		h.Logger.Debugf("UserURLsHandler bad params: %v", err)
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil {
 		h.Logger.Error("UserURLsHandler get err", zap.Error(err))
 		res.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if len(usersURLs) == 0 {
+	if len(page.URLs) == 0 {
 		res.WriteHeader(http.StatusNoContent)
 		h.Logger.Debugf("users urls is empty for user with ID = `%v`", userID)
 		return
 	}
 
-	//make a response
-	JSONResp, err := json.Marshal(usersURLs)
-	if err != nil {
-		h.Logger.Error("UserURLsHandler error while marshalling JSON", zap.Error(err))
-		res.WriteHeader(http.StatusInternalServerError)
-		return
-	}
+	streamURLsPages(res, h.Logger, page, onePage, func(cursor string) (logic.UserURLsPage, error) {
+		return logic.GetUsersURLsPage(req.Context(), h.URLStorage, h.Conf.BaseAddress, userID, cursor, query)
+	})
+}
 
+// streamURLsPages writes not empty pages of URLs as one JSON array.
+// If onePage is false getPage is called for every next page.
+// A cursor of the next page is sent in NextCursorHeader if onePage is true.
+// Status is already sent when the next page fails, so the connection is aborted in this case
+// (a closed array would look like a full list of URLs).
+func streamURLsPages(res http.ResponseWriter, logger zap.SugaredLogger, page logic.UserURLsPage, onePage bool, getPage func(cursor string) (logic.UserURLsPage, error)) {
 	res.Header().Set("Content-Type", "application/json")
+	if onePage && page.NextCursor != "" {
+		res.Header().Set(NextCursorHeader, page.NextCursor)
+	}
 	res.WriteHeader(http.StatusOK)
-	res.Write(JSONResp)
+	res.Write([]byte("["))
+	first := true
+	for {
+		for _, u := range page.URLs {
+			JSONURL, err := json.Marshal(u)
+			if err != nil {
+				logger.Error("error while marshalling URL to JSON", zap.Error(err))
+				panic(http.ErrAbortHandler)
+			}
+			if !first {
+				res.Write([]byte(","))
+			}
+			first = false
+			res.Write(JSONURL)
+		}
+		if flusher, ok := res.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if onePage || page.NextCursor == "" {
+			break
+		}
+		var err error
+		page, err = getPage(page.NextCursor)
+		if err != nil {
+			logger.Error("error while getting a page of URLs", zap.Error(err))
+			panic(http.ErrAbortHandler)
+		}
+	}
+	res.Write([]byte("]"))
+}
+
+// urlsPageQueryFromValues reads params of user`s URLs listing from query values.
+// onePage is true if a "limit" param was sent.
+// Without it pages of logic.MaxURLsPageLimit URLs are used.
+func urlsPageQueryFromValues(values url.Values) (query entities.URLsPageQuery, onePage bool, err error) {
+	query.Limit = logic.MaxURLsPageLimit
+	if limit := values.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit <= 0 {
+			return entities.URLsPageQuery{}, false, fmt.Errorf("bad limit `%s`", limit)
+		}
+		onePage = true
+	}
+
+	query.SortBy = values.Get("sort")
+	switch order := values.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return entities.URLsPageQuery{}, false, fmt.Errorf("bad order `%s`", order)
+	}
+
+	if includeDeleted := values.Get("include_deleted"); includeDeleted != "" {
+		query.IncludeDeleted, err = strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return entities.URLsPageQuery{}, false, fmt.Errorf("bad include_deleted `%s`", includeDeleted)
+		}
+	}
+	query.OriginalContains = values.Get("contains")
+	return query, onePage, nil
 }
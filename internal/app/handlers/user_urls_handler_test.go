@@ -1,18 +1,21 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/internal/app/logic/mocks"
-	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Lesnoi3283/url_shortener/config"
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
-	"github.com/Lesnoi3283/url_shortener/internal/app/middlewares"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,18 +45,10 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 		BaseAddress: "http://baseAddress",
 	}
 
-	jh := secure.NewJWTHelper("testSecretKey", 5)
-
-	correctJWTToken, err := jh.BuildNewJWTString(correctUserID)
-	require.NoErrorf(t, err, "error while building JWT in test: %v", err)
-	buildARequestWithJWT := func(token string) *http.Request {
-		//build a request and add a cookie to it
+	buildARequestWithUserID := func(userID int) *http.Request {
+		//build a request of an authorised user (AuthMW puts a user ID to a context)
 		req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
-		req.AddCookie(&http.Cookie{
-			Name:  middlewares.JwtCookieName,
-			Value: token,
-		})
-		return req
+		return req.WithContext(logic.ContextWithUserID(req.Context(), userID))
 	}
 
 	//prepare mocks
@@ -86,7 +81,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 				URLStorage: func() logic.URLStorageInterface {
 					//mock storage
 					storage := mocks.NewMockURLStorageInterface(c)
-					storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID).Return(correctURLs, nil)
+					storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID, gomock.Any()).Return(correctURLs, nil)
 					return storage
 				}(),
 				Conf:       conf,
@@ -109,7 +104,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			},
 			args: args{
 				res: httptest.NewRecorder(),
-				req: buildARequestWithJWT(correctJWTToken),
+				req: buildARequestWithUserID(correctUserID),
 			},
 		},
 		{
@@ -127,7 +122,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			},
 		},
 		{
-			name: "Not valid JWT",
+			name: "New user (no valid JWT)",
 			fields: fields{
 				URLStorage: nil,
 				Conf:       conf,
@@ -137,7 +132,10 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			},
 			args: args{
 				res: httptest.NewRecorder(),
-				req: buildARequestWithJWT("not a correct JWT token"),
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+					return req.WithContext(logic.ContextWithNewUser(req.Context(), correctUserID))
+				}(),
 			},
 		},
 		{
@@ -145,7 +143,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			fields: fields{
 				URLStorage: func() logic.URLStorageInterface {
 					storage := mocks.NewMockURLStorageInterface(c)
-					storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID).Return(make([]entities.URL, 0), nil)
+					storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID, gomock.Any()).Return(make([]entities.URL, 0), nil)
 					return storage
 				}(),
 				Conf:       conf,
@@ -155,7 +153,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			},
 			args: args{
 				res: httptest.NewRecorder(),
-				req: buildARequestWithJWT(correctJWTToken),
+				req: buildARequestWithUserID(correctUserID),
 			},
 		},
 		{
@@ -163,7 +161,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			fields: fields{
 				URLStorage: func() logic.URLStorageInterface {
 					storage := mocks.NewMockURLStorageInterface(c)
-					storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID).Return(make([]entities.URL, 0), errors.New("db error"))
+					storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID, gomock.Any()).Return(make([]entities.URL, 0), errors.New("db error"))
 					return storage
 				}(),
 				Conf:       conf,
@@ -173,7 +171,7 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 			},
 			args: args{
 				res: httptest.NewRecorder(),
-				req: buildARequestWithJWT(correctJWTToken),
+				req: buildARequestWithUserID(correctUserID),
 			},
 		},
 	}
@@ -183,7 +181,6 @@ func TestUserURLsHandler_ServeHTTP(t *testing.T) {
 				URLStorage: tt.fields.URLStorage,
 				Conf:       tt.fields.Conf,
 				Logger:     tt.fields.Logger,
-				JWTHelper:  jh,
 			}
 			h.ServeHTTP(tt.args.res, tt.args.req)
 			assert.Equal(t, tt.fields.StatusWant, tt.args.res.Code)
@@ -209,25 +206,17 @@ func BenchmarkUserURLsHandler_ServeHTTP(b *testing.B) {
 		BaseAddress: "http://baseAddress",
 	}
 
-	jh := secure.NewJWTHelper("testSecretKey", 5)
-
-	correctJWTToken, err := jh.BuildNewJWTString(correctUserID)
-	require.NoErrorf(b, err, "error while building JWT in test: %v", err)
-	buildARequestWithJWT := func(token string) *http.Request {
-		//build a request and add a cookie to it
+	buildARequestWithUserID := func(userID int) *http.Request {
+		//build a request of an authorised user (AuthMW puts a user ID to a context)
 		req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
-		req.AddCookie(&http.Cookie{
-			Name:  middlewares.JwtCookieName,
-			Value: token,
-		})
-		return req
+		return req.WithContext(logic.ContextWithUserID(req.Context(), userID))
 	}
 
 	//prepare mocks
 	c := gomock.NewController(b)
 	defer c.Finish()
 	storage := mocks.NewMockURLStorageInterface(c)
-	storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID).Return(correctURLs, nil).AnyTimes()
+	storage.EXPECT().GetUserUrls(gomock.Any(), correctUserID, gomock.Any()).Return(correctURLs, nil).AnyTimes()
 
 	//prepare logger
 	logger := zaptest.NewLogger(b)
@@ -243,10 +232,158 @@ func BenchmarkUserURLsHandler_ServeHTTP(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		//build a request
 		b.StopTimer()
-		req := buildARequestWithJWT(correctJWTToken)
+		req := buildARequestWithUserID(correctUserID)
 		b.StartTimer()
 
 		//test
 		handler.ServeHTTP(httptest.NewRecorder(), req)
 	}
 }
+
+func TestUserURLsHandler_Pagination(t *testing.T) {
+	//prepare storage
+	userID := 1
+	URLStore := databases.NewJustAMap()
+	for _, short := range []string{"c", "a", "d", "b"} {
+		err := URLStore.SaveWithUserID(context.Background(), userID, entities.URL{
+			ShortURL:    short,
+			OriginalURL: "http://" + short + ".example.com",
+		})
+		require.NoError(t, err, "error while preparing a storage")
+	}
+	err := URLStore.SaveWithUserID(context.Background(), userID, entities.URL{
+		ShortURL:    "deleted",
+		OriginalURL: "http://deleted.example.com",
+		IsDeleted:   true,
+	})
+	require.NoError(t, err, "error while preparing a storage")
+
+	//prepare handler
+	conf := config.Config{BaseAddress: "http://baseAddress"}
+	h := &UserURLsHandler{
+		URLStorage: URLStore,
+		Conf:       conf,
+		Logger:     *zaptest.NewLogger(t).Sugar(),
+	}
+	get := func(query string) (*httptest.ResponseRecorder, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/user/urls?"+query, nil)
+		req = req.WithContext(logic.ContextWithUserID(req.Context(), userID))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		shorts := make([]string, 0)
+		if w.Code != http.StatusOK {
+			return w, shorts
+		}
+		URLs := make([]testURLData, 0)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &URLs), "response is not a JSON array")
+		for _, u := range URLs {
+			shorts = append(shorts, strings.TrimPrefix(u.ShortURL, conf.BaseAddress+"/"))
+		}
+		return w, shorts
+	}
+
+	//all URLs in a creation order
+	w, shorts := get("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"c", "a", "d", "b"}, shorts)
+
+	//pages sorted by short URL
+	w, shorts = get("limit=3&sort=short")
+	assert.Equal(t, []string{"a", "b", "c"}, shorts)
+	cursor := w.Header().Get(NextCursorHeader)
+	require.NotEmpty(t, cursor)
+	w, shorts = get("limit=3&sort=short&cursor=" + cursor)
+	assert.Equal(t, []string{"d"}, shorts)
+	assert.Empty(t, w.Header().Get(NextCursorHeader))
+
+	//descending order, filters
+	_, shorts = get("order=desc&include_deleted=true")
+	assert.Equal(t, []string{"deleted", "b", "d", "a", "c"}, shorts)
+	_, shorts = get("contains=d.example")
+	assert.Equal(t, []string{"d"}, shorts)
+
+	//bad params
+	w, _ = get("sort=unknown")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = get("cursor=!!!")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserURLsHandler_Timestamps(t *testing.T) {
+	//prepare storage
+	userID := 1
+	URLStore := databases.NewJustAMap()
+	for _, short := range []string{"edited", "deleted"} {
+		err := URLStore.SaveWithUserID(context.Background(), userID, entities.URL{
+			ShortURL:    short,
+			OriginalURL: "http://" + short + ".example.com",
+		})
+		require.NoError(t, err, "error while preparing a storage")
+	}
+	newURL := "http://new.example.com"
+	err := URLStore.UpdateURL(context.Background(), userID, "edited", entities.URLUpdate{OriginalURL: &newURL})
+	require.NoError(t, err, "error while editing a URL")
+	err = logic.DeleteURLs(userID, []string{"deleted"}, URLStore, *zaptest.NewLogger(t).Sugar())
+	require.NoError(t, err, "error while deleting a URL")
+
+	//prepare handler
+	conf := config.Config{BaseAddress: "http://baseAddress"}
+	h := &UserURLsHandler{
+		URLStorage: URLStore,
+		Conf:       conf,
+		Logger:     *zaptest.NewLogger(t).Sugar(),
+	}
+	getURLs := func() map[string]entities.URL {
+		req := httptest.NewRequest(http.MethodGet, "/api/user/urls?include_deleted=true", nil)
+		req = req.WithContext(logic.ContextWithUserID(req.Context(), userID))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		URLs := make([]entities.URL, 0)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &URLs), "response is not a JSON array")
+		byShort := make(map[string]entities.URL)
+		for _, u := range URLs {
+			byShort[strings.TrimPrefix(u.ShortURL, conf.BaseAddress+"/")] = u
+		}
+		return byShort
+	}
+
+	//deletion is asynchronous
+	require.Eventually(t, func() bool {
+		return getURLs()["deleted"].DeletedAt != nil
+	}, time.Second, 10*time.Millisecond)
+
+	URLs := getURLs()
+	edited := URLs["edited"]
+	require.NotNil(t, edited.CreatedAt)
+	require.NotNil(t, edited.UpdatedAt)
+	assert.True(t, edited.UpdatedAt.After(*edited.CreatedAt))
+	assert.Nil(t, edited.DeletedAt)
+
+	deleted := URLs["deleted"]
+	require.NotNil(t, deleted.CreatedAt)
+	assert.True(t, deleted.IsDeleted)
+	assert.False(t, deleted.DeletedAt.Before(*deleted.CreatedAt))
+}
+
+func TestStreamURLsPages_PageError(t *testing.T) {
+	logger := *zaptest.NewLogger(t).Sugar()
+	firstPage := logic.UserURLsPage{
+		URLs:       []entities.URL{{ShortURL: "http://localhost/a", OriginalURL: "http://a.example.com"}},
+		NextCursor: "next",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		streamURLsPages(res, logger, firstPage, false, func(cursor string) (logic.UserURLsPage, error) {
+			return logic.UserURLsPage{}, errors.New("storage is not available")
+		})
+	}))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	//a broken stream can`t be read as a full list
+	_, err = io.ReadAll(resp.Body)
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Limits of account params. Passwords are limited by 72 bytes, because bcrypt ignores the rest.
+const (
+	MinLoginLength    = 3
+	MaxLoginLength    = 64
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// loginRegexp describes allowed logins (they are lowercased before checking).
+var loginRegexp = regexp.MustCompile(`^[a-z0-9._@-]+$`)
+
+// dummyPasswordHash is compared with a password if there is no such login,
+// so a response time doesn`t tell if a login exists.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// Accounts signs up and logs in registered users.
+// It keeps a state (failed login attempts), so one Accounts have to be shared by all handlers (HTTP and gRPC).
+// Use NewAccounts to build it.
+type Accounts struct {
+	Storage       URLStorageInterface
+	loginAttempts *AttemptsLimiter
+	ipAttempts    *AttemptsLimiter
+}
+
+// ipAttemptsFactor is how many times more failed attempts an IP can make (with any logins) than with one login.
+const ipAttemptsFactor = 4
+
+// AuthResult is a result of signing up or logging in.
+// ClaimedURLs is an amount of URLs moved from an anonymous user to an account.
+type AuthResult struct {
+	Account     entities.Account
+	ClaimedURLs int
+}
+
+// NewAccounts builds a new Accounts.
+// Every login can have only conf.PasswordAttempts failed attempts from one IP during conf.PasswordWindow,
+// and every IP can have only ipAttemptsFactor times more failed attempts with all logins.
+// Failures are not counted by a login only, otherwise anyone could block any account.
+func NewAccounts(storage URLStorageInterface, conf config.Config) *Accounts {
+	return &Accounts{
+		Storage:       storage,
+		loginAttempts: NewAttemptsLimiter(conf.PasswordAttempts, conf.PasswordWindow),
+		ipAttempts:    NewAttemptsLimiter(conf.PasswordAttempts*ipAttemptsFactor, conf.PasswordWindow),
+	}
+}
+
+// SignUp creates a new account with a new user ID and claims URLs of an anonymous user
+// (use NoUserID if there is no anonymous user).
+// Can return a wrapped ErrBadAccountParams and a wrapped databases.ErrLoginTaken.
+func (a *Accounts) SignUp(ctx context.Context, login string, password string, anonymousUserID int) (AuthResult, error) {
+	login = normalizeLogin(login)
+	err := checkAccountParams(login, password)
+	if err != nil {
+		return AuthResult{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("error while hashing a password: %w", err)
+	}
+	userID, err := a.Storage.CreateUser(ctx)
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("error while creating a user: %w", err)
+	}
+	account := entities.Account{
+		UserID:       userID,
+		Login:        login,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	err = a.Storage.CreateAccount(ctx, account)
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("error while saving an account: %w", err)
+	}
+
+	return a.claim(ctx, account, anonymousUserID)
+}
+
+// LogIn checks a login and a password and claims URLs of an anonymous user (use NoUserID if there is no anonymous user).
+// ip is an IP of a client, failed attempts are limited by it (see NewAccounts).
+// Can return ErrWrongCredentials and ErrTooManyAttempts.
+func (a *Accounts) LogIn(ctx context.Context, login string, password string, anonymousUserID int, ip string) (AuthResult, error) {
+	login = normalizeLogin(login)
+	attemptsKey := ip + " " + login
+	if a.ipAttempts.IsBlocked(ip) || a.loginAttempts.IsBlocked(attemptsKey) {
+		return AuthResult{}, ErrTooManyAttempts()
+	}
+
+	account, err := a.Storage.GetAccount(ctx, login)
+	if errors.Is(err, databases.ErrAccountNotFound()) {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		a.addFailure(ip, attemptsKey)
+		return AuthResult{}, ErrWrongCredentials()
+	} else if err != nil {
+		return AuthResult{}, fmt.Errorf("error while getting an account: %w", err)
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password))
+	if err != nil {
+		a.addFailure(ip, attemptsKey)
+		return AuthResult{}, ErrWrongCredentials()
+	}
+	a.loginAttempts.Reset(attemptsKey)
+
+	return a.claim(ctx, account, anonymousUserID)
+}
+
+// addFailure registers a failed attempt of an IP with a login (attemptsKey).
+func (a *Accounts) addFailure(ip string, attemptsKey string) {
+	a.loginAttempts.AddFailure(attemptsKey)
+	a.ipAttempts.AddFailure(ip)
+}
+
+// claim moves URLs of an anonymous user to an account.
+func (a *Accounts) claim(ctx context.Context, account entities.Account, anonymousUserID int) (AuthResult, error) {
+	result := AuthResult{Account: account}
+	if anonymousUserID == NoUserID || anonymousUserID == account.UserID {
+		return result, nil
+	}
+	claimed, err := a.Storage.ClaimURLs(ctx, anonymousUserID, account.UserID)
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("error while claiming URLs of an anonymous user: %w", err)
+	}
+	result.ClaimedURLs = claimed
+	return result, nil
+}
+
+// normalizeLogin makes logins case-insensitive.
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+// checkAccountParams returns a wrapped ErrBadAccountParams if a login or a password is not correct.
+func checkAccountParams(login string, password string) error {
+	if len(login) < MinLoginLength || len(login) > MaxLoginLength {
+		return fmt.Errorf("%w: login have to be from %d to %d symbols", ErrBadAccountParams(), MinLoginLength, MaxLoginLength)
+	}
+	if !loginRegexp.MatchString(login) {
+		return fmt.Errorf("%w: login can contain only latin letters, digits and `.`, `_`, `@`, `-` symbols", ErrBadAccountParams())
+	}
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: password have to be from %d to %d bytes", ErrBadAccountParams(), MinPasswordLength, MaxPasswordLength)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounts_SignUp(t *testing.T) {
+	ctx := context.Background()
+	storage := databases.NewJustAMap()
+	accounts := NewAccounts(storage, config.Config{PasswordAttempts: 5, PasswordWindow: time.Minute})
+
+	//anonymous user`s URLs
+	anonymousUserID := 7
+	err := storage.SaveWithUserID(ctx, anonymousUserID, entities.URL{ShortURL: "abc", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+
+	result, err := accounts.SignUp(ctx, " User@Example.com ", "secret password", anonymousUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", result.Account.Login)
+	assert.NotEqual(t, "secret password", result.Account.PasswordHash)
+	assert.Equal(t, 1, result.ClaimedURLs)
+	URL, err := storage.GetURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, result.Account.UserID, URL.UserID)
+
+	t.Run("login taken", func(t *testing.T) {
+		_, err := accounts.SignUp(ctx, "user@example.com", "other password", NoUserID)
+		assert.ErrorIs(t, err, databases.ErrLoginTaken())
+	})
+
+	t.Run("bad params", func(t *testing.T) {
+		for _, params := range []struct{ login, password string }{
+			{"ab", "secret password"},
+			{"bad login", "secret password"},
+			{"good.login", "short"},
+			{"good.login", string(make([]byte, MaxPasswordLength+1))},
+		} {
+			_, err := accounts.SignUp(ctx, params.login, params.password, NoUserID)
+			assert.ErrorIs(t, err, ErrBadAccountParams(), "login `%s`", params.login)
+		}
+	})
+}
+
+func TestAccounts_LogIn(t *testing.T) {
+	ctx := context.Background()
+	storage := databases.NewJustAMap()
+	accounts := NewAccounts(storage, config.Config{PasswordAttempts: 2, PasswordWindow: time.Minute})
+	ip := "10.0.0.1"
+
+	signedUp, err := accounts.SignUp(ctx, "user", "secret password", NoUserID)
+	require.NoError(t, err)
+
+	t.Run("claims anonymous URLs", func(t *testing.T) {
+		anonymousUserID := 7
+		err := storage.SaveWithUserID(ctx, anonymousUserID, entities.URL{ShortURL: "abc", OriginalURL: "https://example.com"})
+		require.NoError(t, err)
+
+		result, err := accounts.LogIn(ctx, "USER", "secret password", anonymousUserID, ip)
+		require.NoError(t, err)
+		assert.Equal(t, signedUp.Account.UserID, result.Account.UserID)
+		assert.Equal(t, 1, result.ClaimedURLs)
+	})
+
+	t.Run("URLs of other accounts are not claimed", func(t *testing.T) {
+		other, err := accounts.SignUp(ctx, "other", "secret password", NoUserID)
+		require.NoError(t, err)
+		err = storage.SaveWithUserID(ctx, other.Account.UserID, entities.URL{ShortURL: "def", OriginalURL: "https://example.com/other"})
+		require.NoError(t, err)
+
+		result, err := accounts.LogIn(ctx, "user", "secret password", other.Account.UserID, ip)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.ClaimedURLs)
+		URL, err := storage.GetURL(ctx, "def")
+		require.NoError(t, err)
+		assert.Equal(t, other.Account.UserID, URL.UserID)
+	})
+
+	t.Run("unknown login", func(t *testing.T) {
+		_, err := accounts.LogIn(ctx, "nobody", "secret password", NoUserID, ip)
+		assert.ErrorIs(t, err, ErrWrongCredentials())
+	})
+
+	t.Run("too many attempts", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := accounts.LogIn(ctx, "user", "wrong password", NoUserID, ip)
+			assert.ErrorIs(t, err, ErrWrongCredentials())
+		}
+		_, err := accounts.LogIn(ctx, "user", "secret password", NoUserID, ip)
+		assert.ErrorIs(t, err, ErrTooManyAttempts())
+
+		//failures from other IPs don`t block an account
+		_, err = accounts.LogIn(ctx, "user", "secret password", NoUserID, "10.0.0.2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("too many attempts from an IP", func(t *testing.T) {
+		attackerIP := "10.0.0.3"
+		for i := 0; i < 2*ipAttemptsFactor; i++ {
+			_, err := accounts.LogIn(ctx, fmt.Sprintf("user%d", i), "wrong password", NoUserID, attackerIP)
+			assert.ErrorIs(t, err, ErrWrongCredentials())
+		}
+		_, err := accounts.LogIn(ctx, "other", "secret password", NoUserID, attackerIP)
+		assert.ErrorIs(t, err, ErrTooManyAttempts())
+		_, err = accounts.LogIn(ctx, "other", "secret password", NoUserID, ip)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,197 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+)
+
+// API key scopes. ScopeShorten allows to create and to change URLs, ScopeRead allows to read user`s URLs
+// and their statistics, ScopeDelete allows to delete URLs. A key without scopes has all of them.
+const (
+	ScopeShorten = "shorten"
+	ScopeRead    = "read"
+	ScopeDelete  = "delete"
+)
+
+// scopes are all known API key scopes.
+var scopes = map[string]struct{}{
+	ScopeShorten: {},
+	ScopeRead:    {},
+	ScopeDelete:  {},
+}
+
+// API key params. Keys are APIKeyPrefix + 32 random bytes (base64 encoded), so they can be told from JWTs.
+// LastUsedAt of a key is updated not more often than once per APIKeyTouchInterval.
+const (
+	APIKeyPrefix        = "usk_"
+	MaxAPIKeyNameLength = 100
+	MaxAPIKeysPerUser   = 20
+	APIKeyTouchInterval = time.Minute
+	apiKeySecretLength  = 32
+	apiKeyIDLength      = 8
+)
+
+// APIKeys creates, lists, revokes and checks personal API keys of users.
+// Use NewAPIKeys to build it.
+type APIKeys struct {
+	Storage URLStorageInterface
+}
+
+// NewAPIKeys builds a new APIKeys.
+func NewAPIKeys(storage URLStorageInterface) *APIKeys {
+	return &APIKeys{Storage: storage}
+}
+
+// IsAPIKey returns true if a token looks like an API key (not like a JWT).
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// Create creates a new API key of a user. Returns a key itself (it is not saved anywhere, so it can be shown only once)
+// and its description without a hash. Nil expiresAt means that a key never expires.
+// Can return a wrapped ErrBadAPIKeyParams.
+func (a *APIKeys) Create(ctx context.Context, userID int, name string, keyScopes []string, expiresAt *time.Time) (string, entities.APIKey, error) {
+	//validation
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > MaxAPIKeyNameLength {
+		return "", entities.APIKey{}, fmt.Errorf("%w: name can`t be longer than %d symbols", ErrBadAPIKeyParams(), MaxAPIKeyNameLength)
+	}
+	keyScopes, err := prepareScopes(keyScopes)
+	if err != nil {
+		return "", entities.APIKey{}, err
+	}
+	now := time.Now().UTC()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", entities.APIKey{}, fmt.Errorf("%w: expiration time has to be in the future", ErrBadAPIKeyParams())
+	}
+	existing, err := a.Storage.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		return "", entities.APIKey{}, fmt.Errorf("error while getting user`s API keys: %w", err)
+	}
+	if len(existing) >= MaxAPIKeysPerUser {
+		return "", entities.APIKey{}, fmt.Errorf("%w: user can have only %d API keys", ErrBadAPIKeyParams(), MaxAPIKeysPerUser)
+	}
+
+	//key generating
+	id, err := randomString(apiKeyIDLength, hex.EncodeToString)
+	if err != nil {
+		return "", entities.APIKey{}, err
+	}
+	secret, err := randomString(apiKeySecretLength, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", entities.APIKey{}, err
+	}
+	key := APIKeyPrefix + secret
+	if expiresAt != nil {
+		expires := expiresAt.UTC()
+		expiresAt = &expires
+	}
+	apiKey := entities.APIKey{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		KeyHash:   hashToken(key),
+		Scopes:    keyScopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	err = a.Storage.SaveAPIKey(ctx, apiKey)
+	if err != nil {
+		return "", entities.APIKey{}, fmt.Errorf("error while saving an API key: %w", err)
+	}
+
+	apiKey.KeyHash = ""
+	return key, apiKey, nil
+}
+
+// List returns all API keys of a user (with expired ones) without hashes.
+func (a *APIKeys) List(ctx context.Context, userID int) ([]entities.APIKey, error) {
+	keys, err := a.Storage.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting user`s API keys: %w", err)
+	}
+	for i := range keys {
+		keys[i].KeyHash = ""
+	}
+	return keys, nil
+}
+
+// Revoke deletes an API key of a user. Returns a wrapped databases.ErrAPIKeyNotFound if user has no such key.
+func (a *APIKeys) Revoke(ctx context.Context, userID int, id string) error {
+	err := a.Storage.DeleteAPIKey(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("error while deleting an API key: %w", err)
+	}
+	return nil
+}
+
+// Check returns an API key description by a key and remembers when it was used.
+// Returns ErrInvalidAPIKey if there is no such key or it has expired.
+func (a *APIKeys) Check(ctx context.Context, key string) (entities.APIKey, error) {
+	apiKey, err := a.Storage.GetAPIKey(ctx, hashToken(key))
+	if errors.Is(err, databases.ErrAPIKeyNotFound()) {
+		return entities.APIKey{}, ErrInvalidAPIKey()
+	} else if err != nil {
+		return entities.APIKey{}, fmt.Errorf("error while getting an API key: %w", err)
+	}
+	now := time.Now().UTC()
+	if apiKey.IsExpiredAt(now) {
+		return entities.APIKey{}, ErrInvalidAPIKey()
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= APIKeyTouchInterval {
+		err = a.Storage.SetAPIKeyLastUsed(ctx, apiKey.ID, now)
+		if err != nil {
+			return entities.APIKey{}, fmt.Errorf("error while saving API key usage: %w", err)
+		}
+		apiKey.LastUsedAt = &now
+	}
+	apiKey.KeyHash = ""
+	return apiKey, nil
+}
+
+// prepareScopes removes duplicates of scopes and returns a wrapped ErrBadAPIKeyParams if there is an unknown scope.
+func prepareScopes(keyScopes []string) ([]string, error) {
+	prepared := make([]string, 0, len(keyScopes))
+	seen := make(map[string]struct{}, len(keyScopes))
+	for _, scope := range keyScopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if _, ok := scopes[scope]; !ok {
+			return nil, fmt.Errorf("%w: unknown scope `%s`", ErrBadAPIKeyParams(), scope)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		prepared = append(prepared, scope)
+	}
+	return prepared, nil
+}
+
+// hashToken returns a hex encoded SHA-256 hash of a token (an API key or a secret of a refresh token).
+// Tokens are long random strings, so a fast hash is enough (unlike passwords).
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// randomString returns `length` random bytes encoded by encode func.
+func randomString(length int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("error while generating random bytes: %w", err)
+	}
+	return encode(buf), nil
+}
@@ -0,0 +1,98 @@
+package logic
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	storage := databases.NewJustAMap()
+	keys := NewAPIKeys(storage)
+	userID := 7
+
+	key, apiKey, err := keys.Create(ctx, userID, " CI ", []string{ScopeShorten, "READ", ScopeShorten}, nil)
+	require.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.Equal(t, "CI", apiKey.Name)
+	assert.Equal(t, []string{ScopeShorten, ScopeRead}, apiKey.Scopes)
+	assert.Empty(t, apiKey.KeyHash, "hash must not be returned")
+	assert.Nil(t, apiKey.LastUsedAt)
+
+	t.Run("only a hash is saved", func(t *testing.T) {
+		for _, saved := range storage.APIKeyStore {
+			assert.NotContains(t, saved.KeyHash, strings.TrimPrefix(key, APIKeyPrefix))
+			assert.Equal(t, hashToken(key), saved.KeyHash)
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		checked, err := keys.Check(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, userID, checked.UserID)
+		require.NotNil(t, checked.LastUsedAt)
+
+		listed, err := keys.List(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.NotNil(t, listed[0].LastUsedAt, "last usage has to be saved")
+		assert.Empty(t, listed[0].KeyHash)
+
+		_, err = keys.Check(ctx, APIKeyPrefix+"unknown")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey())
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		expiresAt := time.Now().Add(50 * time.Millisecond)
+		expiringKey, _, err := keys.Create(ctx, userID, "short-lived", nil, &expiresAt)
+		require.NoError(t, err)
+		_, err = keys.Check(ctx, expiringKey)
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+		_, err = keys.Check(ctx, expiringKey)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey())
+	})
+
+	t.Run("bad params", func(t *testing.T) {
+		_, _, err := keys.Create(ctx, userID, "", []string{"admin"}, nil)
+		assert.ErrorIs(t, err, ErrBadAPIKeyParams())
+		_, _, err = keys.Create(ctx, userID, strings.Repeat("a", MaxAPIKeyNameLength+1), nil, nil)
+		assert.ErrorIs(t, err, ErrBadAPIKeyParams())
+		past := time.Now().Add(-time.Hour)
+		_, _, err = keys.Create(ctx, userID, "", nil, &past)
+		assert.ErrorIs(t, err, ErrBadAPIKeyParams())
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		err := keys.Revoke(ctx, userID+1, apiKey.ID)
+		assert.ErrorIs(t, err, databases.ErrAPIKeyNotFound(), "only an owner can revoke a key")
+
+		err = keys.Revoke(ctx, userID, apiKey.ID)
+		require.NoError(t, err)
+		_, err = keys.Check(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey())
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, HasScope(ContextWithUserID(ctx, 1), ScopeDelete), "JWT users have all scopes")
+
+	withAll := ContextWithAPIKey(ctx, entities.APIKey{UserID: 1})
+	assert.True(t, HasScope(withAll, ScopeDelete), "a key without scopes has all of them")
+
+	withRead := ContextWithAPIKey(ctx, entities.APIKey{UserID: 1, Scopes: []string{ScopeRead}})
+	assert.True(t, HasScope(withRead, ScopeRead))
+	assert.False(t, HasScope(withRead, ScopeShorten))
+	userID, ok := UserIDFromContext(withRead)
+	assert.True(t, ok)
+	assert.Equal(t, 1, userID)
+}
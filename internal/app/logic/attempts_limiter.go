@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"sync"
+	"time"
+)
+
+// AttemptsLimiter counts failed attempts by key (for example by short URL).
+// Key is blocked if `limit` failed attempts were made during last `window`. Thread-safe.
+// Keys are chosen by clients, so old failures of all keys are removed once per `window` (not only when a key is used again).
+// Use NewAttemptsLimiter to build it.
+type AttemptsLimiter struct {
+	mutex     sync.Mutex
+	limit     int
+	window    time.Duration
+	failures  map[string][]time.Time
+	lastSweep time.Time
+}
+
+// NewAttemptsLimiter returns a new AttemptsLimiter. Limit <= 0 disables limiting.
+func NewAttemptsLimiter(limit int, window time.Duration) *AttemptsLimiter {
+	return &AttemptsLimiter{
+		limit:    limit,
+		window:   window,
+		failures: make(map[string][]time.Time),
+	}
+}
+
+// IsBlocked returns true if key has reached the failed attempts limit.
+func (a *AttemptsLimiter) IsBlocked(key string) bool {
+	if a.limit <= 0 {
+		return false
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return len(a.actualFailures(key, time.Now())) >= a.limit
+}
+
+// AddFailure registers a failed attempt for a key.
+func (a *AttemptsLimiter) AddFailure(key string) {
+	if a.limit <= 0 {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := time.Now()
+	a.sweep(now)
+	failures := append(a.actualFailures(key, now), now)
+	//failures over a limit don`t change anything, so they are not kept
+	if len(failures) > a.limit {
+		failures = failures[len(failures)-a.limit:]
+	}
+	a.failures[key] = failures
+}
+
+// Reset removes all failed attempts of a key.
+func (a *AttemptsLimiter) Reset(key string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	delete(a.failures, key)
+}
+
+// sweep removes failures older than window of all keys if the last sweep was more than window ago.
+// Mutex have to be locked by a caller.
+func (a *AttemptsLimiter) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) < a.window {
+		return
+	}
+	for key := range a.failures {
+		a.actualFailures(key, now)
+	}
+	a.lastSweep = now
+}
+
+// actualFailures removes failures older than window and returns others. Mutex have to be locked by a caller.
+func (a *AttemptsLimiter) actualFailures(key string, now time.Time) []time.Time {
+	failures := a.failures[key]
+	firstActual := 0
+	for firstActual < len(failures) && now.Sub(failures[firstActual]) > a.window {
+		firstActual++
+	}
+	failures = failures[firstActual:]
+	if len(failures) == 0 {
+		delete(a.failures, key)
+		return nil
+	}
+	a.failures[key] = failures
+	return failures
+}
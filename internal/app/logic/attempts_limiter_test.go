@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptsLimiter(t *testing.T) {
+	limiter := NewAttemptsLimiter(2, time.Hour)
+
+	//first failures
+	assert.False(t, limiter.IsBlocked("key"))
+	limiter.AddFailure("key")
+	assert.False(t, limiter.IsBlocked("key"))
+	limiter.AddFailure("key")
+	assert.True(t, limiter.IsBlocked("key"))
+
+	//different keys are counted separately
+	assert.False(t, limiter.IsBlocked("other key"))
+
+	//reset
+	limiter.Reset("key")
+	assert.False(t, limiter.IsBlocked("key"))
+}
+
+func TestAttemptsLimiter_Window(t *testing.T) {
+	limiter := NewAttemptsLimiter(1, 10*time.Millisecond)
+
+	limiter.AddFailure("key")
+	assert.True(t, limiter.IsBlocked("key"))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, limiter.IsBlocked("key"))
+}
+
+func TestAttemptsLimiter_Disabled(t *testing.T) {
+	limiter := NewAttemptsLimiter(0, time.Hour)
+
+	limiter.AddFailure("key")
+	assert.False(t, limiter.IsBlocked("key"))
+}
+
+func TestAttemptsLimiter_Sweep(t *testing.T) {
+	limiter := NewAttemptsLimiter(2, 10*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		limiter.AddFailure(fmt.Sprintf("key %d", i))
+	}
+	for i := 0; i < 5; i++ {
+		limiter.AddFailure("key 0")
+	}
+	assert.Len(t, limiter.failures["key 0"], 2, "failures over a limit must not be kept")
+
+	//old keys are removed when other keys fail
+	time.Sleep(20 * time.Millisecond)
+	limiter.AddFailure("new key")
+	assert.Len(t, limiter.failures, 1)
+}
@@ -0,0 +1,148 @@
+package logic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"go.uber.org/zap"
+)
+
+// ClickPipelineStats contains counters of a ClickPipeline.
+type ClickPipelineStats struct {
+	Enqueued int64
+	Dropped  int64
+	Saved    int64
+	Failed   int64
+}
+
+// ClickPipeline is a ClickRecorder witch saves clicks asynchronously.
+// RecordClick never blocks: clicks are put into a bounded buffer and a worker saves them in batches
+// (when a batch is full or every flush interval). If the buffer is full, a click is dropped and counted.
+// Use NewClickPipeline to build it, Run to start a worker and Close to flush all buffered clicks.
+type ClickPipeline struct {
+	storage       URLStorageInterface
+	events        chan entities.Click
+	batchSize     int
+	flushInterval time.Duration
+	logger        zap.SugaredLogger
+
+	closeMutex sync.RWMutex
+	closed     bool
+	done       chan struct{}
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	saved    atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewClickPipeline builds a new ClickPipeline.
+// Values less than 1 are replaced with 1 (and with one second for flushInterval).
+func NewClickPipeline(storage URLStorageInterface, bufferSize int, batchSize int, flushInterval time.Duration, logger zap.SugaredLogger) *ClickPipeline {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	return &ClickPipeline{
+		storage:       storage,
+		events:        make(chan entities.Click, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		logger:        logger,
+		done:          make(chan struct{}),
+	}
+}
+
+// RecordClick puts a click into a buffer without blocking. Clicks are dropped if the buffer is full or pipeline is closed.
+func (p *ClickPipeline) RecordClick(ctx context.Context, click entities.Click) {
+	p.closeMutex.RLock()
+	defer p.closeMutex.RUnlock()
+
+	if p.closed {
+		p.dropped.Add(1)
+		return
+	}
+	select {
+	case p.events <- click:
+		p.enqueued.Add(1)
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// Run saves buffered clicks until the pipeline is closed.
+// This func have to be called in different goroutine, because it has an endless loop.
+func (p *ClickPipeline) Run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]entities.Click, 0, p.batchSize)
+	for {
+		select {
+		case click, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= p.batchSize {
+				batch = p.flush(batch)
+			}
+		case <-ticker.C:
+			batch = p.flush(batch)
+		}
+	}
+}
+
+// flush saves a batch and returns an empty batch to reuse.
+func (p *ClickPipeline) flush(batch []entities.Click) []entities.Click {
+	if len(batch) == 0 {
+		return batch
+	}
+	err := p.storage.SaveClicks(context.Background(), batch)
+	if err != nil {
+		p.failed.Add(int64(len(batch)))
+		p.logger.Errorf("error while saving a batch of %d clicks: %v", len(batch), err)
+	} else {
+		p.saved.Add(int64(len(batch)))
+	}
+	return batch[:0]
+}
+
+// Close stops accepting clicks and waits until all buffered clicks are saved (or ctx is done).
+// Run have to be started before Close.
+func (p *ClickPipeline) Close(ctx context.Context) error {
+	p.closeMutex.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.closeMutex.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns current counters of a pipeline.
+func (p *ClickPipeline) Stats() ClickPipelineStats {
+	return ClickPipelineStats{
+		Enqueued: p.enqueued.Load(),
+		Dropped:  p.dropped.Load(),
+		Saved:    p.saved.Load(),
+		Failed:   p.failed.Load(),
+	}
+}
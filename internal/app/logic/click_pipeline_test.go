@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestClickPipeline_Batches(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	mutex := sync.Mutex{}
+	batchSizes := make([]int, 0)
+	storage := mocks.NewMockURLStorageInterface(c)
+	storage.EXPECT().SaveClicks(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, clicks []entities.Click) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		batchSizes = append(batchSizes, len(clicks))
+		return nil
+	}).AnyTimes()
+
+	pipeline := NewClickPipeline(storage, 100, 3, time.Hour, *zaptest.NewLogger(t).Sugar())
+	go pipeline.Run()
+	for i := 0; i < 7; i++ {
+		pipeline.RecordClick(context.Background(), entities.Click{ShortURL: "short"})
+	}
+	err := pipeline.Close(context.Background())
+	require.NoError(t, err)
+
+	//two full batches and the rest on close
+	assert.Equal(t, []int{3, 3, 1}, batchSizes)
+	assert.Equal(t, ClickPipelineStats{Enqueued: 7, Saved: 7}, pipeline.Stats())
+
+	//closed pipeline drops clicks
+	pipeline.RecordClick(context.Background(), entities.Click{ShortURL: "short"})
+	assert.Equal(t, int64(1), pipeline.Stats().Dropped)
+}
+
+func TestClickPipeline_Interval(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	saved := make(chan int, 1)
+	storage := mocks.NewMockURLStorageInterface(c)
+	storage.EXPECT().SaveClicks(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, clicks []entities.Click) error {
+		saved <- len(clicks)
+		return nil
+	})
+
+	pipeline := NewClickPipeline(storage, 100, 100, 10*time.Millisecond, *zaptest.NewLogger(t).Sugar())
+	go pipeline.Run()
+	pipeline.RecordClick(context.Background(), entities.Click{ShortURL: "short"})
+
+	select {
+	case amount := <-saved:
+		assert.Equal(t, 1, amount)
+	case <-time.After(time.Second):
+		t.Fatal("clicks were not flushed by interval")
+	}
+	require.NoError(t, pipeline.Close(context.Background()))
+}
+
+func TestClickPipeline_Overflow(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	storage := mocks.NewMockURLStorageInterface(c)
+	storage.EXPECT().SaveClicks(gomock.Any(), gomock.Len(2)).Return(nil)
+
+	//worker is not running, so the buffer will be full after 2 clicks
+	pipeline := NewClickPipeline(storage, 2, 10, time.Hour, *zaptest.NewLogger(t).Sugar())
+	for i := 0; i < 5; i++ {
+		pipeline.RecordClick(context.Background(), entities.Click{ShortURL: "short"})
+	}
+	assert.Equal(t, ClickPipelineStats{Enqueued: 2, Dropped: 3}, pipeline.Stats())
+
+	go pipeline.Run()
+	require.NoError(t, pipeline.Close(context.Background()))
+	assert.Equal(t, int64(2), pipeline.Stats().Saved)
+}
@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// User agent classes.
+const (
+	UserAgentIOS     = "ios"
+	UserAgentAndroid = "android"
+	UserAgentDesktop = "desktop"
+	UserAgentBot     = "bot"
+	UserAgentOther   = "other"
+)
+
+// TopReferrersAmount is an amount of referrers returned in URL stats.
+const TopReferrersAmount = 10
+
+// Visit contains data about a redirect request.
+// Query is a query of a redirect request, it is added to an original URL if query passing is enabled.
+// UserAgent and AcceptLanguage are used by redirect rules.
+// Variant is a name of a variant assigned to a visitor before (it is used only by URLs with sticky variants).
+type Visit struct {
+	Password       string
+	Referrer       string
+	UserAgent      string
+	AcceptLanguage string
+	IP             string
+	Query          url.Values
+	Variant        string
+}
+
+// ClickRecorder saves clicks. It must not block a redirect for a long time.
+type ClickRecorder interface {
+	RecordClick(ctx context.Context, click entities.Click)
+}
+
+// ClassifyUserAgent returns a class of a User-Agent header value.
+func ClassifyUserAgent(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return UserAgentOther
+	case strings.Contains(ua, "bot") || strings.Contains(ua, "crawler") || strings.Contains(ua, "spider") ||
+		strings.Contains(ua, "curl") || strings.Contains(ua, "wget") || strings.Contains(ua, "python"):
+		return UserAgentBot
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod"):
+		return UserAgentIOS
+	case strings.Contains(ua, "android"):
+		return UserAgentAndroid
+	case strings.Contains(ua, "windows") || strings.Contains(ua, "macintosh") || strings.Contains(ua, "linux") ||
+		strings.Contains(ua, "x11") || strings.Contains(ua, "cros"):
+		return UserAgentDesktop
+	default:
+		return UserAgentOther
+	}
+}
+
+// referrerHost returns only a host of a referrer (full referrers can contain private data).
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// hashIP returns a salted hash of an IP address. Original IPs are never saved.
+func hashIP(ip string, salt string) string {
+	if ip == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(salt + ip))
+	return hex.EncodeToString(sum[:])
+}
+
+// newClick builds a click from a visit.
+func newClick(shortURL string, visit Visit, moment time.Time, ipSalt string, recordIPs bool) entities.Click {
+	click := entities.Click{
+		ShortURL:       shortURL,
+		Time:           moment.UTC(),
+		Referrer:       referrerHost(visit.Referrer),
+		UserAgentClass: ClassifyUserAgent(visit.UserAgent),
+	}
+	if recordIPs {
+		click.IPHash = hashIP(visit.IP, ipSalt)
+	}
+	return click
+}
+
+// GetURLStats returns a clicks statistics of a URL.
+// Only an owner of a URL can get it, ErrNotAnOwner will be returned for other users.
+func GetURLStats(ctx context.Context, storage URLStorageInterface, shortURL string, userID int) (entities.URLStats, error) {
+	URL, err := storage.GetURL(ctx, shortURL)
+	if err != nil {
+		return entities.URLStats{}, err
+	}
+	if URL.UserID == 0 || URL.UserID != userID {
+		return entities.URLStats{}, ErrNotAnOwner()
+	}
+	return storage.GetURLStats(ctx, shortURL, TopReferrersAmount)
+}
@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{
+			name:      "iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15",
+			want:      UserAgentIOS,
+		},
+		{
+			name:      "android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile",
+			want:      UserAgentAndroid,
+		},
+		{
+			name:      "desktop",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0",
+			want:      UserAgentDesktop,
+		},
+		{
+			name:      "bot",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want:      UserAgentBot,
+		},
+		{
+			name:      "empty",
+			userAgent: "",
+			want:      UserAgentOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyUserAgent(tt.userAgent))
+		})
+	}
+}
+
+func TestNewClick(t *testing.T) {
+	now := time.Now()
+	visit := Visit{
+		Referrer:  "https://Example.com/some/private/path?token=123",
+		UserAgent: "curl/8.0",
+		IP:        "10.0.0.1",
+	}
+
+	click := newClick("short", visit, now, "salt", true)
+	assert.Equal(t, "example.com", click.Referrer)
+	assert.Equal(t, UserAgentBot, click.UserAgentClass)
+	assert.NotEmpty(t, click.IPHash)
+	assert.NotContains(t, click.IPHash, visit.IP)
+	assert.Equal(t, click.IPHash, newClick("short", visit, now, "salt", true).IPHash)
+
+	click = newClick("short", visit, now, "salt", false)
+	assert.Empty(t, click.IPHash)
+}
+
+func TestGetURLStats(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	storage := mocks.NewMockURLStorageInterface(c)
+	storage.EXPECT().GetURL(gomock.Any(), "short").Return(entities.URL{ShortURL: "short", UserID: 1}, nil).AnyTimes()
+	storage.EXPECT().GetURLStats(gomock.Any(), "short", TopReferrersAmount).Return(entities.URLStats{ShortURL: "short", TotalClicks: 3}, nil)
+
+	stats, err := GetURLStats(context.Background(), storage, "short", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.TotalClicks)
+
+	_, err = GetURLStats(context.Background(), storage, "short", 2)
+	assert.ErrorIs(t, err, ErrNotAnOwner())
+}
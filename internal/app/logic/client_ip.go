@@ -0,0 +1,55 @@
+package logic
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TrustedProxies are networks of reverse proxies witch send an IP of a client in an "X-Real-IP" header (or metadata).
+// Other clients can send any "X-Real-IP", so it is ignored for them and an IP of a connection is used.
+// A nil TrustedProxies trusts nobody.
+type TrustedProxies []*net.IPNet
+
+// NewTrustedProxies parses comma separated CIDRs of trusted proxies (like "10.0.0.0/8,192.168.1.1/32").
+func NewTrustedProxies(list string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0)
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing trusted proxy %q: %w", value, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// ClientIP returns an IP of a client. remoteAddr is an address of a connection ("host:port" or "host"),
+// realIP is an "X-Real-IP" value witch is used only if a connection comes from a trusted proxy.
+func (t TrustedProxies) ClientIP(remoteAddr string, realIP string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	if realIP == "" || !t.contains(net.ParseIP(host)) {
+		return host
+	}
+	return strings.TrimSpace(realIP)
+}
+
+// contains returns true if an IP is an IP of a trusted proxy.
+func (t TrustedProxies) contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8, 192.168.1.1/32")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		proxies    TrustedProxies
+		remoteAddr string
+		realIP     string
+		want       string
+	}{
+		{
+			name:       "trusted proxy",
+			proxies:    proxies,
+			remoteAddr: "10.1.2.3:4567",
+			realIP:     "203.0.113.7",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "not trusted client",
+			proxies:    proxies,
+			remoteAddr: "203.0.113.8:4567",
+			realIP:     "203.0.113.7",
+			want:       "203.0.113.8",
+		},
+		{
+			name:       "trusted proxy without a header",
+			proxies:    proxies,
+			remoteAddr: "192.168.1.1:4567",
+			want:       "192.168.1.1",
+		},
+		{
+			name:       "no proxies",
+			remoteAddr: "10.1.2.3:4567",
+			realIP:     "203.0.113.7",
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "address without a port",
+			proxies:    proxies,
+			remoteAddr: "203.0.113.8",
+			want:       "203.0.113.8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.proxies.ClientIP(tt.remoteAddr, tt.realIP))
+		})
+	}
+}
+
+func TestNewTrustedProxies(t *testing.T) {
+	proxies, err := NewTrustedProxies("")
+	require.NoError(t, err)
+	assert.Empty(t, proxies)
+
+	_, err = NewTrustedProxies("10.0.0.0/8,not a CIDR")
+	assert.Error(t, err)
+}
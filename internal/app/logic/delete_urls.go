@@ -2,20 +2,26 @@ package logic
 
 import (
 	"fmt"
+
+	"go.uber.org/zap"
 )
 
 // DeleteURLs deletes URLs from database. It creates a new goroutine witch deletes URLs.
-func DeleteURLs(userID int, shortURLs []string, storage URLStorageInterface) error {
-	inputCh, err := storage.DeleteBatchWithUserID(userID)
+// Errors of deleting happen after a return, so they are logged.
+func DeleteURLs(userID int, shortURLs []string, storage URLStorageInterface, logger zap.SugaredLogger) error {
+	inputCh, done, err := storage.DeleteBatchWithUserID(userID)
 	if err != nil {
 		return fmt.Errorf("error while deleting URLs: %w", err)
 	}
 	//fan-out
 	go func() {
-		defer close(inputCh)
 		for _, URL := range shortURLs {
 			inputCh <- URL
 		}
+		close(inputCh)
+		if err := <-done; err != nil {
+			logger.Errorf("error while deleting URLs of user %d: %v", userID, err)
+		}
 	}()
 	return nil
 }
@@ -0,0 +1,77 @@
+package logic
+
+import "errors"
+
+var errBadURLParams = errors.New("bad url params")
+
+// ErrBadURLParams returns an errBadURLParams error.
+// It means that optional params of a URL (like expiration time, TTL or clicks limit) are not correct.
+func ErrBadURLParams() error {
+	return errBadURLParams
+}
+
+var errPasswordRequired = errors.New("this url is protected by a password")
+
+// ErrPasswordRequired returns an errPasswordRequired error.
+func ErrPasswordRequired() error {
+	return errPasswordRequired
+}
+
+var errWrongPassword = errors.New("wrong password")
+
+// ErrWrongPassword returns an errWrongPassword error.
+func ErrWrongPassword() error {
+	return errWrongPassword
+}
+
+var errTooManyAttempts = errors.New("too many password attempts")
+
+// ErrTooManyAttempts returns an errTooManyAttempts error.
+func ErrTooManyAttempts() error {
+	return errTooManyAttempts
+}
+
+var errNotAnOwner = errors.New("user is not an owner of this url")
+
+// ErrNotAnOwner returns an errNotAnOwner error.
+func ErrNotAnOwner() error {
+	return errNotAnOwner
+}
+
+var errBadAccountParams = errors.New("bad account params")
+
+// ErrBadAccountParams returns an errBadAccountParams error.
+// It means that a login or a password of a new account is not correct.
+func ErrBadAccountParams() error {
+	return errBadAccountParams
+}
+
+var errWrongCredentials = errors.New("wrong login or password")
+
+// ErrWrongCredentials returns an errWrongCredentials error.
+func ErrWrongCredentials() error {
+	return errWrongCredentials
+}
+
+var errBadAPIKeyParams = errors.New("bad api key params")
+
+// ErrBadAPIKeyParams returns an errBadAPIKeyParams error.
+// It means that a name, scopes or an expiration time of a new API key are not correct.
+func ErrBadAPIKeyParams() error {
+	return errBadAPIKeyParams
+}
+
+var errInvalidAPIKey = errors.New("api key is not valid")
+
+// ErrInvalidAPIKey returns an errInvalidAPIKey error. It means that a key doesn`t exist, was revoked or has expired.
+func ErrInvalidAPIKey() error {
+	return errInvalidAPIKey
+}
+
+var errInvalidSession = errors.New("session is not valid")
+
+// ErrInvalidSession returns an errInvalidSession error.
+// It means that a token is not valid or its session doesn`t exist, was revoked or has expired.
+func ErrInvalidSession() error {
+	return errInvalidSession
+}
@@ -0,0 +1,27 @@
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// ResolveExpiration sets url.ExpiresAt using url.TTL (if it was given) and checks it.
+// TTL has a priority over ExpiresAt. TTL is cleared after resolving.
+// Returns a wrapped ErrBadURLParams if TTL can`t be parsed or expiration time is not in the future.
+func ResolveExpiration(url *entities.URL, now time.Time) error {
+	if url.TTL != "" {
+		ttl, err := time.ParseDuration(url.TTL)
+		if err != nil {
+			return fmt.Errorf("%w: can`t parse ttl `%s`: %v", ErrBadURLParams(), url.TTL, err)
+		}
+		expiresAt := now.Add(ttl)
+		url.ExpiresAt = &expiresAt
+		url.TTL = ""
+	}
+	if url.ExpiresAt != nil && !url.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: expiration time `%v` has already passed", ErrBadURLParams(), *url.ExpiresAt)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExpiration(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		url           entities.URL
+		wantExpiresAt *time.Time
+		wantErr       bool
+	}{
+		{
+			name:          "no expiration",
+			url:           entities.URL{OriginalURL: "http://example.com"},
+			wantExpiresAt: nil,
+		},
+		{
+			name:          "ttl",
+			url:           entities.URL{OriginalURL: "http://example.com", TTL: "1h"},
+			wantExpiresAt: &future,
+		},
+		{
+			name:          "expires at",
+			url:           entities.URL{OriginalURL: "http://example.com", ExpiresAt: &future},
+			wantExpiresAt: &future,
+		},
+		{
+			name:    "bad ttl",
+			url:     entities.URL{OriginalURL: "http://example.com", TTL: "one hour"},
+			wantErr: true,
+		},
+		{
+			name:    "negative ttl",
+			url:     entities.URL{OriginalURL: "http://example.com", TTL: "-1h"},
+			wantErr: true,
+		},
+		{
+			name:    "expires at in the past",
+			url:     entities.URL{OriginalURL: "http://example.com", ExpiresAt: &past},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResolveExpiration(&tt.url, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrBadURLParams()))
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, tt.url.TTL)
+			assert.Equal(t, tt.wantExpiresAt, tt.url.ExpiresAt)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunStorageHealthCheck pings a storage every `interval` until ctx is done and calls setServing when a storage
+// state changes (serving is false if a storage is not available). The first state is reported immediately.
+// This func have to be called in different goroutine, because it has an endless loop.
+func RunStorageHealthCheck(ctx context.Context, storage URLStorageInterface, interval time.Duration, setServing func(serving bool), logger zap.SugaredLogger) {
+	err := PingDB(storage)
+	serving := err == nil
+	if !serving {
+		logger.Errorf("storage is not available: %v", err)
+	}
+	setServing(serving)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err = PingDB(storage)
+			if (err == nil) == serving {
+				continue
+			}
+			serving = err == nil
+			if serving {
+				logger.Info("storage is available again")
+			} else {
+				logger.Errorf("storage is not available: %v", err)
+			}
+			setServing(serving)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// switchableStorage is a storage witch Ping fails while `down` is true.
+type switchableStorage struct {
+	*databases.JustAMap
+	down atomic.Bool
+}
+
+func (s *switchableStorage) Ping() error {
+	if s.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestRunStorageHealthCheck(t *testing.T) {
+	storage := &switchableStorage{JustAMap: databases.NewJustAMap()}
+	states := make(chan bool, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go RunStorageHealthCheck(ctx, storage, 5*time.Millisecond, func(serving bool) {
+		states <- serving
+	}, *zaptest.NewLogger(t).Sugar())
+
+	waitState := func() bool {
+		select {
+		case serving := <-states:
+			return serving
+		case <-time.After(time.Second):
+			require.Fail(t, "state was not reported")
+			return false
+		}
+	}
+
+	assert.True(t, waitState(), "the first state is reported immediately")
+	storage.down.Store(true)
+	assert.False(t, waitState(), "outage is reported")
+	storage.down.Store(false)
+	assert.True(t, waitState(), "recovery is reported")
+
+	//a state is reported only when it changes
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, states)
+}
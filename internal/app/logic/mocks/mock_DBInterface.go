@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: url_shortener_handler.go
+// Source: reqiredInterfaces.go
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entities "github.com/Lesnoi3283/url_shortener/internal/app/entities"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,49 @@ func (m *MockURLStorageInterface) EXPECT() *MockURLStorageInterfaceMockRecorder
 	return m.recorder
 }
 
+// ClaimURLs mocks base method.
+func (m *MockURLStorageInterface) ClaimURLs(ctx context.Context, fromUserID, toUserID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimURLs", ctx, fromUserID, toUserID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimURLs indicates an expected call of ClaimURLs.
+func (mr *MockURLStorageInterfaceMockRecorder) ClaimURLs(ctx, fromUserID, toUserID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimURLs", reflect.TypeOf((*MockURLStorageInterface)(nil).ClaimURLs), ctx, fromUserID, toUserID)
+}
+
+// CreateAccount mocks base method.
+func (m *MockURLStorageInterface) CreateAccount(ctx context.Context, account entities.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockURLStorageInterfaceMockRecorder) CreateAccount(ctx, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockURLStorageInterface)(nil).CreateAccount), ctx, account)
+}
+
+// CreateSession mocks base method.
+func (m *MockURLStorageInterface) CreateSession(ctx context.Context, session entities.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockURLStorageInterfaceMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockURLStorageInterface)(nil).CreateSession), ctx, session)
+}
+
 // CreateUser mocks base method.
 func (m *MockURLStorageInterface) CreateUser(ctx context.Context) (int, error) {
 	m.ctrl.T.Helper()
@@ -50,13 +94,28 @@ func (mr *MockURLStorageInterfaceMockRecorder) CreateUser(ctx interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockURLStorageInterface)(nil).CreateUser), ctx)
 }
 
+// DeleteAPIKey mocks base method.
+func (m *MockURLStorageInterface) DeleteAPIKey(ctx context.Context, userID int, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKey", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIKey indicates an expected call of DeleteAPIKey.
+func (mr *MockURLStorageInterfaceMockRecorder) DeleteAPIKey(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*MockURLStorageInterface)(nil).DeleteAPIKey), ctx, userID, id)
+}
+
 // DeleteBatchWithUserID mocks base method.
-func (m *MockURLStorageInterface) DeleteBatchWithUserID(userID int) (chan string, error) {
+func (m *MockURLStorageInterface) DeleteBatchWithUserID(userID int) (chan string, <-chan error, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteBatchWithUserID", userID)
 	ret0, _ := ret[0].(chan string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(<-chan error)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // DeleteBatchWithUserID indicates an expected call of DeleteBatchWithUserID.
@@ -65,6 +124,50 @@ func (mr *MockURLStorageInterfaceMockRecorder) DeleteBatchWithUserID(userID inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBatchWithUserID", reflect.TypeOf((*MockURLStorageInterface)(nil).DeleteBatchWithUserID), userID)
 }
 
+// DeleteEndedSessions mocks base method.
+func (m *MockURLStorageInterface) DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEndedSessions", ctx, endedBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEndedSessions indicates an expected call of DeleteEndedSessions.
+func (mr *MockURLStorageInterfaceMockRecorder) DeleteEndedSessions(ctx, endedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEndedSessions", reflect.TypeOf((*MockURLStorageInterface)(nil).DeleteEndedSessions), ctx, endedBefore)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockURLStorageInterface) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, expiredBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockURLStorageInterfaceMockRecorder) DeleteExpired(ctx, expiredBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockURLStorageInterface)(nil).DeleteExpired), ctx, expiredBefore)
+}
+
+// ExtendSession mocks base method.
+func (m *MockURLStorageInterface) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtendSession", ctx, id, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExtendSession indicates an expected call of ExtendSession.
+func (mr *MockURLStorageInterfaceMockRecorder) ExtendSession(ctx, id, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtendSession", reflect.TypeOf((*MockURLStorageInterface)(nil).ExtendSession), ctx, id, expiresAt)
+}
+
 // Get mocks base method.
 func (m *MockURLStorageInterface) Get(ctx context.Context, short string) (string, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +183,51 @@ func (mr *MockURLStorageInterfaceMockRecorder) Get(ctx, short interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockURLStorageInterface)(nil).Get), ctx, short)
 }
 
+// GetAPIKey mocks base method.
+func (m *MockURLStorageInterface) GetAPIKey(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, keyHash)
+	ret0, _ := ret[0].(entities.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockURLStorageInterfaceMockRecorder) GetAPIKey(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockURLStorageInterface)(nil).GetAPIKey), ctx, keyHash)
+}
+
+// GetAccount mocks base method.
+func (m *MockURLStorageInterface) GetAccount(ctx context.Context, login string) (entities.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, login)
+	ret0, _ := ret[0].(entities.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockURLStorageInterfaceMockRecorder) GetAccount(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockURLStorageInterface)(nil).GetAccount), ctx, login)
+}
+
+// GetSession mocks base method.
+func (m *MockURLStorageInterface) GetSession(ctx context.Context, id string) (entities.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, id)
+	ret0, _ := ret[0].(entities.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockURLStorageInterfaceMockRecorder) GetSession(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockURLStorageInterface)(nil).GetSession), ctx, id)
+}
+
 // GetShortURLCount mocks base method.
 func (m *MockURLStorageInterface) GetShortURLCount(ctx context.Context) (int, error) {
 	m.ctrl.T.Helper()
@@ -95,34 +243,79 @@ func (mr *MockURLStorageInterfaceMockRecorder) GetShortURLCount(ctx interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShortURLCount", reflect.TypeOf((*MockURLStorageInterface)(nil).GetShortURLCount), ctx)
 }
 
-// GetUserCount mocks base method.
-func (m *MockURLStorageInterface) GetUsersCount(ctx context.Context) (int, error) {
+// GetURL mocks base method.
+func (m *MockURLStorageInterface) GetURL(ctx context.Context, short string) (entities.URL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsersCount", ctx)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "GetURL", ctx, short)
+	ret0, _ := ret[0].(entities.URL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserCount indicates an expected call of GetUserCount.
-func (mr *MockURLStorageInterfaceMockRecorder) GetUserCount(ctx interface{}) *gomock.Call {
+// GetURL indicates an expected call of GetURL.
+func (mr *MockURLStorageInterfaceMockRecorder) GetURL(ctx, short interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersCount", reflect.TypeOf((*MockURLStorageInterface)(nil).GetUsersCount), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockURLStorageInterface)(nil).GetURL), ctx, short)
+}
+
+// GetURLStats mocks base method.
+func (m *MockURLStorageInterface) GetURLStats(ctx context.Context, short string, topReferrers int) (entities.URLStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLStats", ctx, short, topReferrers)
+	ret0, _ := ret[0].(entities.URLStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLStats indicates an expected call of GetURLStats.
+func (mr *MockURLStorageInterfaceMockRecorder) GetURLStats(ctx, short, topReferrers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLStats", reflect.TypeOf((*MockURLStorageInterface)(nil).GetURLStats), ctx, short, topReferrers)
+}
+
+// GetUserAPIKeys mocks base method.
+func (m *MockURLStorageInterface) GetUserAPIKeys(ctx context.Context, userID int) ([]entities.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAPIKeys", ctx, userID)
+	ret0, _ := ret[0].([]entities.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAPIKeys indicates an expected call of GetUserAPIKeys.
+func (mr *MockURLStorageInterfaceMockRecorder) GetUserAPIKeys(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAPIKeys", reflect.TypeOf((*MockURLStorageInterface)(nil).GetUserAPIKeys), ctx, userID)
 }
 
 // GetUserUrls mocks base method.
-func (m *MockURLStorageInterface) GetUserUrls(ctx context.Context, userID int) ([]entities.URL, error) {
+func (m *MockURLStorageInterface) GetUserUrls(ctx context.Context, userID int, query entities.URLsPageQuery) ([]entities.URL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserUrls", ctx, userID)
+	ret := m.ctrl.Call(m, "GetUserUrls", ctx, userID, query)
 	ret0, _ := ret[0].([]entities.URL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserUrls indicates an expected call of GetUserUrls.
-func (mr *MockURLStorageInterfaceMockRecorder) GetUserUrls(ctx, userID interface{}) *gomock.Call {
+func (mr *MockURLStorageInterfaceMockRecorder) GetUserUrls(ctx, userID, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserUrls", reflect.TypeOf((*MockURLStorageInterface)(nil).GetUserUrls), ctx, userID, query)
+}
+
+// GetUsersCount mocks base method.
+func (m *MockURLStorageInterface) GetUsersCount(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersCount", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersCount indicates an expected call of GetUsersCount.
+func (mr *MockURLStorageInterfaceMockRecorder) GetUsersCount(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserUrls", reflect.TypeOf((*MockURLStorageInterface)(nil).GetUserUrls), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersCount", reflect.TypeOf((*MockURLStorageInterface)(nil).GetUsersCount), ctx)
 }
 
 // Ping mocks base method.
@@ -139,6 +332,20 @@ func (mr *MockURLStorageInterfaceMockRecorder) Ping() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockURLStorageInterface)(nil).Ping))
 }
 
+// RevokeSession mocks base method.
+func (m *MockURLStorageInterface) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, id, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockURLStorageInterfaceMockRecorder) RevokeSession(ctx, id, revokedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockURLStorageInterface)(nil).RevokeSession), ctx, id, revokedAt)
+}
+
 // Save mocks base method.
 func (m *MockURLStorageInterface) Save(ctx context.Context, url entities.URL) error {
 	m.ctrl.T.Helper()
@@ -153,6 +360,20 @@ func (mr *MockURLStorageInterfaceMockRecorder) Save(ctx, url interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockURLStorageInterface)(nil).Save), ctx, url)
 }
 
+// SaveAPIKey mocks base method.
+func (m *MockURLStorageInterface) SaveAPIKey(ctx context.Context, key entities.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAPIKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAPIKey indicates an expected call of SaveAPIKey.
+func (mr *MockURLStorageInterfaceMockRecorder) SaveAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAPIKey", reflect.TypeOf((*MockURLStorageInterface)(nil).SaveAPIKey), ctx, key)
+}
+
 // SaveBatch mocks base method.
 func (m *MockURLStorageInterface) SaveBatch(ctx context.Context, urls []entities.URL) error {
 	m.ctrl.T.Helper()
@@ -181,6 +402,20 @@ func (mr *MockURLStorageInterfaceMockRecorder) SaveBatchWithUserID(ctx, userID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatchWithUserID", reflect.TypeOf((*MockURLStorageInterface)(nil).SaveBatchWithUserID), ctx, userID, urls)
 }
 
+// SaveClicks mocks base method.
+func (m *MockURLStorageInterface) SaveClicks(ctx context.Context, clicks []entities.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveClicks", ctx, clicks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveClicks indicates an expected call of SaveClicks.
+func (mr *MockURLStorageInterfaceMockRecorder) SaveClicks(ctx, clicks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveClicks", reflect.TypeOf((*MockURLStorageInterface)(nil).SaveClicks), ctx, clicks)
+}
+
 // SaveWithUserID mocks base method.
 func (m *MockURLStorageInterface) SaveWithUserID(ctx context.Context, userID int, url entities.URL) error {
 	m.ctrl.T.Helper()
@@ -194,3 +429,45 @@ func (mr *MockURLStorageInterfaceMockRecorder) SaveWithUserID(ctx, userID, url i
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWithUserID", reflect.TypeOf((*MockURLStorageInterface)(nil).SaveWithUserID), ctx, userID, url)
 }
+
+// SetAPIKeyLastUsed mocks base method.
+func (m *MockURLStorageInterface) SetAPIKeyLastUsed(ctx context.Context, id string, lastUsed time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAPIKeyLastUsed", ctx, id, lastUsed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAPIKeyLastUsed indicates an expected call of SetAPIKeyLastUsed.
+func (mr *MockURLStorageInterfaceMockRecorder) SetAPIKeyLastUsed(ctx, id, lastUsed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAPIKeyLastUsed", reflect.TypeOf((*MockURLStorageInterface)(nil).SetAPIKeyLastUsed), ctx, id, lastUsed)
+}
+
+// UpdateURL mocks base method.
+func (m *MockURLStorageInterface) UpdateURL(ctx context.Context, userID int, short string, update entities.URLUpdate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", ctx, userID, short, update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockURLStorageInterfaceMockRecorder) UpdateURL(ctx, userID, short, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockURLStorageInterface)(nil).UpdateURL), ctx, userID, short, update)
+}
+
+// UseClick mocks base method.
+func (m *MockURLStorageInterface) UseClick(ctx context.Context, short string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseClick", ctx, short)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseClick indicates an expected call of UseClick.
+func (mr *MockURLStorageInterfaceMockRecorder) UseClick(ctx, short interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseClick", reflect.TypeOf((*MockURLStorageInterface)(nil).UseClick), ctx, short)
+}
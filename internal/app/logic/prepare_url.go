@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// prepareURL checks optional params of a URL before saving and fills fields witch depend on them.
+// Returns a wrapped ErrBadURLParams if params are not correct.
+func prepareURL(url *entities.URL, now time.Time) error {
+	err := ResolveExpiration(url, now)
+	if err != nil {
+		return err
+	}
+
+	if url.MaxClicks < 0 {
+		return fmt.Errorf("%w: max clicks can`t be negative, got %d", ErrBadURLParams(), url.MaxClicks)
+	}
+	url.ClicksLeft = nil
+	if url.MaxClicks > 0 {
+		clicksLeft := url.MaxClicks
+		url.ClicksLeft = &clicksLeft
+	}
+
+	if url.RedirectCode != 0 && !entities.IsRedirectCode(url.RedirectCode) {
+		return fmt.Errorf("%w: %d is not a redirect code", ErrBadURLParams(), url.RedirectCode)
+	}
+
+	err = checkUTMParams(url.UTM)
+	if err != nil {
+		return err
+	}
+	if len(url.UTM) == 0 {
+		url.UTM = nil
+	}
+
+	url.Rules, err = prepareRedirectRules(url.Rules)
+	if err != nil {
+		return err
+	}
+
+	url.Variants, err = prepareVariants(url.Variants)
+	if err != nil {
+		return err
+	}
+
+	err = prepareURLMeta(url)
+	if err != nil {
+		return err
+	}
+
+	url.PasswordHash = ""
+	if url.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(url.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("%w: can`t hash a password: %v", ErrBadURLParams(), err)
+		}
+		url.PasswordHash = string(hash)
+		url.Password = ""
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// PreviewURL returns a public information about a short URL. It doesn`t use a click of a URL and doesn`t record a click.
+// Short version includes the base address. Redirect rules and A/B variants are shown too,
+// so all destinations of a URL are known before following it.
+// An original URL, rules and variants of a protected URL are hidden.
+// A total amount of clicks is returned only if withClicks is true and user (userID) is an owner of a URL.
+// Can return databases.ErrURLNotFound.
+func PreviewURL(ctx context.Context, storage URLStorageInterface, baseAddress string, shortURL string, userID int, withClicks bool) (entities.URLPreview, error) {
+	URL, err := storage.GetURL(ctx, shortURL)
+	if err != nil {
+		return entities.URLPreview{}, fmt.Errorf("error while getting url from db: %w", err)
+	}
+
+	preview := entities.URLPreview{
+		ShortURL:     baseAddress + "/" + shortURL,
+		CreatedAt:    URL.CreatedAt,
+		ExpiresAt:    URL.ExpiresAt,
+		IsDeleted:    URL.IsDeleted,
+		IsExpired:    URL.IsExpiredAt(time.Now()),
+		NoClicksLeft: URL.IsExhausted(),
+		HasPassword:  URL.PasswordHash != "",
+	}
+	if !preview.HasPassword {
+		preview.OriginalURL = URL.OriginalURL
+		preview.Rules = URL.Rules
+		preview.Variants = URL.Variants
+	}
+
+	if withClicks && URL.UserID != 0 && URL.UserID == userID {
+		stats, err := storage.GetURLStats(ctx, shortURL, 0)
+		if err != nil {
+			return entities.URLPreview{}, fmt.Errorf("error while getting url stats: %w", err)
+		}
+		preview.TotalClicks = &stats.TotalClicks
+	}
+	return preview, nil
+}
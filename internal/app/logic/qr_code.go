@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"rsc.io/qr"
+)
+
+// QR code formats.
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+// QR code params limits. Size is measured in pixels, margin is measured in QR code modules.
+const (
+	DefaultQRSize   = 256
+	MaxQRSize       = 2048
+	DefaultQRMargin = 4
+	MaxQRMargin     = 16
+	DefaultQRLevel  = "M"
+)
+
+// qrLevels are error correction levels of QR codes (L - 7%, M - 15%, Q - 25%, H - 30% of a code can be restored).
+var qrLevels = map[string]qr.Level{
+	"L": qr.L,
+	"M": qr.M,
+	"Q": qr.Q,
+	"H": qr.H,
+}
+
+// QRCodeParams are params of a QR code image. Zero values mean default values.
+// Margin is a pointer, because zero margin is a valid value.
+type QRCodeParams struct {
+	Format string
+	Size   int
+	Margin *int
+	Level  string
+}
+
+// QRCode is a rendered QR code image.
+type QRCode struct {
+	ContentType string
+	Data        []byte
+}
+
+// BuildQRCode renders a QR code of a full short URL (baseAddress + "/" + shortURL).
+// Returns databases.ErrURLNotFound if there is no such URL and databases.ErrURLWasDeleted, databases.ErrURLExpired
+// or databases.ErrClicksLimitReached if URL can`t be used anymore.
+// Returns a wrapped ErrBadURLParams if params are not correct.
+func BuildQRCode(ctx context.Context, storage URLStorageInterface, baseAddress string, shortURL string, params QRCodeParams) (QRCode, error) {
+	params, level, err := prepareQRCodeParams(params)
+	if err != nil {
+		return QRCode{}, err
+	}
+
+	//URL check
+	URL, err := storage.GetURL(ctx, shortURL)
+	if err != nil {
+		return QRCode{}, fmt.Errorf("error while getting url from db: %w", err)
+	}
+	switch {
+	case URL.IsDeleted:
+		return QRCode{}, databases.ErrURLWasDeleted()
+	case URL.IsExpiredAt(time.Now()):
+		return QRCode{}, databases.ErrURLExpired()
+	case URL.IsExhausted():
+		return QRCode{}, databases.ErrClicksLimitReached()
+	}
+
+	//encoding
+	code, err := qr.Encode(baseAddress+"/"+shortURL, level)
+	if err != nil {
+		return QRCode{}, fmt.Errorf("error while encoding a qr code: %w", err)
+	}
+	modules := code.Size + 2*(*params.Margin)
+	if params.Size < modules {
+		return QRCode{}, fmt.Errorf("%w: size have to be at least %d pixels for this URL", ErrBadURLParams(), modules)
+	}
+
+	if params.Format == QRFormatSVG {
+		return QRCode{
+			ContentType: "image/svg+xml",
+			Data:        qrCodeSVG(code, *params.Margin, params.Size),
+		}, nil
+	}
+	data, err := qrCodePNG(code, *params.Margin, params.Size)
+	if err != nil {
+		return QRCode{}, fmt.Errorf("error while encoding png: %w", err)
+	}
+	return QRCode{
+		ContentType: "image/png",
+		Data:        data,
+	}, nil
+}
+
+// prepareQRCodeParams sets default values and checks params.
+func prepareQRCodeParams(params QRCodeParams) (QRCodeParams, qr.Level, error) {
+	params.Format = strings.ToLower(params.Format)
+	if params.Format == "" {
+		params.Format = QRFormatPNG
+	}
+	if params.Format != QRFormatPNG && params.Format != QRFormatSVG {
+		return QRCodeParams{}, 0, fmt.Errorf("%w: unknown format `%s`", ErrBadURLParams(), params.Format)
+	}
+
+	if params.Size == 0 {
+		params.Size = DefaultQRSize
+	}
+	if params.Size < 0 || params.Size > MaxQRSize {
+		return QRCodeParams{}, 0, fmt.Errorf("%w: size have to be between 1 and %d", ErrBadURLParams(), MaxQRSize)
+	}
+
+	if params.Margin == nil {
+		margin := DefaultQRMargin
+		params.Margin = &margin
+	}
+	if *params.Margin < 0 || *params.Margin > MaxQRMargin {
+		return QRCodeParams{}, 0, fmt.Errorf("%w: margin have to be between 0 and %d", ErrBadURLParams(), MaxQRMargin)
+	}
+
+	params.Level = strings.ToUpper(params.Level)
+	if params.Level == "" {
+		params.Level = DefaultQRLevel
+	}
+	level, ok := qrLevels[params.Level]
+	if !ok {
+		return QRCodeParams{}, 0, fmt.Errorf("%w: unknown error correction level `%s`", ErrBadURLParams(), params.Level)
+	}
+	return params, level, nil
+}
+
+// qrCodePNG renders a code as a PNG image size x size pixels.
+// Every module has the same integer width, so a code is centered and free pixels are added to a margin.
+func qrCodePNG(code *qr.Code, margin int, size int) ([]byte, error) {
+	modules := code.Size + 2*margin
+	scale := size / modules
+	offset := (size - code.Size*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+			for py := offset + y*scale; py < offset+(y+1)*scale; py++ {
+				for px := offset + x*scale; px < offset+(x+1)*scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// qrCodeSVG renders a code as an SVG image size x size pixels. One SVG unit is one module.
+func qrCodeSVG(code *qr.Code, margin int, size int) []byte {
+	modules := code.Size + 2*margin
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(buf, "M%d %dh1v1h-1z", x+margin, y+margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
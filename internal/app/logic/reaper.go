@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DeleteExpiredURLs removes URLs witch expired more than `retention` ago.
+// Returns an amount of removed URLs.
+func DeleteExpiredURLs(ctx context.Context, storage URLStorageInterface, retention time.Duration) (int, error) {
+	return storage.DeleteExpired(ctx, time.Now().Add(-retention))
+}
+
+// DeleteEndedSessions removes sessions witch expired or were revoked more than `retention` ago.
+// Returns an amount of removed sessions.
+func DeleteEndedSessions(ctx context.Context, storage URLStorageInterface, retention time.Duration) (int, error) {
+	return storage.DeleteEndedSessions(ctx, time.Now().Add(-retention))
+}
+
+// RunExpiredURLsReaper calls DeleteExpiredURLs and DeleteEndedSessions every `interval` until ctx is done.
+// This func have to be called in different goroutine, because it has an endless loop.
+func RunExpiredURLsReaper(ctx context.Context, storage URLStorageInterface, interval time.Duration, retention time.Duration, logger zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := DeleteExpiredURLs(ctx, storage, retention)
+			if err != nil {
+				logger.Errorf("expired URLs reaper error: %v", err)
+			} else if deleted > 0 {
+				logger.Infof("expired URLs reaper removed %d URLs", deleted)
+			}
+
+			deleted, err = DeleteEndedSessions(ctx, storage, retention)
+			if err != nil {
+				logger.Errorf("expired URLs reaper error (sessions): %v", err)
+			} else if deleted > 0 {
+				logger.Infof("expired URLs reaper removed %d sessions", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package logic
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of UTM params of a URL.
+const (
+	UTMPrefix         = "utm_"
+	MaxUTMParams      = 10
+	MaxUTMValueLength = 255
+)
+
+// ParseUTMParams parses default UTM params from a query string (like "utm_source=shortener&utm_medium=link").
+// Returns a wrapped ErrBadURLParams if params are not correct.
+func ParseUTMParams(query string) (map[string]string, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: can`t parse UTM params: %v", ErrBadURLParams(), err)
+	}
+	utm := make(map[string]string, len(values))
+	for key, value := range values {
+		utm[key] = value[0]
+	}
+	err = checkUTMParams(utm)
+	if err != nil {
+		return nil, err
+	}
+	return utm, nil
+}
+
+// checkUTMParams returns a wrapped ErrBadURLParams if UTM params are not correct.
+// Every key has to start with UTMPrefix, values can`t be empty.
+func checkUTMParams(utm map[string]string) error {
+	if len(utm) > MaxUTMParams {
+		return fmt.Errorf("%w: URL can have only %d UTM params", ErrBadURLParams(), MaxUTMParams)
+	}
+	for key, value := range utm {
+		if !strings.HasPrefix(key, UTMPrefix) || len(key) == len(UTMPrefix) {
+			return fmt.Errorf("%w: UTM param `%s` has to start with `%s`", ErrBadURLParams(), key, UTMPrefix)
+		}
+		if value == "" || utf8.RuneCountInString(value) > MaxUTMValueLength {
+			return fmt.Errorf("%w: UTM param `%s` has to be from 1 to %d symbols", ErrBadURLParams(), key, MaxUTMValueLength)
+		}
+	}
+	return nil
+}
+
+// buildDestination adds params to an original URL. Only params witch are absent in an original URL are added, so
+// params of an original URL always win. Then incoming params (only if passQuery is true) win over UTM params,
+// and UTM params of a URL win over global UTM params.
+// An original query is not re-encoded, new params are appended to it.
+func buildDestination(original string, incoming url.Values, passQuery bool, utm map[string]string, globalUTM map[string]string) (string, error) {
+	if (!passQuery || len(incoming) == 0) && len(utm) == 0 && len(globalUTM) == 0 {
+		return original, nil
+	}
+	destination, err := url.Parse(original)
+	if err != nil {
+		return "", fmt.Errorf("can`t parse an original URL: %w", err)
+	}
+	own := destination.Query()
+
+	added := url.Values{}
+	if passQuery {
+		for key, values := range incoming {
+			if _, ok := own[key]; !ok {
+				added[key] = values
+			}
+		}
+	}
+	for _, params := range []map[string]string{utm, globalUTM} {
+		for key, value := range params {
+			if _, ok := own[key]; ok {
+				continue
+			}
+			if _, ok := added[key]; ok {
+				continue
+			}
+			added.Set(key, value)
+		}
+	}
+	if len(added) == 0 {
+		return original, nil
+	}
+
+	if destination.RawQuery == "" {
+		destination.RawQuery = added.Encode()
+	} else {
+		destination.RawQuery += "&" + added.Encode()
+	}
+	return destination.String(), nil
+}
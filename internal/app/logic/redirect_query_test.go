@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDestination(t *testing.T) {
+	tests := []struct {
+		name      string
+		original  string
+		incoming  url.Values
+		passQuery bool
+		utm       map[string]string
+		globalUTM map[string]string
+		want      string
+	}{
+		{
+			name:     "nothing to add",
+			original: "https://example.com/page?b=2&a=1",
+			incoming: url.Values{"x": {"1"}},
+			want:     "https://example.com/page?b=2&a=1",
+		},
+		{
+			name:      "incoming query",
+			original:  "https://example.com/page",
+			incoming:  url.Values{"x": {"1", "2"}},
+			passQuery: true,
+			want:      "https://example.com/page?x=1&x=2",
+		},
+		{
+			name:      "original params win",
+			original:  "https://example.com/page?ref=partner&utm_source=site#top",
+			incoming:  url.Values{"ref": {"visitor"}, "x": {"1"}},
+			passQuery: true,
+			utm:       map[string]string{"utm_source": "link"},
+			want:      "https://example.com/page?ref=partner&utm_source=site&x=1#top",
+		},
+		{
+			name:      "incoming params win over utm",
+			original:  "https://example.com/",
+			incoming:  url.Values{"utm_source": {"newsletter"}},
+			passQuery: true,
+			utm:       map[string]string{"utm_source": "link", "utm_medium": "qr"},
+			want:      "https://example.com/?utm_medium=qr&utm_source=newsletter",
+		},
+		{
+			name:      "url utm wins over global utm",
+			original:  "https://example.com/",
+			utm:       map[string]string{"utm_source": "link"},
+			globalUTM: map[string]string{"utm_source": "shortener", "utm_medium": "short"},
+			want:      "https://example.com/?utm_medium=short&utm_source=link",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildDestination(tt.original, tt.incoming, tt.passQuery, tt.utm, tt.globalUTM)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseUTMParams(t *testing.T) {
+	utm, err := ParseUTMParams("utm_source=shortener&utm_medium=link")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"utm_source": "shortener", "utm_medium": "link"}, utm)
+
+	for _, query := range []string{"source=shortener", "utm_=x", "utm_source=", "utm_source=%zz"} {
+		_, err = ParseUTMParams(query)
+		assert.True(t, errors.Is(err, ErrBadURLParams()), query)
+	}
+}
@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// Limits of redirect rules of a URL.
+const (
+	MaxRedirectRules  = 20
+	MaxLanguageLength = 35
+)
+
+// ruleDevices are user agent classes witch can be used in redirect rules.
+var ruleDevices = map[string]struct{}{
+	UserAgentIOS:     {},
+	UserAgentAndroid: {},
+	UserAgentDesktop: {},
+	UserAgentBot:     {},
+}
+
+// checkDestination returns a wrapped ErrBadURLParams if a destination is not an absolute URL.
+func checkDestination(destination string) error {
+	parsed, err := url.ParseRequestURI(destination)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("%w: `%s` is not a valid url", ErrBadURLParams(), destination)
+	}
+	return nil
+}
+
+// prepareRedirectRules normalizes (lowercases devices and languages) and checks redirect rules.
+// Returns a wrapped ErrBadURLParams if rules are not correct.
+func prepareRedirectRules(rules []entities.RedirectRule) ([]entities.RedirectRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if len(rules) > MaxRedirectRules {
+		return nil, fmt.Errorf("%w: URL can have only %d redirect rules", ErrBadURLParams(), MaxRedirectRules)
+	}
+
+	prepared := make([]entities.RedirectRule, len(rules))
+	for i, rule := range rules {
+		rule.Device = strings.ToLower(strings.TrimSpace(rule.Device))
+		rule.Language = strings.ToLower(strings.TrimSpace(rule.Language))
+		if rule.Device == "" && rule.Language == "" {
+			return nil, fmt.Errorf("%w: rule %d has no device and no language", ErrBadURLParams(), i)
+		}
+		if _, ok := ruleDevices[rule.Device]; rule.Device != "" && !ok {
+			return nil, fmt.Errorf("%w: unknown device `%s` in rule %d", ErrBadURLParams(), rule.Device, i)
+		}
+		if !isLanguageTag(rule.Language) {
+			return nil, fmt.Errorf("%w: `%s` is not a language in rule %d", ErrBadURLParams(), rule.Language, i)
+		}
+		err := checkDestination(rule.Destination)
+		if err != nil {
+			return nil, err
+		}
+		prepared[i] = rule
+	}
+	return prepared, nil
+}
+
+// isLanguageTag returns true if a language is empty or looks like a language tag ("en", "en-us", "zh-hant-tw").
+func isLanguageTag(language string) bool {
+	if language == "" {
+		return true
+	}
+	if len(language) > MaxLanguageLength {
+		return false
+	}
+	for _, subtag := range strings.Split(language, "-") {
+		if subtag == "" {
+			return false
+		}
+		for _, r := range subtag {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// acceptedLanguages returns lowercased languages from an Accept-Language header.
+// Languages with q=0 and "*" are skipped. Order of languages is kept.
+func acceptedLanguages(header string) []string {
+	languages := make([]string, 0)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		language := strings.ToLower(strings.TrimSpace(params[0]))
+		if language == "" || language == "*" {
+			continue
+		}
+		accepted := true
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if q, ok := strings.CutPrefix(param, "q="); ok {
+				weight, err := strconv.ParseFloat(q, 64)
+				accepted = err == nil && weight > 0
+			}
+		}
+		if accepted {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
+
+// languageMatches returns true if a rule language is one of accepted languages.
+// Rule language "en" matches "en" and "en-us", rule language "en-us" matches only "en-us".
+func languageMatches(ruleLanguage string, languages []string) bool {
+	for _, language := range languages {
+		if language == ruleLanguage || strings.HasPrefix(language, ruleLanguage+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRedirectRule returns a destination of the first rule witch matches a visit.
+// Returns false if no rule matches (an original URL has to be used).
+func matchRedirectRule(rules []entities.RedirectRule, visit Visit) (string, bool) {
+	if len(rules) == 0 {
+		return "", false
+	}
+	device := ClassifyUserAgent(visit.UserAgent)
+	languages := acceptedLanguages(visit.AcceptLanguage)
+	for _, rule := range rules {
+		if rule.Device != "" && rule.Device != device {
+			continue
+		}
+		if rule.Language != "" && !languageMatches(rule.Language, languages) {
+			continue
+		}
+		return rule.Destination, true
+	}
+	return "", false
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15"
+	testAndroidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36"
+	testDesktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
+)
+
+func TestMatchRedirectRule(t *testing.T) {
+	rules := []entities.RedirectRule{
+		{Device: UserAgentIOS, Language: "de", Destination: "https://apps.apple.com/de/app"},
+		{Device: UserAgentIOS, Destination: "https://apps.apple.com/app"},
+		{Device: UserAgentAndroid, Destination: "https://play.google.com/app"},
+		{Language: "en-gb", Destination: "https://example.co.uk/"},
+	}
+
+	tests := []struct {
+		name     string
+		visit    Visit
+		want     string
+		wantRule bool
+	}{
+		{
+			name:     "device and language",
+			visit:    Visit{UserAgent: testIPhoneUA, AcceptLanguage: "fr-FR, de;q=0.5"},
+			want:     "https://apps.apple.com/de/app",
+			wantRule: true,
+		},
+		{
+			name:     "language with q=0 is not accepted",
+			visit:    Visit{UserAgent: testIPhoneUA, AcceptLanguage: "fr-FR, de;q=0"},
+			want:     "https://apps.apple.com/app",
+			wantRule: true,
+		},
+		{
+			name:     "device",
+			visit:    Visit{UserAgent: testAndroidUA, AcceptLanguage: "de"},
+			want:     "https://play.google.com/app",
+			wantRule: true,
+		},
+		{
+			name:     "language",
+			visit:    Visit{UserAgent: testDesktopUA, AcceptLanguage: "en-GB,en;q=0.9"},
+			want:     "https://example.co.uk/",
+			wantRule: true,
+		},
+		{
+			name:  "more specific rule language",
+			visit: Visit{UserAgent: testDesktopUA, AcceptLanguage: "en"},
+		},
+		{
+			name:  "no rule",
+			visit: Visit{UserAgent: testDesktopUA},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchRedirectRule(rules, tt.visit)
+			assert.Equal(t, tt.wantRule, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrepareRedirectRules(t *testing.T) {
+	prepared, err := prepareRedirectRules([]entities.RedirectRule{
+		{Device: " IOS ", Language: "en-US", Destination: "https://apps.apple.com/app"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []entities.RedirectRule{
+		{Device: UserAgentIOS, Language: "en-us", Destination: "https://apps.apple.com/app"},
+	}, prepared)
+
+	badRules := map[string]entities.RedirectRule{
+		"no condition":   {Destination: "https://example.com/"},
+		"unknown device": {Device: "tv", Destination: "https://example.com/"},
+		"bad language":   {Language: "en_US", Destination: "https://example.com/"},
+		"bad url":        {Device: UserAgentBot, Destination: "example"},
+	}
+	for name, rule := range badRules {
+		_, err = prepareRedirectRules([]entities.RedirectRule{rule})
+		assert.True(t, errors.Is(err, ErrBadURLParams()), name)
+	}
+}
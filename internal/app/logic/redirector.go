@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Redirector resolves short URLs for redirects.
+// It keeps a state (like failed password attempts), so one Redirector have to be shared by all handlers (HTTP and gRPC).
+// Use NewRedirector to build it.
+type Redirector struct {
+	Storage          URLStorageInterface
+	Clicks           ClickRecorder
+	passwordAttempts *AttemptsLimiter
+	ipSalt           string
+	recordIPs        bool
+	redirectCode     int
+	passQuery        bool
+	utm              map[string]string
+}
+
+// Redirect is a result of a short URL resolving: where and how a client has to be redirected.
+// Variant is a name of a chosen A/B variant (empty if variants were not used).
+// StickyVariant is true if a variant has to be remembered for a visitor (see Visit.Variant).
+type Redirect struct {
+	URL           string
+	Code          int
+	Variant       string
+	StickyVariant bool
+}
+
+// NewRedirector builds a new Redirector.
+// Every short URL can have only conf.PasswordAttempts failed password attempts during conf.PasswordWindow.
+// Clicks are recorded by `clicks` (nil disables recording), IPs are hashed with conf.IPHashSalt as a salt.
+// URLs without their own redirect code use conf.RedirectCode (or config.DefaultRedirectCode if it is not set).
+// conf.PassQuery and conf.UTMParams are used to build destinations (see buildDestination).
+// conf.UTMParams have to be checked by ParseUTMParams before, not correct params are ignored.
+func NewRedirector(storage URLStorageInterface, clicks ClickRecorder, conf config.Config) *Redirector {
+	redirectCode := conf.RedirectCode
+	if redirectCode == 0 {
+		redirectCode = config.DefaultRedirectCode
+	}
+	utm, _ := ParseUTMParams(conf.UTMParams)
+	return &Redirector{
+		Storage:          storage,
+		Clicks:           clicks,
+		passwordAttempts: NewAttemptsLimiter(conf.PasswordAttempts, conf.PasswordWindow),
+		ipSalt:           conf.IPHashSalt,
+		recordIPs:        !conf.DisableClickIPs,
+		redirectCode:     redirectCode,
+		passQuery:        conf.PassQuery,
+		utm:              utm,
+	}
+}
+
+// Resolve returns a destination and a redirect code for given short URL.
+// A destination is a destination of the first matching redirect rule, or of a weighted variant (if no rule matches),
+// or an original URL. Then params are added to it.
+// It checks a password (if URL is protected) and uses one click of a URL (if URL has a clicks limit), so call it only for redirects.
+// Can return the databases.ErrURLWasDeleted, databases.ErrURLExpired and databases.ErrClicksLimitReached errors.
+// Can return ErrPasswordRequired, ErrWrongPassword and ErrTooManyAttempts errors if URL is protected.
+// Successful redirects are recorded as clicks.
+func (r *Redirector) Resolve(ctx context.Context, shortURL string, visit Visit) (Redirect, error) {
+	//reading from DB
+	URL, err := r.Storage.GetURL(ctx, shortURL)
+	if err != nil {
+		return Redirect{}, fmt.Errorf("error while getting url from db: %w", err)
+	}
+	switch {
+	case URL.IsDeleted:
+		return Redirect{}, databases.ErrURLWasDeleted()
+	case URL.IsExpiredAt(time.Now()):
+		return Redirect{}, databases.ErrURLExpired()
+	case URL.IsExhausted():
+		return Redirect{}, databases.ErrClicksLimitReached()
+	}
+
+	//password check
+	if URL.PasswordHash != "" {
+		err = r.checkPassword(shortURL, URL.PasswordHash, visit.Password)
+		if err != nil {
+			return Redirect{}, err
+		}
+	}
+
+	//destination building
+	passQuery := r.passQuery
+	if URL.PassQuery != nil {
+		passQuery = *URL.PassQuery
+	}
+	destination := URL.OriginalURL
+	variant := ""
+	if ruleDestination, ok := matchRedirectRule(URL.Rules, visit); ok {
+		destination = ruleDestination
+	} else if picked, ok := pickVariant(URL.Variants, sticky(URL.StickyVariants, visit.Variant)); ok {
+		destination = picked.Destination
+		variant = picked.Name
+	}
+	destination, err = buildDestination(destination, visit.Query, passQuery, URL.UTM, r.utm)
+	if err != nil {
+		return Redirect{}, err
+	}
+
+	//clicks counting
+	err = r.Storage.UseClick(ctx, shortURL)
+	if err != nil {
+		return Redirect{}, fmt.Errorf("error while using a click: %w", err)
+	}
+
+	//analytics
+	if r.Clicks != nil {
+		click := newClick(shortURL, visit, time.Now(), r.ipSalt, r.recordIPs)
+		click.Variant = variant
+		r.Clicks.RecordClick(ctx, click)
+	}
+
+	//redirect type
+	redirect := Redirect{
+		URL:           destination,
+		Code:          URL.RedirectCode,
+		Variant:       variant,
+		StickyVariant: URL.StickyVariants && variant != "",
+	}
+	if redirect.Code == 0 {
+		redirect.Code = r.redirectCode
+	}
+	return redirect, nil
+}
+
+// sticky returns an assigned variant only if variants of a URL are sticky.
+func sticky(stickyVariants bool, assigned string) string {
+	if !stickyVariants {
+		return ""
+	}
+	return assigned
+}
+
+// checkPassword compares password with a hash and counts failed attempts.
+func (r *Redirector) checkPassword(shortURL string, hash string, password string) error {
+	if password == "" {
+		return ErrPasswordRequired()
+	}
+	if r.passwordAttempts.IsBlocked(shortURL) {
+		return ErrTooManyAttempts()
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		r.passwordAttempts.AddFailure(shortURL)
+		return ErrWrongPassword()
+	}
+	r.passwordAttempts.Reset(shortURL)
+	return nil
+}
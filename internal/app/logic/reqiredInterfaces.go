@@ -2,22 +2,61 @@ package logic
 
 import (
 	"context"
+	"time"
+
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 )
 
-//go:generate mockgen -source=url_shortener_handler.go -destination=mocks/mock_DBInterface.go -package=mocks github.com/Lesnoi3283/url_shortener/internal/app/handlers URLStorageInterface
+//go:generate mockgen -source=reqiredInterfaces.go -destination=mocks/mock_DBInterface.go -package=mocks
 
 // URLStorageInterface is a main database interface.
+// Save methods have to return a databases.AlreadyExistsError if a short URL is already used by the same original URL
+// and a databases.ShortURLTakenError if it is used by another one (batches are not saved at all in these cases).
+// GetURL have to return a URL with all its fields (even if URL was deleted or has expired).
+// Get have to return databases.ErrURLWasDeleted, databases.ErrURLExpired or databases.ErrClicksLimitReached if URL can`t be used anymore.
+// UseClick have to decrease clicks left of a URL atomically (if URL has a clicks limit)
+// and return databases.ErrClicksLimitReached if there are no clicks left.
+// DeleteBatchWithUserID have to delete URLs sent to urlsChan until a caller closes it, then it has to send a result
+// of deleting (nil or an error) to done. urlsChan have to be read till the end even after an error.
+// GetUserUrls have to return only one page of URLs described by entities.URLsPageQuery.
+// GetURLStats have to return only `topReferrers` most popular referrers.
+// UpdateURL have to change a URL only if it belongs to a user, databases.ErrURLNotFound have to be returned if not.
+// CreateAccount have to return databases.ErrLoginTaken if a login is already used,
+// GetAccount have to return databases.ErrAccountNotFound if there is no such login.
+// GetAPIKey have to find a key by its hash, GetAPIKey and DeleteAPIKey have to return databases.ErrAPIKeyNotFound
+// if there is no such key (DeleteAPIKey deletes only keys of given user).
+// GetSession have to return a session even if it was revoked or has expired, GetSession, ExtendSession and RevokeSession
+// have to return databases.ErrSessionNotFound if there is no such session (ExtendSession have to return it for revoked sessions too).
+// ClaimURLs have to move URLs of a user to another user only if the first one has no account (URLs of accounts are never moved).
 type URLStorageInterface interface {
 	Save(ctx context.Context, url entities.URL) error
 	SaveBatch(ctx context.Context, urls []entities.URL) error
 	Get(ctx context.Context, short string) (full string, err error)
 	SaveWithUserID(ctx context.Context, userID int, url entities.URL) error
 	SaveBatchWithUserID(ctx context.Context, userID int, urls []entities.URL) error
-	DeleteBatchWithUserID(userID int) (urlsChan chan string, err error)
-	GetUserUrls(ctx context.Context, userID int) ([]entities.URL, error)
+	DeleteBatchWithUserID(userID int) (urlsChan chan string, done <-chan error, err error)
+	GetUserUrls(ctx context.Context, userID int, query entities.URLsPageQuery) ([]entities.URL, error)
 	Ping() error
 	CreateUser(ctx context.Context) (int, error)
 	GetUsersCount(ctx context.Context) (int, error)
 	GetShortURLCount(ctx context.Context) (int, error)
+	DeleteExpired(ctx context.Context, expiredBefore time.Time) (deleted int, err error)
+	UseClick(ctx context.Context, short string) error
+	GetURL(ctx context.Context, short string) (entities.URL, error)
+	SaveClicks(ctx context.Context, clicks []entities.Click) error
+	GetURLStats(ctx context.Context, short string, topReferrers int) (entities.URLStats, error)
+	UpdateURL(ctx context.Context, userID int, short string, update entities.URLUpdate) error
+	CreateAccount(ctx context.Context, account entities.Account) error
+	GetAccount(ctx context.Context, login string) (entities.Account, error)
+	ClaimURLs(ctx context.Context, fromUserID int, toUserID int) (claimed int, err error)
+	SaveAPIKey(ctx context.Context, key entities.APIKey) error
+	GetAPIKey(ctx context.Context, keyHash string) (entities.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID int) ([]entities.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID int, id string) error
+	SetAPIKeyLastUsed(ctx context.Context, id string, lastUsed time.Time) error
+	CreateSession(ctx context.Context, session entities.Session) error
+	GetSession(ctx context.Context, id string) (entities.Session, error)
+	ExtendSession(ctx context.Context, id string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, id string, revokedAt time.Time) error
+	DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (deleted int, err error)
 }
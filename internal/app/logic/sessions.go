@@ -0,0 +1,218 @@
+package logic
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+)
+
+// Session params. Refresh tokens are "<session ID>.<secret>", only a hash of a secret is kept.
+const (
+	sessionIDLength     = 16
+	refreshSecretLength = 32
+)
+
+// Sessions starts, checks, refreshes and revokes sessions of users.
+// Every session has a long-lived refresh token (only its hash is kept by a storage)
+// and short-lived access tokens (JWTs with an ID of a session). A session expires after RefreshTTL without activity.
+// Tokens of revoked sessions are not accepted anymore, so a logout works at once.
+// Use NewSessions to build it.
+type Sessions struct {
+	Storage    URLStorageInterface
+	JWTHelper  *secure.JWTHelper
+	RefreshTTL time.Duration
+}
+
+// SessionTokens are tokens of a session. RefreshToken is empty if only an access token was reissued.
+type SessionTokens struct {
+	UserID           int
+	SessionID        string
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// SessionAuth is a result of an access token checking.
+// Reissued is not nil if a token was close to expiry, it contains a new access token of the same session.
+type SessionAuth struct {
+	UserID    int
+	SessionID string
+	Reissued  *SessionTokens
+}
+
+// NewSessions builds a new Sessions. Sessions live conf.RefreshTokenTTL without activity
+// (config.DefaultRefreshTokenTTL if it is not set).
+func NewSessions(storage URLStorageInterface, jh *secure.JWTHelper, conf config.Config) *Sessions {
+	ttl := conf.RefreshTokenTTL
+	if ttl <= 0 {
+		ttl = config.DefaultRefreshTokenTTL
+	}
+	return &Sessions{
+		Storage:    storage,
+		JWTHelper:  jh,
+		RefreshTTL: ttl,
+	}
+}
+
+// Start starts a new session of a user and returns its tokens.
+func (s *Sessions) Start(ctx context.Context, userID int) (SessionTokens, error) {
+	id, err := randomString(sessionIDLength, hex.EncodeToString)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	secret, err := randomString(refreshSecretLength, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	now := time.Now().UTC()
+	session := entities.Session{
+		ID:               id,
+		UserID:           userID,
+		RefreshTokenHash: hashToken(secret),
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(s.RefreshTTL),
+	}
+	err = s.Storage.CreateSession(ctx, session)
+	if err != nil {
+		return SessionTokens{}, fmt.Errorf("error while saving a session: %w", err)
+	}
+
+	tokens, err := s.accessTokens(session, now)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	tokens.RefreshToken = id + "." + secret
+	return tokens, nil
+}
+
+// Authenticate checks an access token and its session. Tokens without a session are not accepted (they can`t be revoked).
+// A token with less than a half of its lifetime left is reissued (see SessionAuth.Reissued)
+// and its session is prolonged if less than a half of RefreshTTL is left.
+// Returns ErrInvalidSession if a token is not valid or its session was revoked or has expired.
+func (s *Sessions) Authenticate(ctx context.Context, accessToken string) (SessionAuth, error) {
+	claims, err := s.JWTHelper.GetClaims(accessToken)
+	if err != nil {
+		return SessionAuth{}, fmt.Errorf("%w: %v", ErrInvalidSession(), err)
+	}
+	if claims.SessionID == "" {
+		return SessionAuth{}, fmt.Errorf("%w: token has no session", ErrInvalidSession())
+	}
+
+	now := time.Now().UTC()
+	session, err := s.activeSession(ctx, claims.SessionID, now)
+	if err != nil {
+		return SessionAuth{}, err
+	}
+	if session.UserID != claims.UserID {
+		return SessionAuth{}, ErrInvalidSession()
+	}
+	auth := SessionAuth{UserID: session.UserID, SessionID: session.ID}
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Sub(now) >= s.JWTHelper.TokenExp/2 {
+		return auth, nil
+	}
+
+	//sliding: a session is prolonged by an activity
+	if session.ExpiresAt.Sub(now) < s.RefreshTTL/2 {
+		session, err = s.extend(ctx, session, now)
+		if err != nil {
+			return SessionAuth{}, err
+		}
+	}
+	tokens, err := s.accessTokens(session, now)
+	if err != nil {
+		return SessionAuth{}, err
+	}
+	auth.Reissued = &tokens
+	return auth, nil
+}
+
+// Refresh checks a refresh token, prolongs its session and returns a new access token (with the same refresh token).
+// Returns ErrInvalidSession if a refresh token is not valid or its session was revoked or has expired.
+func (s *Sessions) Refresh(ctx context.Context, refreshToken string) (SessionTokens, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return SessionTokens{}, ErrInvalidSession()
+	}
+	now := time.Now().UTC()
+	session, err := s.activeSession(ctx, id, now)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(session.RefreshTokenHash)) != 1 {
+		return SessionTokens{}, ErrInvalidSession()
+	}
+
+	session, err = s.extend(ctx, session, now)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	tokens, err := s.accessTokens(session, now)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	tokens.RefreshToken = refreshToken
+	return tokens, nil
+}
+
+// Revoke revokes a session, so its tokens are not accepted anymore.
+// Returns a wrapped databases.ErrSessionNotFound if there is no such session.
+func (s *Sessions) Revoke(ctx context.Context, sessionID string) error {
+	err := s.Storage.RevokeSession(ctx, sessionID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error while revoking a session: %w", err)
+	}
+	return nil
+}
+
+// activeSession returns a session if it exists, was not revoked and has not expired. Returns ErrInvalidSession if not.
+func (s *Sessions) activeSession(ctx context.Context, id string, now time.Time) (entities.Session, error) {
+	session, err := s.Storage.GetSession(ctx, id)
+	if errors.Is(err, databases.ErrSessionNotFound()) {
+		return entities.Session{}, ErrInvalidSession()
+	} else if err != nil {
+		return entities.Session{}, fmt.Errorf("error while getting a session: %w", err)
+	}
+	if !session.IsActiveAt(now) {
+		return entities.Session{}, ErrInvalidSession()
+	}
+	return session, nil
+}
+
+// extend prolongs a session for RefreshTTL. Returns ErrInvalidSession if it was revoked.
+func (s *Sessions) extend(ctx context.Context, session entities.Session, now time.Time) (entities.Session, error) {
+	expiresAt := now.Add(s.RefreshTTL)
+	err := s.Storage.ExtendSession(ctx, session.ID, expiresAt)
+	if errors.Is(err, databases.ErrSessionNotFound()) {
+		return entities.Session{}, ErrInvalidSession()
+	} else if err != nil {
+		return entities.Session{}, fmt.Errorf("error while prolonging a session: %w", err)
+	}
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+// accessTokens builds a new access token of a session.
+func (s *Sessions) accessTokens(session entities.Session, now time.Time) (SessionTokens, error) {
+	token, err := s.JWTHelper.BuildSessionJWTString(session.UserID, session.ID)
+	if err != nil {
+		return SessionTokens{}, fmt.Errorf("error while building JWT: %w", err)
+	}
+	return SessionTokens{
+		UserID:           session.UserID,
+		SessionID:        session.ID,
+		AccessToken:      token,
+		AccessExpiresAt:  now.Add(s.JWTHelper.TokenExp),
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
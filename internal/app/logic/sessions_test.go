@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/Lesnoi3283/url_shortener/pkg/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	storage := databases.NewJustAMap()
+	jh := secure.NewJWTHelper("testSecretKey", 1)
+	sessions := NewSessions(storage, jh, config.Config{RefreshTokenTTL: time.Hour})
+
+	userID := 7
+	tokens, err := sessions.Start(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, tokens.UserID)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	saved, err := storage.GetSession(ctx, tokens.SessionID)
+	require.NoError(t, err)
+	_, secret, _ := strings.Cut(tokens.RefreshToken, ".")
+	assert.Equal(t, hashToken(secret), saved.RefreshTokenHash, "only a hash of a refresh token has to be kept")
+
+	t.Run("authenticate", func(t *testing.T) {
+		auth, err := sessions.Authenticate(ctx, tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, userID, auth.UserID)
+		assert.Equal(t, tokens.SessionID, auth.SessionID)
+		assert.Nil(t, auth.Reissued, "a new token must not be reissued")
+
+		_, err = sessions.Authenticate(ctx, "not a token")
+		assert.ErrorIs(t, err, ErrInvalidSession())
+	})
+
+	t.Run("token close to expiry is reissued", func(t *testing.T) {
+		shortJH := secure.NewJWTHelper("testSecretKey", 1)
+		shortJH.TokenExp = time.Minute
+		oldToken, err := shortJH.BuildSessionJWTString(userID, tokens.SessionID)
+		require.NoError(t, err)
+
+		auth, err := sessions.Authenticate(ctx, oldToken)
+		require.NoError(t, err)
+		require.NotNil(t, auth.Reissued)
+		assert.Empty(t, auth.Reissued.RefreshToken)
+		claims, err := jh.GetClaims(auth.Reissued.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, tokens.SessionID, claims.SessionID)
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		refreshed, err := sessions.Refresh(ctx, tokens.RefreshToken)
+		require.NoError(t, err)
+		assert.Equal(t, tokens.SessionID, refreshed.SessionID)
+		assert.Equal(t, tokens.RefreshToken, refreshed.RefreshToken)
+		assert.False(t, refreshed.RefreshExpiresAt.Before(tokens.RefreshExpiresAt), "a session has to be prolonged")
+
+		for _, refreshToken := range []string{"", "no dot", tokens.SessionID + ".wrong", "unknown." + "secret"} {
+			_, err = sessions.Refresh(ctx, refreshToken)
+			assert.ErrorIs(t, err, ErrInvalidSession(), "refresh token `%s`", refreshToken)
+		}
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		other, err := sessions.Start(ctx, userID)
+		require.NoError(t, err)
+		require.NoError(t, sessions.Revoke(ctx, tokens.SessionID))
+
+		_, err = sessions.Authenticate(ctx, tokens.AccessToken)
+		assert.ErrorIs(t, err, ErrInvalidSession())
+		_, err = sessions.Refresh(ctx, tokens.RefreshToken)
+		assert.ErrorIs(t, err, ErrInvalidSession())
+
+		//other sessions of a user still work
+		_, err = sessions.Authenticate(ctx, other.AccessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("tokens without a session", func(t *testing.T) {
+		token, err := jh.BuildSessionJWTString(userID, "")
+		require.NoError(t, err)
+		_, err = sessions.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidSession())
+	})
+}
@@ -3,33 +3,37 @@ package logic
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 )
 
 // Shorten saves one URL to a storage.
-// Returns a short version with a base address. Even after an error from database it will return a short version.
+// Only url.OriginalURL is required, other fields (like url.ExpiresAt, url.TTL or url.MaxClicks) are optional.
+// Returns a short version with a base address. If a short version is taken by other URL (it happens after an original URL
+// of a short URL was changed), other short versions are tried.
 // Can return a wrapped databases.AlreadyExistsError (in this case use short url value from error).
+// Can return a wrapped ErrBadURLParams.
 // Use "userID = -1" to save URLs without a userID.
-func Shorten(ctx context.Context, URL []byte, baseAddress string, storage URLStorageInterface, userID int) (string, error) {
-	urlShort := string(ShortenURL(URL))
+func Shorten(ctx context.Context, url entities.URL, baseAddress string, storage URLStorageInterface, userID int) (string, error) {
+	err := prepareURL(&url, time.Now())
+	if err != nil {
+		return "", err
+	}
+	urls := []entities.URL{url}
+	urls[0].ShortURL = shortURLFor(url.OriginalURL, 0)
 
 	//url saving
-	var err error
-	if userID != -1 {
-		err = storage.SaveWithUserID(ctx, userID, entities.URL{
-			ShortURL:    urlShort,
-			OriginalURL: string(URL),
-		})
-	} else {
-		err = storage.Save(ctx, entities.URL{
-			ShortURL:    urlShort,
-			OriginalURL: string(URL),
-		})
-	}
+	err = withFreeShorts(urls, func() error {
+		if userID != -1 {
+			return storage.SaveWithUserID(ctx, userID, urls[0])
+		}
+		return storage.Save(ctx, urls[0])
+	})
 	if err != nil {
 		return "", fmt.Errorf("error while saving URL into a storage: %w", err)
 	}
 
 	//return
-	return baseAddress + "/" + urlShort, nil
+	return baseAddress + "/" + urls[0].ShortURL, nil
 }
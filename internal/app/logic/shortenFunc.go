@@ -1,6 +1,14 @@
 package logic
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+)
 
 const defaultShortURLLen = 16
 
@@ -18,3 +26,45 @@ func ShortenURL(url []byte) []byte {
 	}
 	return sum[:defaultShortURLLen]
 }
+
+// maxShortURLAttempts is an amount of short URLs witch are tried for one original URL.
+const maxShortURLAttempts = 5
+
+// shortURLFor returns a short URL of an original URL for an attempt (starting from 0).
+// The first attempt gives ShortenURL of an original URL, next attempts give other short URLs.
+func shortURLFor(original string, attempt int) string {
+	if attempt == 0 {
+		return string(ShortenURL([]byte(original)))
+	}
+	return string(ShortenURL([]byte(original + "#" + strconv.Itoa(attempt))))
+}
+
+// withFreeShorts calls save until it saves URLs. If save returns a databases.ShortURLTakenError (a short URL is used
+// by another original URL, because an original URL of a short URL was changed), URLs with a taken short URL
+// get a next one (see shortURLFor) and save is called again. Short URLs of URLs have to be built by shortURLFor.
+func withFreeShorts(urls []entities.URL, save func() error) error {
+	attempts := make([]int, len(urls))
+	for {
+		err := save()
+		takenErr := &databases.ShortURLTakenError{}
+		if !errors.As(err, &takenErr) {
+			return err
+		}
+
+		changed := false
+		for i := range urls {
+			if urls[i].ShortURL != takenErr.ShortURL {
+				continue
+			}
+			attempts[i]++
+			if attempts[i] == maxShortURLAttempts {
+				return fmt.Errorf("no free short URL for `%s`: %w", urls[i].OriginalURL, err)
+			}
+			urls[i].ShortURL = shortURLFor(urls[i].OriginalURL, attempts[i])
+			changed = true
+		}
+		if !changed {
+			return err
+		}
+	}
+}
@@ -3,26 +3,33 @@ package logic
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 )
 
 // ShortenBatch saves a batch of URLs to a storage.
 // Returns a slice of URLs with an original URL and a short version (with a base address).
+// Can return a wrapped ErrBadURLParams if optional params of any URL are not correct (nothing will be saved in this case).
 // Use "userID = -1" to save URLs without a userID.
 func ShortenBatch(ctx context.Context, URLs []entities.URL, baseAddress string, storage URLStorageInterface, userID int) ([]entities.URL, error) {
 	//shorting
+	now := time.Now()
 	for i, url := range URLs {
-		URLs[i].ShortURL = string(ShortenURL([]byte(url.OriginalURL)))
-		URLs[i].OriginalURL = ""
+		err := prepareURL(&URLs[i], now)
+		if err != nil {
+			return nil, fmt.Errorf("url with correlation id `%s`: %w", url.CorrelationID, err)
+		}
+		URLs[i].ShortURL = shortURLFor(url.OriginalURL, 0)
 	}
 
 	//url saving
-	var err error
-	if userID != -1 {
-		err = storage.SaveBatchWithUserID(ctx, userID, URLs)
-	} else {
-		err = storage.SaveBatch(ctx, URLs)
-	}
+	err := withFreeShorts(URLs, func() error {
+		if userID != -1 {
+			return storage.SaveBatchWithUserID(ctx, userID, URLs)
+		}
+		return storage.SaveBatch(ctx, URLs)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error while saving URLs to a storage: %w", err)
 	}
@@ -30,6 +37,7 @@ func ShortenBatch(ctx context.Context, URLs []entities.URL, baseAddress string,
 	//adding base address to return
 	for i := range URLs {
 		URLs[i].ShortURL = baseAddress + "/" + URLs[i].ShortURL
+		URLs[i].OriginalURL = ""
 	}
 	return URLs, nil
 }
@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+)
+
+// BulkSource gives URLs of a bulk shortening request one by one.
+type BulkSource interface {
+	// Next returns the next URL and its line number. Returns io.EOF after the last URL.
+	// A wrapped ErrBadURLParams means that only this line is broken, other errors stop shortening.
+	Next() (url entities.URL, line int, err error)
+}
+
+// bulkLine is a URL of a chunk witch waits for saving. Index is an index of its result in a chunk.
+type bulkLine struct {
+	url   entities.URL
+	index int
+}
+
+// ShortenBulk reads URLs from a source, shortens them and saves them to a storage by chunks of chunkSize lines,
+// so a memory usage doesn`t depend on a request size. Results of every chunk are given to write in a lines order.
+// Broken lines and lines with bad params get results with an error, other lines of a chunk are saved anyway.
+// If a chunk can`t be saved at once, its URLs are saved one by one, so every line gets its own error.
+// Returns an error if a source or write returns an error witch is not a line error.
+// Use "userID = -1" to save URLs without a userID.
+func ShortenBulk(ctx context.Context, source BulkSource, baseAddress string, storage URLStorageInterface, userID int,
+	chunkSize int, write func(results []entities.BulkResult) error) error {
+	results := make([]entities.BulkResult, 0, chunkSize)
+	lines := make([]bulkLine, 0, chunkSize)
+	flush := func() error {
+		err := saveBulkChunk(ctx, storage, userID, baseAddress, lines, results)
+		if err != nil {
+			return err
+		}
+		err = write(results)
+		if err != nil {
+			return fmt.Errorf("error while writing results: %w", err)
+		}
+		results = results[:0]
+		lines = lines[:0]
+		return nil
+	}
+
+	for {
+		url, line, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, ErrBadURLParams()) {
+			return fmt.Errorf("error while reading line %d: %w", line, err)
+		}
+
+		result := entities.BulkResult{Line: line, CorrelationID: url.CorrelationID}
+		if err == nil && url.OriginalURL == "" {
+			err = fmt.Errorf("%w: original URL is required", ErrBadURLParams())
+		}
+		if err == nil {
+			err = prepareURL(&url, time.Now())
+		}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			url.ShortURL = shortURLFor(url.OriginalURL, 0)
+			lines = append(lines, bulkLine{url: url, index: len(results)})
+		}
+		results = append(results, result)
+
+		if len(results) == chunkSize {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return flush()
+}
+
+// saveBulkChunk saves URLs of a chunk and fills their results.
+// Returns an error only if a context is done.
+func saveBulkChunk(ctx context.Context, storage URLStorageInterface, userID int, baseAddress string, lines []bulkLine, results []entities.BulkResult) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	URLs := make([]entities.URL, len(lines))
+	for i, line := range lines {
+		URLs[i] = line.url
+	}
+
+	//the whole chunk
+	err := withFreeShorts(URLs, func() error {
+		if userID != -1 {
+			return storage.SaveBatchWithUserID(ctx, userID, URLs)
+		}
+		return storage.SaveBatch(ctx, URLs)
+	})
+	if err == nil {
+		for i, line := range lines {
+			results[line.index].ShortURL = baseAddress + "/" + URLs[i].ShortURL
+		}
+		return nil
+	}
+
+	//one by one
+	for _, line := range lines {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		url := []entities.URL{line.url}
+		err = withFreeShorts(url, func() error {
+			if userID != -1 {
+				return storage.SaveWithUserID(ctx, userID, url[0])
+			}
+			return storage.Save(ctx, url[0])
+		})
+		alrExistsErr := &databases.AlreadyExistsError{}
+		switch {
+		case errors.As(err, &alrExistsErr):
+			results[line.index].ShortURL = baseAddress + "/" + alrExistsErr.ShortURL
+			results[line.index].Error = "URL is already shortened"
+		case err != nil:
+			results[line.index].Error = "can`t save URL"
+		default:
+			results[line.index].ShortURL = baseAddress + "/" + url[0].ShortURL
+		}
+	}
+	return nil
+}
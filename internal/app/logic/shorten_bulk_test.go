@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource is a BulkSource witch returns URLs from a slice. Nil URL means a broken line.
+type sliceSource struct {
+	urls []*entities.URL
+	line int
+}
+
+func (s *sliceSource) Next() (entities.URL, int, error) {
+	if s.line == len(s.urls) {
+		return entities.URL{}, s.line, io.EOF
+	}
+	s.line++
+	url := s.urls[s.line-1]
+	if url == nil {
+		return entities.URL{}, s.line, ErrBadURLParams()
+	}
+	return *url, s.line, nil
+}
+
+// failingBatchStorage is a storage witch can`t save batches, so URLs are saved one by one.
+type failingBatchStorage struct {
+	*databases.JustAMap
+	batches int
+}
+
+func (f *failingBatchStorage) SaveBatch(ctx context.Context, urls []entities.URL) error {
+	f.batches++
+	return errors.New("batch error")
+}
+
+func TestShortenBulk(t *testing.T) {
+	urls := []*entities.URL{
+		{CorrelationID: "1", OriginalURL: "https://example.com/1"},
+		nil,
+		{CorrelationID: "3", OriginalURL: "https://example.com/3", MaxClicks: -1},
+		{CorrelationID: "4"},
+		{CorrelationID: "5", OriginalURL: "https://example.com/5"},
+	}
+
+	t.Run("chunks", func(t *testing.T) {
+		storage := databases.NewJustAMap()
+		chunks := make([][]entities.BulkResult, 0)
+		err := ShortenBulk(context.Background(), &sliceSource{urls: urls}, "http://localhost", storage, -1, 2,
+			func(results []entities.BulkResult) error {
+				chunks = append(chunks, append([]entities.BulkResult(nil), results...))
+				return nil
+			})
+		require.NoError(t, err)
+		require.Len(t, chunks, 3)
+
+		results := make([]entities.BulkResult, 0)
+		for _, chunk := range chunks {
+			results = append(results, chunk...)
+		}
+		require.Len(t, results, len(urls))
+		for i, result := range results {
+			assert.Equal(t, i+1, result.Line)
+		}
+		assert.Equal(t, "http://localhost/"+string(ShortenURL([]byte("https://example.com/1"))), results[0].ShortURL)
+		assert.Empty(t, results[0].Error)
+		assert.NotEmpty(t, results[1].Error, "broken line")
+		assert.NotEmpty(t, results[2].Error, "bad params")
+		assert.NotEmpty(t, results[3].Error, "no original URL")
+		assert.Empty(t, results[4].Error)
+
+		count, err := storage.GetShortURLCount(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("one by one saving", func(t *testing.T) {
+		storage := &failingBatchStorage{JustAMap: databases.NewJustAMap()}
+		results := make([]entities.BulkResult, 0)
+		err := ShortenBulk(context.Background(), &sliceSource{urls: urls}, "http://localhost", storage, -1, 10,
+			func(chunk []entities.BulkResult) error {
+				results = append(results, chunk...)
+				return nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, 1, storage.batches)
+		require.Len(t, results, len(urls))
+		assert.NotEmpty(t, results[0].ShortURL)
+		assert.NotEmpty(t, results[4].ShortURL)
+
+		count, err := storage.GetShortURLCount(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("write error stops shortening", func(t *testing.T) {
+		err := ShortenBulk(context.Background(), &sliceSource{urls: urls}, "http://localhost", databases.NewJustAMap(), -1, 1,
+			func(results []entities.BulkResult) error {
+				return errors.New("client is gone")
+			})
+		assert.Error(t, err)
+	})
+}
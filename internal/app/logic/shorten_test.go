@@ -0,0 +1,63 @@
+package logic
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShorten_AfterEdit(t *testing.T) {
+	storages := map[string]func(t *testing.T) URLStorageInterface{
+		"map": func(t *testing.T) URLStorageInterface {
+			return databases.NewJustAMap()
+		},
+		"JSON": func(t *testing.T) URLStorageInterface {
+			return databases.NewJSONFileStorage(filepath.Join(t.TempDir(), "urls.json"))
+		},
+	}
+	baseAddress := "http://localhost"
+	typo := "https://typo.example/"
+	fixed := "https://fixed.example/"
+	owner, otherUser := 1, 2
+
+	for name, newStorage := range storages {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := newStorage(t)
+
+			//an owner fixes his link
+			editedShort, err := Shorten(ctx, entities.URL{OriginalURL: typo}, baseAddress, storage, owner)
+			require.NoError(t, err)
+			editedShort = strings.TrimPrefix(editedShort, baseAddress+"/")
+			err = UpdateURL(ctx, storage, owner, editedShort, entities.URLUpdate{OriginalURL: &fixed})
+			require.NoError(t, err)
+
+			//other user shortens the old original URL
+			batch, err := ShortenBatch(ctx, []entities.URL{{OriginalURL: typo}}, baseAddress, storage, otherUser)
+			require.NoError(t, err)
+			newShort := strings.TrimPrefix(batch[0].ShortURL, baseAddress+"/")
+			assert.NotEqual(t, editedShort, newShort)
+
+			_, err = Shorten(ctx, entities.URL{OriginalURL: typo}, baseAddress, storage, otherUser)
+			alrExistsErr := &databases.AlreadyExistsError{}
+			require.ErrorAs(t, err, &alrExistsErr)
+			assert.Equal(t, newShort, alrExistsErr.ShortURL)
+
+			edited, err := storage.GetURL(ctx, editedShort)
+			require.NoError(t, err)
+			assert.Equal(t, fixed, edited.OriginalURL)
+			assert.Equal(t, owner, edited.UserID)
+
+			created, err := storage.GetURL(ctx, newShort)
+			require.NoError(t, err)
+			assert.Equal(t, typo, created.OriginalURL)
+			assert.Equal(t, otherUser, created.UserID)
+		})
+	}
+}
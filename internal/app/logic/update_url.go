@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// UpdateURL changes an original URL, a title, notes, tags, redirect rules or variants of user`s short URL.
+// A short URL stays the same.
+// Only not nil fields of an update are changed, at least one of them is required.
+// Can return a wrapped ErrBadURLParams (if an update is not valid), a wrapped databases.ErrURLNotFound
+// (if user has no such URL) and a wrapped databases.AlreadyExistsError (if a new original URL is already shortened).
+// There is no redirects cache now, so the next redirect will use a new URL immediately.
+func UpdateURL(ctx context.Context, storage URLStorageInterface, userID int, shortURL string, update entities.URLUpdate) error {
+	if update.OriginalURL == nil && update.Title == nil && update.Notes == nil && update.Tags == nil && update.Rules == nil &&
+		update.Variants == nil && update.StickyVariants == nil {
+		return fmt.Errorf("%w: nothing to update", ErrBadURLParams())
+	}
+
+	//validation
+	if update.OriginalURL != nil {
+		err := checkDestination(*update.OriginalURL)
+		if err != nil {
+			return err
+		}
+	}
+	title, notes := "", ""
+	if update.Title != nil {
+		title = strings.TrimSpace(*update.Title)
+		update.Title = &title
+	}
+	if update.Notes != nil {
+		notes = *update.Notes
+	}
+	err := checkTitleAndNotes(title, notes)
+	if err != nil {
+		return err
+	}
+	if update.Tags != nil {
+		tags, err := normalizeTags(*update.Tags)
+		if err != nil {
+			return err
+		}
+		update.Tags = &tags
+	}
+	if update.Rules != nil {
+		rules, err := prepareRedirectRules(*update.Rules)
+		if err != nil {
+			return err
+		}
+		update.Rules = &rules
+	}
+	if update.Variants != nil {
+		variants, err := prepareVariants(*update.Variants)
+		if err != nil {
+			return err
+		}
+		update.Variants = &variants
+	}
+
+	err = storage.UpdateURL(ctx, userID, shortURL, update)
+	if err != nil {
+		return fmt.Errorf("error while updating url in a storage: %w", err)
+	}
+	return nil
+}
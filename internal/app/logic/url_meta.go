@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// Limits of user`s data of URLs.
+const (
+	MaxTitleLength = 255
+	MaxNotesLength = 2000
+	MaxTags        = 20
+	MaxTagLength   = 50
+)
+
+// normalizeTag trims and lowercases a tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags normalizes tags and removes empty tags and duplicates.
+// Returns a wrapped ErrBadURLParams if tags are not correct.
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{})
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("%w: tag `%s` is longer than %d symbols", ErrBadURLParams(), tag, MaxTagLength)
+		}
+		if strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("%w: tag `%s` contains a comma", ErrBadURLParams(), tag)
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("%w: URL can have only %d tags", ErrBadURLParams(), MaxTags)
+	}
+	return normalized, nil
+}
+
+// checkTitleAndNotes returns a wrapped ErrBadURLParams if a title or notes are too long.
+func checkTitleAndNotes(title string, notes string) error {
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return fmt.Errorf("%w: title is longer than %d symbols", ErrBadURLParams(), MaxTitleLength)
+	}
+	if utf8.RuneCountInString(notes) > MaxNotesLength {
+		return fmt.Errorf("%w: notes are longer than %d symbols", ErrBadURLParams(), MaxNotesLength)
+	}
+	return nil
+}
+
+// prepareURLMeta checks and normalizes a title, notes and tags of a URL.
+func prepareURLMeta(url *entities.URL) error {
+	url.Title = strings.TrimSpace(url.Title)
+	err := checkTitleAndNotes(url.Title, url.Notes)
+	if err != nil {
+		return err
+	}
+	url.Tags, err = normalizeTags(url.Tags)
+	return err
+}
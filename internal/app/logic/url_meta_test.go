@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareURLMeta(t *testing.T) {
+	manyTags := make([]string, MaxTags+1)
+	for i := range manyTags {
+		manyTags[i] = strings.Repeat("t", i+1)
+	}
+
+	tests := []struct {
+		name      string
+		url       entities.URL
+		wantTitle string
+		wantTags  []string
+		wantErr   bool
+	}{
+		{
+			name: "no meta",
+			url:  entities.URL{},
+		},
+		{
+			name:      "normalized",
+			url:       entities.URL{Title: "  Title ", Tags: []string{" Work", "work", "", "home"}},
+			wantTitle: "Title",
+			wantTags:  []string{"work", "home"},
+		},
+		{
+			name:    "long title",
+			url:     entities.URL{Title: strings.Repeat("a", MaxTitleLength+1)},
+			wantErr: true,
+		},
+		{
+			name:    "long notes",
+			url:     entities.URL{Notes: strings.Repeat("a", MaxNotesLength+1)},
+			wantErr: true,
+		},
+		{
+			name:    "long tag",
+			url:     entities.URL{Tags: []string{strings.Repeat("a", MaxTagLength+1)}},
+			wantErr: true,
+		},
+		{
+			name:    "comma in a tag",
+			url:     entities.URL{Tags: []string{"a,b"}},
+			wantErr: true,
+		},
+		{
+			name:    "too many tags",
+			url:     entities.URL{Tags: manyTags},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := tt.url
+			err := prepareURLMeta(&url)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrBadURLParams()))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTitle, url.Title)
+			assert.Equal(t, tt.wantTags, url.Tags)
+		})
+	}
+}
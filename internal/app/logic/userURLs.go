@@ -2,18 +2,111 @@ package logic
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
 )
 
-// GetUsersURLs return all user`s URLs with full and short versions.
-// Short version includes the base address.
-func GetUsersURLs(ctx context.Context, storage URLStorageInterface, baseAddress string, userID int) ([]entities.URL, error) {
-	usersURLs, err := storage.GetUserUrls(ctx, userID)
+// Page size limits of user`s URLs listings.
+const (
+	DefaultURLsPageLimit = 100
+	MaxURLsPageLimit     = 1000
+)
+
+// UserURLsPage is a page of user`s URLs. NextCursor is empty if it is the last page.
+type UserURLsPage struct {
+	URLs       []entities.URL
+	NextCursor string
+}
+
+// GetUsersURLsPage returns one page of user`s URLs with full and short versions.
+// Short version includes the base address. Expired URLs are marked with IsExpired flag.
+// Cursor is a NextCursor of a previous page (empty for the first page), query.After is ignored.
+// query.Limit = 0 means DefaultURLsPageLimit, query.SortBy = "" means entities.SortByCreated.
+// Can return a wrapped ErrBadURLParams if query or cursor is not valid.
+func GetUsersURLsPage(ctx context.Context, storage URLStorageInterface, baseAddress string, userID int, cursor string, query entities.URLsPageQuery) (UserURLsPage, error) {
+	//query validation
+	if query.Limit == 0 {
+		query.Limit = DefaultURLsPageLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxURLsPageLimit {
+		return UserURLsPage{}, fmt.Errorf("%w: limit have to be from 1 to %d", ErrBadURLParams(), MaxURLsPageLimit)
+	}
+	if query.SortBy == "" {
+		query.SortBy = entities.SortByCreated
+	}
+	if query.SortBy != entities.SortByCreated && query.SortBy != entities.SortByShort {
+		return UserURLsPage{}, fmt.Errorf("%w: unknown sorting field `%s`", ErrBadURLParams(), query.SortBy)
+	}
+	query.Tag = normalizeTag(query.Tag)
+	query.After = nil
+	if cursor != "" {
+		after, err := decodeURLsCursor(cursor)
+		if err != nil {
+			return UserURLsPage{}, fmt.Errorf("%w: cursor is not valid", ErrBadURLParams())
+		}
+		query.After = &after
+	}
+
+	//one more URL shows that there is a next page
+	limit := query.Limit
+	query.Limit++
+	usersURLs, err := storage.GetUserUrls(ctx, userID, query)
+	if err != nil {
+		return UserURLsPage{}, err
+	}
+	page := UserURLsPage{URLs: usersURLs}
+	if len(usersURLs) > limit {
+		page.URLs = usersURLs[:limit]
+		page.NextCursor = encodeURLsCursor(page.URLs[limit-1])
+	}
+
+	now := time.Now()
+	for i := range page.URLs {
+		page.URLs[i].ShortURL = baseAddress + "/" + page.URLs[i].ShortURL
+		page.URLs[i].IsExpired = page.URLs[i].IsExpiredAt(now)
+		page.URLs[i].HasPassword = page.URLs[i].PasswordHash != ""
+	}
+	return page, nil
+}
+
+// encodeURLsCursor returns a cursor of a page witch starts after a URL.
+// A cursor keeps a creation time and a short URL, so it stays valid after a URL is deleted.
+func encodeURLsCursor(url entities.URL) string {
+	createdAt := time.Time{}
+	if url.CreatedAt != nil {
+		createdAt = *url.CreatedAt
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + " " + url.ShortURL))
+}
+
+// decodeURLsCursor reads a position from a cursor built by encodeURLsCursor.
+func decodeURLsCursor(cursor string) (entities.URLsCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return entities.URLsCursor{}, err
+	}
+	createdAt, short, found := strings.Cut(string(decoded), " ")
+	if !found {
+		return entities.URLsCursor{}, fmt.Errorf("no short URL in a cursor")
+	}
+	after := entities.URLsCursor{ShortURL: short}
+	after.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
 	if err != nil {
-		return nil, err
+		return entities.URLsCursor{}, err
 	}
-	for i := range usersURLs {
-		usersURLs[i].ShortURL = baseAddress + "/" + usersURLs[i].ShortURL
+	return after, nil
+}
+
+// SearchUsersURLs returns one page of user`s URLs filtered by a tag, a title or an original URL substring.
+// At least one of query.Tag, query.TitleQuery or query.OriginalContains is required.
+// Works like GetUsersURLsPage in other cases.
+func SearchUsersURLs(ctx context.Context, storage URLStorageInterface, baseAddress string, userID int, cursor string, query entities.URLsPageQuery) (UserURLsPage, error) {
+	if normalizeTag(query.Tag) == "" && len(entities.Words(query.TitleQuery)) == 0 && query.OriginalContains == "" {
+		return UserURLsPage{}, fmt.Errorf("%w: tag, title query or original URL substring is required", ErrBadURLParams())
 	}
-	return usersURLs, nil
+	return GetUsersURLsPage(ctx, storage, baseAddress, userID, cursor, query)
 }
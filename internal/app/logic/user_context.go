@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+type contextKey string
+
+// Context keys of an authenticated user. They are shared by HTTP middlewares and gRPC interceptors.
+const (
+	UserIDContextKey  contextKey = "userID"
+	newUserContextKey contextKey = "newUser"
+	apiKeyContextKey  contextKey = "apiKey"
+	sessionContextKey contextKey = "session"
+)
+
+// NoUserID is a userID of anonymous requests.
+const NoUserID = -1
+
+// ContextWithUserID returns a context with an ID of a user witch sent a request.
+func ContextWithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, UserIDContextKey, userID)
+}
+
+// ContextWithNewUser returns a context with an ID of a user witch was created for this request.
+// Such user has no URLs yet, so endpoints for existing users can answer him with 401 (see IsNewUser).
+func ContextWithNewUser(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ContextWithUserID(ctx, userID), newUserContextKey, true)
+}
+
+// UserIDFromContext returns an ID of a user witch sent a request. Returns false for anonymous requests.
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(UserIDContextKey).(int)
+	if !ok || userID == NoUserID {
+		return NoUserID, false
+	}
+	return userID, true
+}
+
+// ContextWithSession returns a context with a user witch sent a request and an ID of his session.
+func ContextWithSession(ctx context.Context, userID int, sessionID string) context.Context {
+	return context.WithValue(ContextWithUserID(ctx, userID), sessionContextKey, sessionID)
+}
+
+// SessionIDFromContext returns an ID of a session of a request. Returns false if a request has no session
+// (it was authenticated by an API key or it is anonymous).
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(sessionContextKey).(string)
+	return sessionID, ok && sessionID != ""
+}
+
+// IsNewUser returns true if a user of a request was created for this request (he had no credentials).
+func IsNewUser(ctx context.Context) bool {
+	isNew, _ := ctx.Value(newUserContextKey).(bool)
+	return isNew
+}
+
+// AnonymousUserID returns a user of a request whose URLs can be claimed by an account (see Accounts.SignUp).
+// Returns NoUserID if there is no user, if he was created for this request or if a request uses an API key
+// (a key gives access to URLs, but it can`t give them away).
+func AnonymousUserID(ctx context.Context) int {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok || IsNewUser(ctx) {
+		return NoUserID
+	}
+	if _, isKey := APIKeyFromContext(ctx); isKey {
+		return NoUserID
+	}
+	return userID
+}
+
+// ContextWithAPIKey returns a context with a user of a request authenticated by an API key.
+func ContextWithAPIKey(ctx context.Context, key entities.APIKey) context.Context {
+	return context.WithValue(ContextWithUserID(ctx, key.UserID), apiKeyContextKey, key)
+}
+
+// APIKeyFromContext returns an API key of a request. Returns false if a request was not authenticated by an API key.
+func APIKeyFromContext(ctx context.Context) (entities.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey).(entities.APIKey)
+	return key, ok
+}
+
+// HasScope returns true if a request is allowed to use a scope.
+// Only requests authenticated by API keys can be limited by scopes (a key without scopes has all of them).
+func HasScope(ctx context.Context, scope string) bool {
+	key, ok := APIKeyFromContext(ctx)
+	if !ok || len(key.Scopes) == 0 {
+		return true
+	}
+	return slices.Contains(key.Scopes, scope)
+}
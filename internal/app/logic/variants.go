@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// Limits of A/B variants of a URL.
+const (
+	MaxVariants          = 10
+	MaxVariantNameLength = 50
+	MaxVariantWeight     = 10000
+)
+
+// prepareVariants trims names of variants and checks variants.
+// Names have to be unique and can contain only latin letters, digits, "-" and "_" (they are saved in cookies).
+// Returns a wrapped ErrBadURLParams if variants are not correct.
+func prepareVariants(variants []entities.Variant) ([]entities.Variant, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	if len(variants) > MaxVariants {
+		return nil, fmt.Errorf("%w: URL can have only %d variants", ErrBadURLParams(), MaxVariants)
+	}
+
+	prepared := make([]entities.Variant, len(variants))
+	names := make(map[string]struct{})
+	totalWeight := 0
+	for i, variant := range variants {
+		variant.Name = strings.TrimSpace(variant.Name)
+		if !isVariantName(variant.Name) {
+			return nil, fmt.Errorf("%w: `%s` is not a valid variant name", ErrBadURLParams(), variant.Name)
+		}
+		if _, ok := names[variant.Name]; ok {
+			return nil, fmt.Errorf("%w: variant name `%s` is not unique", ErrBadURLParams(), variant.Name)
+		}
+		names[variant.Name] = struct{}{}
+		if variant.Weight < 0 || variant.Weight > MaxVariantWeight {
+			return nil, fmt.Errorf("%w: weight of variant `%s` has to be from 0 to %d", ErrBadURLParams(), variant.Name, MaxVariantWeight)
+		}
+		totalWeight += variant.Weight
+		err := checkDestination(variant.Destination)
+		if err != nil {
+			return nil, err
+		}
+		prepared[i] = variant
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("%w: at least one variant has to have a positive weight", ErrBadURLParams())
+	}
+	return prepared, nil
+}
+
+// isVariantName returns true if a name is not empty and contains only latin letters, digits, "-" and "_".
+func isVariantName(name string) bool {
+	if name == "" || len(name) > MaxVariantNameLength {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// pickVariant returns a variant for a visitor. A variant named `assigned` is returned if it exists and is enabled
+// (it is a sticky assignment), otherwise a variant is chosen randomly by weights.
+// Returns false if URL has no variants.
+func pickVariant(variants []entities.Variant, assigned string) (entities.Variant, bool) {
+	totalWeight := 0
+	for _, variant := range variants {
+		if assigned != "" && variant.Name == assigned && variant.Weight > 0 {
+			return variant, true
+		}
+		totalWeight += variant.Weight
+	}
+	if totalWeight == 0 {
+		return entities.Variant{}, false
+	}
+
+	point := rand.IntN(totalWeight)
+	for _, variant := range variants {
+		if point < variant.Weight {
+			return variant, true
+		}
+		point -= variant.Weight
+	}
+	return entities.Variant{}, false
+}
@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []entities.Variant
+		wantErr  bool
+	}{
+		{
+			name: "ok",
+			variants: []entities.Variant{
+				{Name: " a ", Destination: "https://a.example.com/", Weight: 70},
+				{Name: "b_2", Destination: "https://b.example.com/", Weight: 30},
+				{Name: "off", Destination: "https://c.example.com/", Weight: 0},
+			},
+		},
+		{
+			name: "bad name",
+			variants: []entities.Variant{
+				{Name: "a;b", Destination: "https://a.example.com/", Weight: 1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "same names",
+			variants: []entities.Variant{
+				{Name: "a", Destination: "https://a.example.com/", Weight: 1},
+				{Name: "a", Destination: "https://b.example.com/", Weight: 1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative weight",
+			variants: []entities.Variant{
+				{Name: "a", Destination: "https://a.example.com/", Weight: -1},
+				{Name: "b", Destination: "https://b.example.com/", Weight: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "all weights are zero",
+			variants: []entities.Variant{
+				{Name: "a", Destination: "https://a.example.com/"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad destination",
+			variants: []entities.Variant{
+				{Name: "a", Destination: "/relative", Weight: 1},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prepared, err := prepareVariants(tt.variants)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrBadURLParams()), "ErrBadURLParams expected, got: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, prepared, len(tt.variants))
+			assert.Equal(t, "a", prepared[0].Name, "name has to be trimmed")
+		})
+	}
+}
+
+func TestPickVariant(t *testing.T) {
+	variants := []entities.Variant{
+		{Name: "a", Destination: "https://a.example.com/", Weight: 1},
+		{Name: "b", Destination: "https://b.example.com/", Weight: 3},
+		{Name: "off", Destination: "https://c.example.com/", Weight: 0},
+	}
+
+	t.Run("no variants", func(t *testing.T) {
+		_, ok := pickVariant(nil, "")
+		assert.False(t, ok)
+	})
+
+	t.Run("sticky assignment", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			variant, ok := pickVariant(variants, "a")
+			require.True(t, ok)
+			assert.Equal(t, "a", variant.Name)
+		}
+	})
+
+	t.Run("disabled or unknown assignment is ignored", func(t *testing.T) {
+		for _, assigned := range []string{"off", "unknown"} {
+			variant, ok := pickVariant(variants, assigned)
+			require.True(t, ok)
+			assert.NotEqual(t, assigned, variant.Name)
+		}
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		picked := make(map[string]int)
+		for i := 0; i < 4000; i++ {
+			variant, ok := pickVariant(variants, "")
+			require.True(t, ok)
+			picked[variant.Name]++
+		}
+		assert.Zero(t, picked["off"], "variant with zero weight can`t be picked")
+		assert.InDelta(t, 1000, picked["a"], 200)
+		assert.InDelta(t, 3000, picked["b"], 200)
+	})
+}
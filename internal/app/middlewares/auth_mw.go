@@ -3,9 +3,13 @@ package middlewares
 
 import (
 	"context"
+	"errors"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
 )
 
 // JWT params.
@@ -13,55 +17,169 @@ const (
 	JwtCookieName = "JWT"
 )
 
-type contextKey string
+// APIKeyHeader is a header with an API key. API keys can be sent in an "Authorization: Bearer <key>" header too.
+const APIKeyHeader = "X-API-Key"
 
-// UserIDContextKey is a key to get a userID from context values.
-const UserIDContextKey contextKey = "userID"
+// AuthTokenHeader is a response header with a new access token (JWT) of a user.
+// "Authorization" is a request header, so tokens are not sent back in it.
+const AuthTokenHeader = "X-Auth-Token"
 
-//go:generate mockgen -source=auth_mw.go -destination=mocks/mocks_AuthMW.go -package=mocks_MW github.com/Lesnoi3283/url_shortener/internal/app/middlewares UserCreater
+// UserIDContextKey is a key to get a userID from context values. Use logic.UserIDFromContext to read it.
+const UserIDContextKey = logic.UserIDContextKey
+
+//go:generate mockgen -source=auth_mw.go -destination=mocks/mocks_AuthMW.go -package=mocks_MW github.com/Lesnoi3283/url_shortener/internal/app/middlewares UserCreater,APIKeyChecker,SessionManager
 
 // UserCreater can create a new user.
 type UserCreater interface {
 	CreateUser(ctx context.Context) (int, error)
 }
 
-// AuthMW parses AuthJWT from cookie and puts UserID to http.Request.Context values.
-func AuthMW(store UserCreater, logger zap.SugaredLogger, jh *secure.JWTHelper) func(handlerFunc http.Handler) http.Handler {
+// APIKeyChecker can check API keys (logic.APIKeys is used in this project).
+type APIKeyChecker interface {
+	Check(ctx context.Context, key string) (entities.APIKey, error)
+}
+
+// SessionManager can start, check and refresh sessions of users (logic.Sessions is used in this project).
+type SessionManager interface {
+	Start(ctx context.Context, userID int) (logic.SessionTokens, error)
+	Authenticate(ctx context.Context, accessToken string) (logic.SessionAuth, error)
+	Refresh(ctx context.Context, refreshToken string) (logic.SessionTokens, error)
+}
+
+// AuthMW reads an API key (from an APIKeyHeader or an "Authorization: Bearer <key>" header) or a JWT
+// (from an "Authorization: Bearer <jwt>" header or from a cookie) and puts UserID
+// to http.Request.Context values (use logic.UserIDFromContext to get it, logic.APIKeyFromContext gives an API key
+// and logic.SessionIDFromContext gives a session).
+// A request with a not valid API key or bearer token gets 401, because API clients have to know that their token is bad.
+// A JWT close to expiry is reissued: in an AuthTokenHeader response header for bearer tokens and in a cookie for cookies.
+// An expired (or not valid) JWT cookie is replaced using a refresh token cookie, so users don`t lose their URLs.
+// A request without valid credentials is anonymous (logic.NoUserID). Users are created only by NewUserMW.
+func AuthMW(logger zap.SugaredLogger, sessions SessionManager, keys APIKeyChecker) func(handlerFunc http.Handler) http.Handler {
+	return authMW(logger, sessions, keys, true)
+}
+
+// PassiveAuthMW works like AuthMW, but never writes cookies or headers: JWTs are not reissued
+// and expired JWT cookies are not refreshed (such requests are anonymous).
+// It is used by public endpoints (like a URL preview), where visitors are usually crawlers and bots.
+func PassiveAuthMW(logger zap.SugaredLogger, sessions SessionManager, keys APIKeyChecker) func(handlerFunc http.Handler) http.Handler {
+	return authMW(logger, sessions, keys, false)
+}
+
+func authMW(logger zap.SugaredLogger, sessions SessionManager, keys APIKeyChecker, renew bool) func(handlerFunc http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, hasBearer := secure.BearerToken(r.Header.Get("Authorization"))
+			if key := r.Header.Get(APIKeyHeader); key != "" || (hasBearer && logic.IsAPIKey(token)) {
+				if key == "" {
+					key = token
+				}
+				apiKey, err := keys.Check(r.Context(), key)
+				if errors.Is(err, logic.ErrInvalidAPIKey()) {
+					logger.Debugf("Not valid API key: %v", err)
+					w.Header().Set("WWW-Authenticate", secure.BearerScheme)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				} else if err != nil {
+					logger.Errorf("err while checking API key in auth mw: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(logic.ContextWithAPIKey(r.Context(), apiKey)))
+				return
+			}
+
+			if hasBearer {
+				auth, err := sessions.Authenticate(r.Context(), token)
+				if errors.Is(err, logic.ErrInvalidSession()) {
+					logger.Debugf("Not valid bearer JWT: %v", err)
+					w.Header().Set("WWW-Authenticate", secure.BearerScheme)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				} else if err != nil {
+					logger.Errorf("err while checking bearer JWT in auth mw: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if auth.Reissued != nil && renew {
+					w.Header().Set(AuthTokenHeader, auth.Reissued.AccessToken)
+				}
+				next.ServeHTTP(w, r.WithContext(logic.ContextWithSession(r.Context(), auth.UserID, auth.SessionID)))
+				return
+			}
+
+			refreshCookie, _ := r.Cookie(RefreshCookieName)
 			cookie, err := r.Cookie(JwtCookieName)
 			if err == nil {
-				userID, err := jh.GetUserID(cookie.Value)
+				auth, err := sessions.Authenticate(r.Context(), cookie.Value)
 				if err == nil {
-					ctx := context.WithValue(r.Context(), UserIDContextKey, userID)
-					next.ServeHTTP(w, r.WithContext(ctx))
+					if auth.Reissued != nil && renew {
+						//a refresh token cookie is prolonged with a session
+						if refreshCookie != nil && strings.HasPrefix(refreshCookie.Value, auth.SessionID+".") {
+							auth.Reissued.RefreshToken = refreshCookie.Value
+						}
+						SetSessionCookies(w, r, *auth.Reissued)
+					}
+					next.ServeHTTP(w, r.WithContext(logic.ContextWithSession(r.Context(), auth.UserID, auth.SessionID)))
 					return
-				} else {
+				} else if errors.Is(err, logic.ErrInvalidSession()) {
 					logger.Debugf("Error while getting userID from JWT: %v", err)
+				} else {
+					logger.Errorf("err while checking JWT in auth mw: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
 				}
 			}
 
+			if refreshCookie != nil && renew {
+				tokens, err := sessions.Refresh(r.Context(), refreshCookie.Value)
+				if err == nil {
+					SetSessionCookies(w, r, tokens)
+					next.ServeHTTP(w, r.WithContext(logic.ContextWithSession(r.Context(), tokens.UserID, tokens.SessionID)))
+					return
+				} else if errors.Is(err, logic.ErrInvalidSession()) {
+					logger.Debugf("Not valid refresh token: %v", err)
+				} else {
+					logger.Errorf("err while refreshing a session in auth mw: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(logic.ContextWithUserID(r.Context(), logic.NoUserID)))
+		})
+	}
+}
+
+// NewUserMW creates a new anonymous user for a request without a user (it has to run after AuthMW),
+// so he can own URLs he shortens. His tokens are sent in cookies and in an AuthTokenHeader header.
+// Only endpoints witch save something for a user use it, so visitors of short URLs are not saved as users.
+func NewUserMW(store UserCreater, logger zap.SugaredLogger, sessions SessionManager) func(handlerFunc http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := logic.UserIDFromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			userID, err := store.CreateUser(r.Context())
 			if err != nil {
-				logger.Errorf("err while creating new user in auth mw: %v", err.Error())
+				logger.Errorf("err while creating new user in new user mw: %v", err.Error())
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			jwt, err := jh.BuildNewJWTString(userID)
+			tokens, err := sessions.Start(r.Context(), userID)
 			if err != nil {
-				logger.Errorf("err while building new jwt in auth mw: %v", err.Error())
+				logger.Errorf("err while starting a session in new user mw: %v", err.Error())
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			http.SetCookie(w, &http.Cookie{
-				Name:  JwtCookieName,
-				Value: jwt,
-			})
+			SetSessionCookies(w, r, tokens)
+			w.Header().Set(AuthTokenHeader, tokens.AccessToken)
 
-			ctx := context.WithValue(r.Context(), UserIDContextKey, userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			ctx := logic.ContextWithSession(r.Context(), userID, tokens.SessionID)
+			next.ServeHTTP(w, r.WithContext(logic.ContextWithNewUser(ctx, userID)))
 		})
 	}
 }
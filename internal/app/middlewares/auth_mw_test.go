@@ -1,10 +1,16 @@
 package middlewares
 
 import (
+	"context"
+	"github.com/Lesnoi3283/url_shortener/config"
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/Lesnoi3283/url_shortener/pkg/databases"
 	"github.com/Lesnoi3283/url_shortener/pkg/secure"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	mocks_MW "github.com/Lesnoi3283/url_shortener/internal/app/middlewares/mocks"
 	"github.com/golang/mock/gomock"
@@ -20,12 +26,13 @@ func TestAuthMW_WithValidJWT(t *testing.T) {
 	//prepare data
 	correctUserID := 1
 
-	correctJWTString, err := jh.BuildNewJWTString(correctUserID)
+	sessions := logic.NewSessions(databases.NewJustAMap(), jh, config.Config{})
+	tokens, err := sessions.Start(context.Background(), correctUserID)
 	require.NoError(t, err, "Err while preparing test")
+	correctJWTString := tokens.AccessToken
 
 	//prepare mocks
 	c := gomock.NewController(t)
-	store := mocks_MW.NewMockUserCreater(c)
 
 	//prepare logger
 	logger := zaptest.NewLogger(t)
@@ -50,7 +57,7 @@ func TestAuthMW_WithValidJWT(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	//test MW
-	mw := AuthMW(store, *sugar, jh)
+	mw := AuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))
 	mw(nextHandler).ServeHTTP(w, r)
 
 	//check result
@@ -58,14 +65,8 @@ func TestAuthMW_WithValidJWT(t *testing.T) {
 }
 
 func TestAuthMW_NoJWT(t *testing.T) {
-
-	//prepare data
-	correctUserID := 1
-
-	//prepare mocks
+	//prepare mocks (a user must not be created)
 	c := gomock.NewController(t)
-	store := mocks_MW.NewMockUserCreater(c)
-	store.EXPECT().CreateUser(gomock.Any()).Return(correctUserID, nil)
 
 	//prepare logger
 	logger := zaptest.NewLogger(t)
@@ -76,10 +77,9 @@ func TestAuthMW_NoJWT(t *testing.T) {
 
 	//prepare handler witch will check our MW
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value(UserIDContextKey)
-		userIDInt, ok := userID.(int)
-		assert.True(t, ok)
-		assert.Equal(t, userIDInt, correctUserID)
+		userID, ok := logic.UserIDFromContext(r.Context())
+		assert.False(t, ok)
+		assert.Equal(t, logic.NoUserID, userID)
 		w.WriteHeader(http.StatusOK)
 	})
 
@@ -88,11 +88,359 @@ func TestAuthMW_NoJWT(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	//test MW
-	mw := AuthMW(store, *sugar, jh)
+	mw := AuthMW(*sugar, logic.NewSessions(databases.NewJustAMap(), jh, config.Config{}), mocks_MW.NewMockAPIKeyChecker(c))
 	mw(nextHandler).ServeHTTP(w, r)
 
 	//check result
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies(), "anonymous request must not get cookies")
+	assert.Empty(t, w.Header().Get(AuthTokenHeader))
+}
+
+func TestAuthMW_Bearer(t *testing.T) {
+	//prepare JWTHelper
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+
+	//prepare data
+	correctUserID := 1
+	sessions := logic.NewSessions(databases.NewJustAMap(), jh, config.Config{})
+	tokens, err := sessions.Start(context.Background(), correctUserID)
+	require.NoError(t, err, "Err while preparing test")
+	correctJWTString := tokens.AccessToken
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+		wantNextCall  bool
+	}{
+		{
+			name:          "Valid token",
+			authorization: "Bearer " + correctJWTString,
+			wantCode:      http.StatusOK,
+			wantNextCall:  true,
+		},
+		{
+			name:          "Lowercase scheme",
+			authorization: "bearer " + correctJWTString,
+			wantCode:      http.StatusOK,
+			wantNextCall:  true,
+		},
+		{
+			name:          "Not valid token",
+			authorization: "Bearer not a valid token",
+			wantCode:      http.StatusUnauthorized,
+			wantNextCall:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//prepare mocks (a user must not be created)
+			c := gomock.NewController(t)
+
+			//prepare logger
+			logger := zaptest.NewLogger(t)
+			sugar := logger.Sugar()
+
+			//prepare handler witch will check our MW
+			nextCalled := false
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				userID, ok := logic.UserIDFromContext(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, correctUserID, userID)
+				assert.False(t, logic.IsNewUser(r.Context()))
+				w.WriteHeader(http.StatusOK)
+			})
+
+			//prepare request and recorder
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", tt.authorization)
+			w := httptest.NewRecorder()
+
+			//test MW
+			mw := AuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))
+			mw(nextHandler).ServeHTTP(w, r)
+
+			//check result
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantNextCall, nextCalled)
+			assert.Empty(t, w.Result().Cookies(), "cookie must not be set for a bearer token")
+		})
+	}
+}
+
+func TestNewUserMW(t *testing.T) {
+	//prepare data
+	correctUserID := 1
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare JWTHelper
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	sessions := logic.NewSessions(databases.NewJustAMap(), jh, config.Config{})
+
+	t.Run("anonymous request gets a new user", func(t *testing.T) {
+		c := gomock.NewController(t)
+		store := mocks_MW.NewMockUserCreater(c)
+		store.EXPECT().CreateUser(gomock.Any()).Return(correctUserID, nil)
+
+		nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := logic.UserIDFromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, correctUserID, userID)
+			assert.True(t, logic.IsNewUser(r.Context()))
+			w.WriteHeader(http.StatusOK)
+		})
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		w := httptest.NewRecorder()
+		mw := AuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))(NewUserMW(store, *sugar, sessions)(nextHandler))
+		mw.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		token := w.Header().Get(AuthTokenHeader)
+		require.NotEmpty(t, token, "a token header expected")
+		assert.Empty(t, w.Header().Get("Authorization"), "a token must not be sent in a request header")
+		userID, err := jh.GetUserID(token)
+		require.NoError(t, err)
+		assert.Equal(t, correctUserID, userID)
+
+		cookies := make(map[string]*http.Cookie)
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		assert.Contains(t, cookies, JwtCookieName)
+		assert.Contains(t, cookies, RefreshCookieName)
+	})
+
+	t.Run("existing user is not created again", func(t *testing.T) {
+		c := gomock.NewController(t)
+		store := mocks_MW.NewMockUserCreater(c)
+		tokens, err := sessions.Start(context.Background(), correctUserID)
+		require.NoError(t, err, "Err while preparing test")
+
+		nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := logic.UserIDFromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, correctUserID, userID)
+			assert.False(t, logic.IsNewUser(r.Context()))
+			w.WriteHeader(http.StatusOK)
+		})
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.AddCookie(&http.Cookie{Name: JwtCookieName, Value: tokens.AccessToken})
+		w := httptest.NewRecorder()
+		mw := AuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))(NewUserMW(store, *sugar, sessions)(nextHandler))
+		mw.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestPassiveAuthMW(t *testing.T) {
+	//prepare sessions
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	sessions := logic.NewSessions(databases.NewJustAMap(), jh, config.Config{})
+	correctUserID := 1
+	tokens, err := sessions.Start(context.Background(), correctUserID)
+	require.NoError(t, err, "Err while preparing test")
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	c := gomock.NewController(t)
+	var gotUserID int
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = logic.UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	mw := PassiveAuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))(nextHandler)
+
+	t.Run("valid JWT cookie", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: JwtCookieName, Value: tokens.AccessToken})
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, correctUserID, gotUserID)
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("expired JWT cookie is not refreshed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: JwtCookieName, Value: "expired token"})
+		r.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: tokens.RefreshToken})
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, logic.NoUserID, gotUserID)
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestAuthMW_APIKey(t *testing.T) {
+	//prepare data
+	correctUserID := 1
+	correctKey := logic.APIKeyPrefix + "correct"
+	apiKey := entities.APIKey{ID: "id", UserID: correctUserID, Scopes: []string{logic.ScopeRead}}
+
+	tests := []struct {
+		name         string
+		header       string
+		value        string
+		wantCode     int
+		wantNextCall bool
+	}{
+		{
+			name:         "X-API-Key header",
+			header:       APIKeyHeader,
+			value:        correctKey,
+			wantCode:     http.StatusOK,
+			wantNextCall: true,
+		},
+		{
+			name:         "Bearer key",
+			header:       "Authorization",
+			value:        "Bearer " + correctKey,
+			wantCode:     http.StatusOK,
+			wantNextCall: true,
+		},
+		{
+			name:         "Not valid key",
+			header:       APIKeyHeader,
+			value:        logic.APIKeyPrefix + "revoked",
+			wantCode:     http.StatusUnauthorized,
+			wantNextCall: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//prepare mocks (a user must not be created)
+			c := gomock.NewController(t)
+			keys := mocks_MW.NewMockAPIKeyChecker(c)
+			keys.EXPECT().Check(gomock.Any(), correctKey).Return(apiKey, nil).AnyTimes()
+			keys.EXPECT().Check(gomock.Any(), gomock.Not(correctKey)).Return(entities.APIKey{}, logic.ErrInvalidAPIKey()).AnyTimes()
+
+			//prepare logger
+			logger := zaptest.NewLogger(t)
+			sugar := logger.Sugar()
+
+			//prepare JWTHelper
+			jh := secure.NewJWTHelper("testSecretKey", 5)
+
+			//prepare handler witch will check our MW
+			nextCalled := false
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				userID, ok := logic.UserIDFromContext(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, correctUserID, userID)
+				key, ok := logic.APIKeyFromContext(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, apiKey, key)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			//prepare request and recorder
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+
+			//test MW
+			mw := AuthMW(*sugar, logic.NewSessions(databases.NewJustAMap(), jh, config.Config{}), keys)
+			mw(nextHandler).ServeHTTP(w, r)
+
+			//check result
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantNextCall, nextCalled)
+		})
+	}
+}
+
+func TestAuthMW_Sessions(t *testing.T) {
+	//prepare sessions
+	ctx := context.Background()
+	jh := secure.NewJWTHelper("testSecretKey", 5)
+	sessions := logic.NewSessions(databases.NewJustAMap(), jh, config.Config{})
+	correctUserID := 1
+	tokens, err := sessions.Start(ctx, correctUserID)
+	require.NoError(t, err, "Err while preparing test")
+
+	//prepare mocks (a user must not be created)
+	c := gomock.NewController(t)
+
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare handler witch will check our MW
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := logic.UserIDFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, correctUserID, userID)
+		sessionID, ok := logic.SessionIDFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, tokens.SessionID, sessionID)
+		w.WriteHeader(http.StatusOK)
+	})
+	mw := AuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))(nextHandler)
+
+	t.Run("expired JWT cookie is refreshed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: JwtCookieName, Value: "expired token"})
+		r.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: tokens.RefreshToken})
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookies := make(map[string]*http.Cookie)
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		require.Contains(t, cookies, JwtCookieName)
+		require.Contains(t, cookies, RefreshCookieName)
+		jwtCookie := cookies[JwtCookieName]
+		assert.True(t, jwtCookie.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, jwtCookie.SameSite)
+		assert.Equal(t, int(jh.TokenExp.Seconds()), jwtCookie.MaxAge)
+		userID, err := jh.GetUserID(jwtCookie.Value)
+		require.NoError(t, err)
+		assert.Equal(t, correctUserID, userID)
+	})
+
+	t.Run("JWT close to expiry is reissued", func(t *testing.T) {
+		shortJH := secure.NewJWTHelper("testSecretKey", 5)
+		shortJH.TokenExp = time.Minute
+		oldToken, err := shortJH.BuildSessionJWTString(correctUserID, tokens.SessionID)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+oldToken)
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		newToken := w.Header().Get(AuthTokenHeader)
+		require.NotEmpty(t, newToken, "a token header with a new token expected")
+		assert.NotEqual(t, oldToken, newToken)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		require.NoError(t, sessions.Revoke(ctx, tokens.SessionID))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
 }
 
 func BenchmarkAuthMW(b *testing.B) {
@@ -101,13 +449,13 @@ func BenchmarkAuthMW(b *testing.B) {
 
 	//prepare data
 	correctUserID := 1
-	correctJWTString, err := jh.BuildNewJWTString(correctUserID)
+	sessions := logic.NewSessions(databases.NewJustAMap(), jh, config.Config{})
+	tokens, err := sessions.Start(context.Background(), correctUserID)
 	require.NoError(b, err, "Err while preparing test")
+	correctJWTString := tokens.AccessToken
 
 	//prepare mocks
 	c := gomock.NewController(b)
-	store := mocks_MW.NewMockUserCreater(c)
-	store.EXPECT().CreateUser(gomock.Any()).Return(correctUserID, nil).AnyTimes()
 
 	//prepare logger
 	logger := zaptest.NewLogger(b)
@@ -119,7 +467,7 @@ func BenchmarkAuthMW(b *testing.B) {
 	})
 
 	//test MW
-	mw := AuthMW(store, *sugar, jh)
+	mw := AuthMW(*sugar, sessions, mocks_MW.NewMockAPIKeyChecker(c))
 	testable := mw(nextHandler)
 
 	b.Run("With JWT", func(b *testing.B) {
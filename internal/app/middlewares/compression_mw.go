@@ -1,7 +1,6 @@
 package middlewares
 
 import (
-	"bytes"
 	"compress/gzip"
 	"io"
 	"net/http"
@@ -12,7 +11,7 @@ import (
 // gzipWriter is a decorator to a http.ResponseWriter witch encodes data before writing it.
 type gzipWriter struct {
 	http.ResponseWriter
-	Writer io.Writer
+	Writer *gzip.Writer
 }
 
 // Write encodes a response and then writes it to a http.responseWriter.
@@ -20,7 +19,34 @@ func (w gzipWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush sends all encoded data to a client (it is used by streaming handlers).
+func (w gzipWriter) Flush() {
+	err := w.Writer.Flush()
+	if err != nil {
+		return
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns an original http.ResponseWriter (it is used by http.ResponseController).
+func (w gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// gzipReader is a request body witch is decompressed while it is read, so a big body is not kept in memory.
+type gzipReader struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Close closes a decompressor and an original body.
+func (r gzipReader) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
+
 // CompressionMW decodes a compressed request and encodes response if "Accept-Encoding" cookie is set.
+// A request body is decompressed while a handler reads it.
 func CompressionMW(logger zap.SugaredLogger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -34,18 +60,11 @@ func CompressionMW(logger zap.SugaredLogger) func(next http.Handler) http.Handle
 					return
 				}
 
-				//decompressing
-				decompressed, err := io.ReadAll(reader)
-				if err != nil {
-					logger.Error("Error while reading from gzip reader", zap.Error(err))
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				//logger.Debug(zap.Int("Decomressed data size", len(decompressed)))
-
 				// data replacement
-				r.Body.Close()
-				r.Body = io.NopCloser(bytes.NewReader(decompressed))
+				r.Body = gzipReader{
+					Reader: reader,
+					body:   r.Body,
+				}
 
 			} else if encoding != "" {
 				logger.Infof("Unsupported compression type `%s`", encoding)
@@ -37,6 +37,11 @@ func (l *loggingResponceWriter) Header() http.Header {
 	return l.rw.Header()
 }
 
+// Unwrap returns an original http.ResponseWriter (it is used by http.ResponseController).
+func (l *loggingResponceWriter) Unwrap() http.ResponseWriter {
+	return l.rw
+}
+
 // LoggerMW logs request`s params: URL, method, duration.
 // And response`s params: status code and size.
 func LoggerMW(logger zap.SugaredLogger) func(next http.Handler) http.Handler {
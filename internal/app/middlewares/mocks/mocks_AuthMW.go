@@ -8,6 +8,8 @@ import (
 	context "context"
 	reflect "reflect"
 
+	entities "github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	logic "github.com/Lesnoi3283/url_shortener/internal/app/logic"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -48,3 +50,109 @@ func (mr *MockUserCreaterMockRecorder) CreateUser(ctx interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserCreater)(nil).CreateUser), ctx)
 }
+
+// MockAPIKeyChecker is a mock of APIKeyChecker interface.
+type MockAPIKeyChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyCheckerMockRecorder
+}
+
+// MockAPIKeyCheckerMockRecorder is the mock recorder for MockAPIKeyChecker.
+type MockAPIKeyCheckerMockRecorder struct {
+	mock *MockAPIKeyChecker
+}
+
+// NewMockAPIKeyChecker creates a new mock instance.
+func NewMockAPIKeyChecker(ctrl *gomock.Controller) *MockAPIKeyChecker {
+	mock := &MockAPIKeyChecker{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyChecker) EXPECT() *MockAPIKeyCheckerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockAPIKeyChecker) Check(ctx context.Context, key string) (entities.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, key)
+	ret0, _ := ret[0].(entities.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockAPIKeyCheckerMockRecorder) Check(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockAPIKeyChecker)(nil).Check), ctx, key)
+}
+
+// MockSessionManager is a mock of SessionManager interface.
+type MockSessionManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionManagerMockRecorder
+}
+
+// MockSessionManagerMockRecorder is the mock recorder for MockSessionManager.
+type MockSessionManagerMockRecorder struct {
+	mock *MockSessionManager
+}
+
+// NewMockSessionManager creates a new mock instance.
+func NewMockSessionManager(ctrl *gomock.Controller) *MockSessionManager {
+	mock := &MockSessionManager{ctrl: ctrl}
+	mock.recorder = &MockSessionManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionManager) EXPECT() *MockSessionManagerMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockSessionManager) Authenticate(ctx context.Context, accessToken string) (logic.SessionAuth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, accessToken)
+	ret0, _ := ret[0].(logic.SessionAuth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockSessionManagerMockRecorder) Authenticate(ctx, accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockSessionManager)(nil).Authenticate), ctx, accessToken)
+}
+
+// Refresh mocks base method.
+func (m *MockSessionManager) Refresh(ctx context.Context, refreshToken string) (logic.SessionTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken)
+	ret0, _ := ret[0].(logic.SessionTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockSessionManagerMockRecorder) Refresh(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockSessionManager)(nil).Refresh), ctx, refreshToken)
+}
+
+// Start mocks base method.
+func (m *MockSessionManager) Start(ctx context.Context, userID int) (logic.SessionTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start", ctx, userID)
+	ret0, _ := ret[0].(logic.SessionTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockSessionManagerMockRecorder) Start(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockSessionManager)(nil).Start), ctx, userID)
+}
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"go.uber.org/zap"
+)
+
+// ScopeMW allows requests only if they have a scope (see logic.HasScope), others get http.StatusForbidden.
+// Only requests authenticated by API keys can be limited by scopes, so it have to be used after AuthMW.
+func ScopeMW(scope string, logger zap.SugaredLogger) func(handlerFunc http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !logic.HasScope(r.Context(), scope) {
+				logger.Debugf("request forbidden (API key has no `%s` scope)", scope)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
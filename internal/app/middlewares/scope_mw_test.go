@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestScopeMW(t *testing.T) {
+	//prepare logger
+	logger := zaptest.NewLogger(t)
+	sugar := logger.Sugar()
+
+	//prepare handler
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		statusWant int
+	}{
+		{
+			name:       "JWT user",
+			ctx:        logic.ContextWithUserID(context.Background(), 1),
+			statusWant: http.StatusOK,
+		},
+		{
+			name:       "key without scopes",
+			ctx:        logic.ContextWithAPIKey(context.Background(), entities.APIKey{UserID: 1}),
+			statusWant: http.StatusOK,
+		},
+		{
+			name:       "key with a scope",
+			ctx:        logic.ContextWithAPIKey(context.Background(), entities.APIKey{UserID: 1, Scopes: []string{logic.ScopeRead, logic.ScopeDelete}}),
+			statusWant: http.StatusOK,
+		},
+		{
+			name:       "key without a scope",
+			ctx:        logic.ContextWithAPIKey(context.Background(), entities.APIKey{UserID: 1, Scopes: []string{logic.ScopeRead}}),
+			statusWant: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/api/user/urls", nil).WithContext(tt.ctx)
+			w := httptest.NewRecorder()
+
+			ScopeMW(logic.ScopeDelete, *sugar)(handler).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusWant, w.Code)
+		})
+	}
+}
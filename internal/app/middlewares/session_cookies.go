@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/logic"
+)
+
+// RefreshCookieName is a name of a cookie with a refresh token (an access token is kept in a JwtCookieName cookie).
+const RefreshCookieName = "JWT_REFRESH"
+
+// SetSessionCookies sends tokens of a session in cookies. Max-Age of cookies matches lifetimes of tokens.
+// A refresh token cookie is not changed if tokens have no refresh token.
+// Cookies are HttpOnly (scripts can`t read them), SameSite=Lax and Secure for TLS requests.
+func SetSessionCookies(w http.ResponseWriter, r *http.Request, tokens logic.SessionTokens) {
+	now := time.Now()
+	http.SetCookie(w, sessionCookie(r, JwtCookieName, tokens.AccessToken, tokens.AccessExpiresAt.Sub(now)))
+	if tokens.RefreshToken != "" {
+		http.SetCookie(w, sessionCookie(r, RefreshCookieName, tokens.RefreshToken, tokens.RefreshExpiresAt.Sub(now)))
+	}
+}
+
+// ClearSessionCookies asks a client to delete session cookies.
+func ClearSessionCookies(w http.ResponseWriter, r *http.Request) {
+	for _, name := range []string{JwtCookieName, RefreshCookieName} {
+		cookie := sessionCookie(r, name, "", 0)
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+// sessionCookie builds a session cookie witch lives maxAge (at least one second).
+func sessionCookie(r *http.Request, name string, value string, maxAge time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   max(int(maxAge.Round(time.Second).Seconds()), 1),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
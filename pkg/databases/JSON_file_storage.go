@@ -7,7 +7,10 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"sync"
 	"time"
@@ -16,18 +19,97 @@ import (
 )
 
 type data struct {
-	ID         int    `json:"id"`
-	Key        string `json:"key"`
-	Val        string `json:"val"`
-	UserID     int    `json:"user_id"`
-	WasDeleted bool   `json:"was_deleted"`
+	ID             int                     `json:"id"`
+	Key            string                  `json:"key"`
+	Val            string                  `json:"val"`
+	UserID         int                     `json:"user_id"`
+	WasDeleted     bool                    `json:"was_deleted"`
+	ExpiresAt      *time.Time              `json:"expires_at,omitempty"`
+	MaxClicks      int                     `json:"max_clicks,omitempty"`
+	ClicksLeft     *int                    `json:"clicks_left,omitempty"`
+	PasswordHash   string                  `json:"password_hash,omitempty"`
+	Title          string                  `json:"title,omitempty"`
+	Notes          string                  `json:"notes,omitempty"`
+	Tags           []string                `json:"tags,omitempty"`
+	CreatedAt      *time.Time              `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time              `json:"updated_at,omitempty"`
+	DeletedAt      *time.Time              `json:"deleted_at,omitempty"`
+	RedirectCode   int                     `json:"redirect_code,omitempty"`
+	PassQuery      *bool                   `json:"pass_query,omitempty"`
+	UTM            map[string]string       `json:"utm,omitempty"`
+	Rules          []entities.RedirectRule `json:"rules,omitempty"`
+	Variants       []entities.Variant      `json:"variants,omitempty"`
+	StickyVariants bool                    `json:"sticky_variants,omitempty"`
+}
+
+// newURLData builds a new data record from a URL.
+func newURLData(id int, userID int, url entities.URL) data {
+	setCreationTime(&url, time.Now())
+	return data{
+		ID:             id,
+		Key:            url.ShortURL,
+		Val:            url.OriginalURL,
+		UserID:         userID,
+		ExpiresAt:      url.ExpiresAt,
+		MaxClicks:      url.MaxClicks,
+		ClicksLeft:     url.ClicksLeft,
+		PasswordHash:   url.PasswordHash,
+		Title:          url.Title,
+		Notes:          url.Notes,
+		Tags:           url.Tags,
+		CreatedAt:      url.CreatedAt,
+		UpdatedAt:      url.UpdatedAt,
+		RedirectCode:   url.RedirectCode,
+		PassQuery:      url.PassQuery,
+		UTM:            url.UTM,
+		Rules:          url.Rules,
+		Variants:       url.Variants,
+		StickyVariants: url.StickyVariants,
+	}
+}
+
+// toURL converts a data record to a URL.
+func (d *data) toURL() entities.URL {
+	return entities.URL{
+		ShortURL:       d.Key,
+		OriginalURL:    d.Val,
+		ExpiresAt:      d.ExpiresAt,
+		MaxClicks:      d.MaxClicks,
+		ClicksLeft:     d.ClicksLeft,
+		PasswordHash:   d.PasswordHash,
+		IsDeleted:      d.WasDeleted,
+		UserID:         d.UserID,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		DeletedAt:      d.DeletedAt,
+		Title:          d.Title,
+		Notes:          d.Notes,
+		Tags:           d.Tags,
+		RedirectCode:   d.RedirectCode,
+		PassQuery:      d.PassQuery,
+		UTM:            d.UTM,
+		Rules:          d.Rules,
+		Variants:       d.Variants,
+		StickyVariants: d.StickyVariants,
+	}
 }
 
 // JSONFileStorage is storage witch uses a file to store data. It writes a JSON arrays to it. Thread-safe.
+// Clicks, accounts, API keys and sessions are saved to different files (Path + ClicksFileSuffix, Path + AccountsFileSuffix,
+// Path + APIKeysFileSuffix and Path + SessionsFileSuffix).
+// A search index (by tags and titles), original URLs and offsets of records in a file by short URLs,
+// positions of user`s URLs in listings and sessions are kept in memory.
 type JSONFileStorage struct {
-	Path   string
-	lastID int
-	mutex  sync.Mutex
+	Path    string
+	lastID  int
+	mutex   sync.Mutex
+	index   *searchIndex
+	shorts  map[string]string
+	offsets map[string]int64
+	users   *userURLsIndex
+
+	sessionsMutex sync.Mutex
+	sessions      map[string]entities.Session
 }
 
 // NewJSONFileStorage build a new JSONFileStorage.
@@ -38,261 +120,143 @@ func NewJSONFileStorage(path string) *JSONFileStorage {
 
 // Save saves a new url to a storage.
 func (j *JSONFileStorage) Save(ctx context.Context, url entities.URL) error {
-	j.mutex.Lock()
-	defer j.mutex.Unlock()
-
-	// Open file
-	file, err := os.OpenFile(j.Path, (os.O_RDWR | os.O_APPEND | os.O_CREATE), 0666)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	//Find last id
-	scanner := bufio.NewScanner(file)
-
-	if j.lastID == 0 {
-		lastLine := ""
-		for scanner.Scan() {
-			lastLine = scanner.Text()
-		}
-		if lastLine != "" {
-			lastData := data{}
-			err = json.Unmarshal([]byte(lastLine), &lastData)
-			if err != nil {
-				return err
-			}
-			j.lastID = lastData.ID
-		}
-	}
-
-	newData := data{
-		ID:  j.lastID + 1,
-		Key: url.ShortURL,
-		Val: url.OriginalURL,
-	}
-	JSONData, err := json.Marshal(newData)
-	JSONData = append(JSONData, '\n')
-
-	if err != nil {
-		return err
-	}
-
-	wr := bufio.NewWriter(file)
-	_, err = wr.Write(JSONData)
-	if err != nil {
-		return err
-	}
-	wr.Flush()
-
-	return nil
+	return j.SaveBatchWithUserID(ctx, 0, []entities.URL{url})
 }
 
 // SaveWithUserID saves a URL with userID.
 func (j *JSONFileStorage) SaveWithUserID(ctx context.Context, userID int, url entities.URL) error {
+	return j.SaveBatchWithUserID(ctx, userID, []entities.URL{url})
+}
+
+// SaveBatch saves a batch of URLs.
+func (j *JSONFileStorage) SaveBatch(ctx context.Context, urls []entities.URL) error {
+	return j.SaveBatchWithUserID(ctx, 0, urls)
+}
+
+// SaveBatchWithUserID save a batch of URLs with userID. Nothing is saved if any short URL is already used.
+func (j *JSONFileStorage) SaveBatchWithUserID(ctx context.Context, userID int, urls []entities.URL) error {
 	j.mutex.Lock()
 	defer j.mutex.Unlock()
 
-	// Open file
-	file, err := os.OpenFile(j.Path, (os.O_RDWR | os.O_APPEND | os.O_CREATE), 0666)
+	err := j.load()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	//Find last id
-	scanner := bufio.NewScanner(file)
-
-	if j.lastID == 0 {
-		lastLine := ""
-		for scanner.Scan() {
-			lastLine = scanner.Text()
-		}
-		if lastLine != "" {
-			lastData := data{}
-			err = json.Unmarshal([]byte(lastLine), &lastData)
-			if err != nil {
-				return err
-			}
-			j.lastID = lastData.ID
-		}
-	}
-
-	newData := data{
-		ID:     j.lastID + 1,
-		Key:    url.ShortURL,
-		Val:    url.OriginalURL,
-		UserID: userID,
-	}
-	JSONData, err := json.Marshal(newData)
-	JSONData = append(JSONData, '\n')
-
+	err = checkShorts(urls, j.original)
 	if err != nil {
 		return err
 	}
 
-	wr := bufio.NewWriter(file)
-	_, err = wr.Write(JSONData)
+	file, err := os.OpenFile(j.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
 	if err != nil {
 		return err
 	}
-	wr.Flush()
-
-	return nil
-}
-
-// SaveBatch saves a batch of URLs.
-func (j *JSONFileStorage) SaveBatch(ctx context.Context, urls []entities.URL) error {
-	j.mutex.Lock()
-	defer j.mutex.Unlock()
-
-	// Open file
-	file, err := os.OpenFile(j.Path, (os.O_RDWR | os.O_APPEND | os.O_CREATE), 0666)
+	defer file.Close()
+	info, err := file.Stat()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	//Find last id
-	scanner := bufio.NewScanner(file)
-
-	if j.lastID == 0 {
-		lastLine := ""
-		for scanner.Scan() {
-			lastLine = scanner.Text()
-		}
-		if lastLine != "" {
-			lastData := data{}
-			err = json.Unmarshal([]byte(lastLine), &lastData)
-			if err != nil {
-				return err
-			}
-			j.lastID = lastData.ID
-		}
-	}
 
-	//save all
+	records := make([]data, 0, len(urls))
+	offsets := make([]int64, 0, len(urls))
+	offset := info.Size()
 	wr := bufio.NewWriter(file)
-	for _, url := range urls {
-		newData := data{
-			ID:  j.lastID + 1,
-			Key: url.ShortURL,
-			Val: url.OriginalURL,
-		}
-		j.lastID++
-
-		JSONData, err := json.Marshal(newData)
+	for i, url := range urls {
+		record := newURLData(j.lastID+i+1, userID, url)
+		JSONData, err := json.Marshal(record)
 		if err != nil {
 			return err
 		}
-
 		JSONData = append(JSONData, '\n')
-
 		_, err = wr.Write(JSONData)
 		if err != nil {
 			return err
 		}
+		records = append(records, record)
+		offsets = append(offsets, offset)
+		offset += int64(len(JSONData))
 	}
-
-	wr.Flush()
-
-	return nil
-}
-
-// SaveBatchWithUserID save a batch of URLs with userID.
-func (j *JSONFileStorage) SaveBatchWithUserID(ctx context.Context, userID int, urls []entities.URL) error {
-	j.mutex.Lock()
-	defer j.mutex.Unlock()
-
-	// Open file
-	file, err := os.OpenFile(j.Path, (os.O_RDWR | os.O_APPEND | os.O_CREATE), 0666)
+	err = wr.Flush()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	//Find last id
-	scanner := bufio.NewScanner(file)
 
-	if j.lastID == 0 {
-		lastLine := ""
-		for scanner.Scan() {
-			lastLine = scanner.Text()
-		}
-		if lastLine != "" {
-			lastData := data{}
-			err = json.Unmarshal([]byte(lastLine), &lastData)
-			if err != nil {
-				return err
-			}
-			j.lastID = lastData.ID
-		}
+	for i, record := range records {
+		j.addRecord(record, offsets[i])
 	}
+	return nil
+}
 
-	//save all
-	wr := bufio.NewWriter(file)
-	for _, url := range urls {
-		newData := data{
-			ID:     j.lastID + 1,
-			Key:    url.ShortURL,
-			Val:    url.OriginalURL,
-			UserID: userID,
+// DeleteBatchWithUserID marks URLs as deleted (if their userID matches with given one).
+// URLs have to be sent to urlsChan, the file is rewritten once after the channel is closed by a caller.
+// done gets a result of rewriting.
+func (j *JSONFileStorage) DeleteBatchWithUserID(userID int) (urlsChan chan string, done <-chan error, err error) {
+	urlsChan = make(chan string)
+	result := make(chan error, 1)
+	go func() {
+		defer close(result)
+		toDelete := make(map[string]struct{})
+		for short := range urlsChan {
+			toDelete[short] = struct{}{}
 		}
-		j.lastID++
 
-		JSONData, err := json.Marshal(newData)
+		j.mutex.Lock()
+		defer j.mutex.Unlock()
+		records, err := j.readAll()
 		if err != nil {
-			return err
+			result <- fmt.Errorf("reading URLs to delete: %w", err)
+			return
 		}
-
-		JSONData = append(JSONData, '\n')
-
-		_, err = wr.Write(JSONData)
+		now := time.Now().UTC()
+		for i := range records {
+			if _, ok := toDelete[records[i].Key]; ok && records[i].UserID == userID && !records[i].WasDeleted {
+				records[i].WasDeleted = true
+				records[i].DeletedAt = &now
+			}
+		}
+		err = j.rewriteAll(records)
 		if err != nil {
-			return err
+			result <- fmt.Errorf("saving deleted URLs: %w", err)
+			return
 		}
-	}
-
-	wr.Flush()
-
-	return nil
+		result <- nil
+	}()
+	return urlsChan, result, nil
 }
 
-// DeleteBatchWithUserID deletes a batch of URLs (if their userID matches with given one).
-func (j *JSONFileStorage) DeleteBatchWithUserID(userID int) (urlsChan chan string, err error) {
-	urlsChan = make(chan string)
-	return urlsChan, ErrThisFuncIsNotSupported()
-}
-
-// GetUserUrls returns all URLs of a user.
-func (j *JSONFileStorage) GetUserUrls(ctx context.Context, userID int) (URLs []entities.URL, err error) {
+// GetUserUrls returns one page of user`s URLs. URLs are sorted by an index of user`s URLs (see pageURLs),
+// so only records of a page are read from a file.
+func (j *JSONFileStorage) GetUserUrls(ctx context.Context, userID int, query entities.URLsPageQuery) ([]entities.URL, error) {
 	j.mutex.Lock()
 	defer j.mutex.Unlock()
 
-	file, err := os.Open(j.Path)
+	err := j.load()
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
+	//only indexed URLs are checked if it is possible
+	shorts, _ := j.index.candidates(query)
 
-	lastLine := ""
-	URLs = make([]entities.URL, 0)
-	for scanner.Scan() {
-		lastLine = scanner.Text()
-		lastData := data{}
-		err = json.Unmarshal([]byte(lastLine), &lastData)
-		if err != nil {
-			return nil, err
+	//only records of a page are read from a file
+	var file *os.File
+	defer func() {
+		if file != nil {
+			file.Close()
 		}
-
-		if lastData.UserID == userID {
-			URLs = append(URLs, entities.URL{OriginalURL: lastData.Val, ShortURL: lastData.Key})
+	}()
+	return pageURLs(j.users, userID, query, shorts, func(short string) (entities.URL, error) {
+		if file == nil {
+			file, err = os.Open(j.Path)
+			if err != nil {
+				return entities.URL{}, err
+			}
 		}
-	}
-
-	return URLs, err
+		record, err := readRecordAt(file, j.offsets[short])
+		if err != nil {
+			return entities.URL{}, err
+		}
+		return record.toURL(), nil
+	})
 }
 
 // Get returns an original URL using it`s short version.
@@ -318,6 +282,13 @@ func (j *JSONFileStorage) Get(ctx context.Context, key string) (string, error) {
 		}
 
 		if lastData.Key == key {
+			url := lastData.toURL()
+			if url.IsExpiredAt(time.Now()) {
+				return "", ErrURLExpired()
+			}
+			if url.IsExhausted() {
+				return "", ErrClicksLimitReached()
+			}
 			return lastData.Val, nil
 		}
 	}
@@ -377,3 +348,789 @@ func (j *JSONFileStorage) GetShortURLCount(ctx context.Context) (int, error) {
 
 	return count, nil
 }
+
+// DeleteExpired removes all URLs witch expired before given time. Returns an amount of removed URLs.
+// It rewrites the whole file.
+func (j *JSONFileStorage) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	records, err := j.readAll()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make([]data, 0, len(records))
+	for _, record := range records {
+		if record.ExpiresAt == nil || !record.ExpiresAt.Before(expiredBefore) {
+			kept = append(kept, record)
+		}
+	}
+	if len(kept) == len(records) {
+		return 0, nil
+	}
+
+	err = j.rewriteAll(kept)
+	if err != nil {
+		return 0, err
+	}
+	return len(records) - len(kept), nil
+}
+
+// UseClick decreases clicks left of a URL if it has a clicks limit.
+// Returns ErrClicksLimitReached if there are no clicks left.
+// It rewrites the whole file if URL has a clicks limit.
+func (j *JSONFileStorage) UseClick(ctx context.Context, short string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	records, err := j.readAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		if records[i].Key != short {
+			continue
+		}
+		if records[i].ClicksLeft == nil {
+			return nil
+		}
+		if *records[i].ClicksLeft <= 0 {
+			return ErrClicksLimitReached()
+		}
+		*records[i].ClicksLeft--
+		return j.rewriteAll(records)
+	}
+	return fmt.Errorf("key doesnt exist")
+}
+
+// GetURL returns a URL with all its fields using it`s short version.
+// Returns ErrURLNotFound if there is no such URL.
+func (j *JSONFileStorage) GetURL(ctx context.Context, short string) (entities.URL, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	err := j.load()
+	if err != nil {
+		return entities.URL{}, err
+	}
+	//only a line of a URL is read
+	offset, ok := j.offsets[short]
+	if !ok {
+		return entities.URL{}, ErrURLNotFound()
+	}
+	file, err := os.Open(j.Path)
+	if err != nil {
+		return entities.URL{}, err
+	}
+	defer file.Close()
+	record, err := readRecordAt(file, offset)
+	if err != nil {
+		return entities.URL{}, err
+	}
+	return record.toURL(), nil
+}
+
+// UpdateURL changes a URL. Only an owner can change it.
+// Returns ErrURLNotFound if user has no such URL (or it was deleted)
+// and AlreadyExistsError if other short URL already has a new original URL.
+func (j *JSONFileStorage) UpdateURL(ctx context.Context, userID int, short string, update entities.URLUpdate) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	records, err := j.readAll()
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, record := range records {
+		if update.OriginalURL != nil && record.Key != short && record.Val == *update.OriginalURL {
+			return NewAlreadyExistsError(record.Key)
+		}
+		if record.Key == short && record.UserID == userID && !record.WasDeleted {
+			index = i
+		}
+	}
+	if index == -1 {
+		return ErrURLNotFound()
+	}
+
+	//a record is built again, so new fields of URLs can`t be forgotten here
+	url := records[index].toURL()
+	applyURLUpdate(&url, update, time.Now())
+	records[index] = newURLData(records[index].ID, userID, url)
+	return j.rewriteAll(records)
+}
+
+// ClicksFileSuffix is added to JSONFileStorage.Path to get a clicks file path.
+const ClicksFileSuffix = ".clicks"
+
+// SaveClicks saves a batch of clicks.
+func (j *JSONFileStorage) SaveClicks(ctx context.Context, clicks []entities.Click) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	file, err := os.OpenFile(j.Path+ClicksFileSuffix, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	wr := bufio.NewWriter(file)
+	for _, click := range clicks {
+		JSONData, err := json.Marshal(click)
+		if err != nil {
+			return err
+		}
+		JSONData = append(JSONData, '\n')
+		_, err = wr.Write(JSONData)
+		if err != nil {
+			return err
+		}
+	}
+	return wr.Flush()
+}
+
+// GetURLStats returns a statistics of a short URL clicks. It reads the whole clicks file.
+func (j *JSONFileStorage) GetURLStats(ctx context.Context, short string, topReferrers int) (entities.URLStats, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	clicks := make([]entities.Click, 0)
+	file, err := os.Open(j.Path + ClicksFileSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return aggregateClicks(short, clicks, topReferrers), nil
+	}
+	if err != nil {
+		return entities.URLStats{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		click := entities.Click{}
+		err = json.Unmarshal(scanner.Bytes(), &click)
+		if err != nil {
+			return entities.URLStats{}, err
+		}
+		if click.ShortURL == short {
+			clicks = append(clicks, click)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entities.URLStats{}, fmt.Errorf("error reading clicks file: %w", err)
+	}
+
+	return aggregateClicks(short, clicks, topReferrers), nil
+}
+
+// AccountsFileSuffix is added to JSONFileStorage.Path to get an accounts file path.
+const AccountsFileSuffix = ".accounts"
+
+// CreateAccount saves a new account. Returns ErrLoginTaken if a login is already used.
+func (j *JSONFileStorage) CreateAccount(ctx context.Context, account entities.Account) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	accounts, err := j.readAccounts()
+	if err != nil {
+		return err
+	}
+	for _, existing := range accounts {
+		if existing.Login == account.Login {
+			return ErrLoginTaken()
+		}
+	}
+
+	file, err := os.OpenFile(j.Path+AccountsFileSuffix, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	JSONData, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	JSONData = append(JSONData, '\n')
+	_, err = file.Write(JSONData)
+	return err
+}
+
+// GetAccount returns an account by its login. Returns ErrAccountNotFound if there is no such login.
+func (j *JSONFileStorage) GetAccount(ctx context.Context, login string) (entities.Account, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	accounts, err := j.readAccounts()
+	if err != nil {
+		return entities.Account{}, err
+	}
+	for _, account := range accounts {
+		if account.Login == login {
+			return account, nil
+		}
+	}
+	return entities.Account{}, ErrAccountNotFound()
+}
+
+// ClaimURLs moves all URLs of a user without an account to another user. Returns an amount of moved URLs.
+func (j *JSONFileStorage) ClaimURLs(ctx context.Context, fromUserID int, toUserID int) (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	accounts, err := j.readAccounts()
+	if err != nil {
+		return 0, err
+	}
+	for _, account := range accounts {
+		if account.UserID == fromUserID {
+			return 0, nil
+		}
+	}
+
+	records, err := j.readAll()
+	if err != nil {
+		return 0, err
+	}
+	claimed := 0
+	for i := range records {
+		if records[i].UserID == fromUserID {
+			records[i].UserID = toUserID
+			claimed++
+		}
+	}
+	if claimed == 0 {
+		return 0, nil
+	}
+	return claimed, j.rewriteAll(records)
+}
+
+// readAccounts reads all accounts from an accounts file. Returns an empty slice if file doesn`t exist.
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) readAccounts() ([]entities.Account, error) {
+	accounts := make([]entities.Account, 0)
+	file, err := os.Open(j.Path + AccountsFileSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return accounts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		account := entities.Account{}
+		err = json.Unmarshal(scanner.Bytes(), &account)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading accounts file: %w", err)
+	}
+	return accounts, nil
+}
+
+// APIKeysFileSuffix is added to JSONFileStorage.Path to get an API keys file path.
+const APIKeysFileSuffix = ".keys"
+
+// SaveAPIKey saves a new API key.
+func (j *JSONFileStorage) SaveAPIKey(ctx context.Context, key entities.APIKey) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	file, err := os.OpenFile(j.Path+APIKeysFileSuffix, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	JSONData, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	JSONData = append(JSONData, '\n')
+	_, err = file.Write(JSONData)
+	return err
+}
+
+// GetAPIKey returns an API key by its hash. Returns ErrAPIKeyNotFound if there is no such key.
+func (j *JSONFileStorage) GetAPIKey(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	keys, err := j.readAPIKeys()
+	if err != nil {
+		return entities.APIKey{}, err
+	}
+	for _, key := range keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return entities.APIKey{}, ErrAPIKeyNotFound()
+}
+
+// GetUserAPIKeys returns all API keys of a user sorted by creation time.
+func (j *JSONFileStorage) GetUserAPIKeys(ctx context.Context, userID int) ([]entities.APIKey, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	keys, err := j.readAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+	userKeys := make([]entities.APIKey, 0)
+	for _, key := range keys {
+		if key.UserID == userID {
+			userKeys = append(userKeys, key)
+		}
+	}
+	sortAPIKeys(userKeys)
+	return userKeys, nil
+}
+
+// DeleteAPIKey deletes an API key of a user. Returns ErrAPIKeyNotFound if user has no such key.
+func (j *JSONFileStorage) DeleteAPIKey(ctx context.Context, userID int, id string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	keys, err := j.readAPIKeys()
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if key.ID == id && key.UserID == userID {
+			return j.rewriteAPIKeys(append(keys[:i], keys[i+1:]...))
+		}
+	}
+	return ErrAPIKeyNotFound()
+}
+
+// SetAPIKeyLastUsed saves a time of the last usage of an API key.
+func (j *JSONFileStorage) SetAPIKeyLastUsed(ctx context.Context, id string, lastUsed time.Time) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	keys, err := j.readAPIKeys()
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if key.ID == id {
+			keys[i].LastUsedAt = &lastUsed
+			return j.rewriteAPIKeys(keys)
+		}
+	}
+	return ErrAPIKeyNotFound()
+}
+
+// readAPIKeys reads all API keys from an API keys file. Returns an empty slice if file doesn`t exist.
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) readAPIKeys() ([]entities.APIKey, error) {
+	keys := make([]entities.APIKey, 0)
+	file, err := os.Open(j.Path + APIKeysFileSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key := entities.APIKey{}
+		err = json.Unmarshal(scanner.Bytes(), &key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading API keys file: %w", err)
+	}
+	return keys, nil
+}
+
+// rewriteAPIKeys replaces an API keys file content with given keys (using a temporary file like rewriteAll).
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) rewriteAPIKeys(keys []entities.APIKey) error {
+	path := j.Path + APIKeysFileSuffix
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	wr := bufio.NewWriter(file)
+	for _, key := range keys {
+		JSONData, err := json.Marshal(key)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		JSONData = append(JSONData, '\n')
+		_, err = wr.Write(JSONData)
+		if err != nil {
+			file.Close()
+			return err
+		}
+	}
+	err = wr.Flush()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// SessionsFileSuffix is added to JSONFileStorage.Path to get a sessions file path.
+const SessionsFileSuffix = ".sessions"
+
+// CreateSession saves a new session.
+func (j *JSONFileStorage) CreateSession(ctx context.Context, session entities.Session) error {
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	return j.writeSession(session)
+}
+
+// GetSession returns a session by its ID (even if it was revoked or has expired).
+// Returns ErrSessionNotFound if there is no such session.
+func (j *JSONFileStorage) GetSession(ctx context.Context, id string) (entities.Session, error) {
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	err := j.loadSessions()
+	if err != nil {
+		return entities.Session{}, err
+	}
+	session, ok := j.sessions[id]
+	if !ok {
+		return entities.Session{}, ErrSessionNotFound()
+	}
+	return session, nil
+}
+
+// ExtendSession sets a new expiration time of a session. Returns ErrSessionNotFound if there is no such session
+// or it was revoked.
+func (j *JSONFileStorage) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	err := j.loadSessions()
+	if err != nil {
+		return err
+	}
+	session, ok := j.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return ErrSessionNotFound()
+	}
+	session.ExpiresAt = expiresAt
+	return j.writeSession(session)
+}
+
+// RevokeSession marks a session as revoked. Returns ErrSessionNotFound if there is no such session.
+func (j *JSONFileStorage) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	err := j.loadSessions()
+	if err != nil {
+		return err
+	}
+	session, ok := j.sessions[id]
+	if !ok {
+		return ErrSessionNotFound()
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	session.RevokedAt = &revokedAt
+	return j.writeSession(session)
+}
+
+// DeleteEndedSessions removes all sessions witch expired or were revoked before given time.
+// Returns an amount of removed sessions. A sessions file is rewritten, so old versions of sessions are removed too.
+func (j *JSONFileStorage) DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (int, error) {
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	err := j.loadSessions()
+	if err != nil {
+		return 0, err
+	}
+	kept := make([]entities.Session, 0, len(j.sessions))
+	for _, session := range j.sessions {
+		if !session.EndedBefore(endedBefore) {
+			kept = append(kept, session)
+		}
+	}
+	err = j.rewriteSessions(kept)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := len(j.sessions) - len(kept)
+	j.sessions = make(map[string]entities.Session, len(kept))
+	for _, session := range kept {
+		j.sessions[session.ID] = session
+	}
+	return deleted, nil
+}
+
+// writeSession appends a session to a sessions file and remembers it.
+// A sessions file is a log: a session is written again after every change and its last line wins.
+// sessionsMutex have to be locked by a caller.
+func (j *JSONFileStorage) writeSession(session entities.Session) error {
+	err := j.loadSessions()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.Path+SessionsFileSuffix, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	JSONData, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	JSONData = append(JSONData, '\n')
+	_, err = file.Write(JSONData)
+	if err != nil {
+		return err
+	}
+	j.sessions[session.ID] = session
+	return nil
+}
+
+// loadSessions reads a sessions file once (the last line of a session wins), later sessions are kept in memory.
+// sessionsMutex have to be locked by a caller.
+func (j *JSONFileStorage) loadSessions() error {
+	if j.sessions != nil {
+		return nil
+	}
+	sessions := make(map[string]entities.Session)
+	file, err := os.Open(j.Path + SessionsFileSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		j.sessions = sessions
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		session := entities.Session{}
+		err = json.Unmarshal(scanner.Bytes(), &session)
+		if err != nil {
+			return err
+		}
+		sessions[session.ID] = session
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading sessions file: %w", err)
+	}
+	j.sessions = sessions
+	return nil
+}
+
+// rewriteSessions replaces a sessions file content with given sessions (using a temporary file like rewriteAll).
+// sessionsMutex have to be locked by a caller.
+func (j *JSONFileStorage) rewriteSessions(sessions []entities.Session) error {
+	path := j.Path + SessionsFileSuffix
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	wr := bufio.NewWriter(file)
+	for _, session := range sessions {
+		JSONData, err := json.Marshal(session)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		JSONData = append(JSONData, '\n')
+		_, err = wr.Write(JSONData)
+		if err != nil {
+			file.Close()
+			return err
+		}
+	}
+	err = wr.Flush()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// readAll reads all records from a file. Returns an empty slice if file doesn`t exist.
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) readAll() ([]data, error) {
+	records, _, err := j.readRecords()
+	return records, err
+}
+
+// readRecords reads all records from a file with offsets of their lines. Returns empty slices if file doesn`t exist.
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) readRecords() (records []data, offsets []int64, err error) {
+	file, err := os.Open(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]data, 0), make([]int64, 0), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	records = make([]data, 0)
+	offsets = make([]int64, 0)
+	offset := int64(0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := data{}
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+		offsets = append(offsets, offset)
+		offset += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return records, offsets, nil
+}
+
+// readRecordAt reads a record from a line of a file witch starts at an offset.
+func readRecordAt(file *os.File, offset int64) (data, error) {
+	line, err := bufio.NewReader(io.NewSectionReader(file, offset, math.MaxInt64-offset)).ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return data{}, fmt.Errorf("error reading file: %w", err)
+	}
+	record := data{}
+	err = json.Unmarshal(line, &record)
+	if err != nil {
+		return data{}, err
+	}
+	return record, nil
+}
+
+// rewriteAll replaces file content with given records.
+// It writes a temporary file first and then renames it, so file is never half-written.
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) rewriteAll(records []data) error {
+	tmpPath := j.Path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	offsets := make([]int64, 0, len(records))
+	offset := int64(0)
+	wr := bufio.NewWriter(file)
+	for _, record := range records {
+		JSONData, err := json.Marshal(record)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		JSONData = append(JSONData, '\n')
+		_, err = wr.Write(JSONData)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		offsets = append(offsets, offset)
+		offset += int64(len(JSONData))
+	}
+	err = wr.Flush()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, j.Path)
+	if err != nil {
+		return err
+	}
+	j.setRecords(records, offsets)
+	return nil
+}
+
+// load reads short URLs, offsets, users and a search index of all records (and the last ID) from a file once,
+// later storage methods keep them up to date. Mutex have to be locked by a caller.
+func (j *JSONFileStorage) load() error {
+	if j.shorts != nil {
+		return nil
+	}
+	records, offsets, err := j.readRecords()
+	if err != nil {
+		return err
+	}
+	j.setRecords(records, offsets)
+	return nil
+}
+
+// setRecords replaces everything known about records of a file with given records and offsets of their lines.
+// Mutex have to be locked by a caller.
+func (j *JSONFileStorage) setRecords(records []data, offsets []int64) {
+	j.shorts = make(map[string]string, len(records))
+	j.offsets = make(map[string]int64, len(records))
+	j.users = newUserURLsIndex()
+	j.index = newSearchIndex()
+	for i, record := range records {
+		j.addRecord(record, offsets[i])
+	}
+}
+
+// addRecord remembers a record written to a file at an offset. Only the first record of a short URL is used
+// (like readers of a file do). Mutex have to be locked by a caller.
+func (j *JSONFileStorage) addRecord(record data, offset int64) {
+	if record.ID > j.lastID {
+		j.lastID = record.ID
+	}
+	if _, ok := j.shorts[record.Key]; ok {
+		return
+	}
+	url := record.toURL()
+	j.shorts[record.Key] = record.Val
+	j.offsets[record.Key] = offset
+	j.index.add(url)
+	if record.UserID != 0 {
+		j.users.add(record.UserID, url)
+	}
+}
+
+// original returns an original URL of a short URL. Mutex have to be locked by a caller (and shorts have to be loaded).
+func (j *JSONFileStorage) original(short string) (string, bool) {
+	original, ok := j.shorts[short]
+	return original, ok
+}
@@ -0,0 +1,154 @@
+package databases
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFileStorage_UpdateURL(t *testing.T) {
+	ctx := context.Background()
+	userID := 1
+	store := NewJSONFileStorage(filepath.Join(t.TempDir(), "urls.json"))
+	err := store.SaveWithUserID(ctx, userID, entities.URL{ShortURL: "abc", OriginalURL: "https://ya.ru/", Title: "Yandex"})
+	require.NoError(t, err, "error while preparing a storage")
+
+	t.Run("rules", func(t *testing.T) {
+		rules := []entities.RedirectRule{{Device: "ios", Destination: "https://apps.apple.com/"}}
+		err := store.UpdateURL(ctx, userID, "abc", entities.URLUpdate{Rules: &rules})
+		require.NoError(t, err)
+
+		url, err := store.GetURL(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, rules, url.Rules)
+		assert.Equal(t, "https://ya.ru/", url.OriginalURL)
+		assert.Equal(t, "Yandex", url.Title)
+		assert.Equal(t, userID, url.UserID)
+	})
+
+	t.Run("variants", func(t *testing.T) {
+		variants := []entities.Variant{
+			{Name: "a", Destination: "https://a.example.com/", Weight: 70},
+			{Name: "b", Destination: "https://b.example.com/", Weight: 30},
+		}
+		sticky := true
+		err := store.UpdateURL(ctx, userID, "abc", entities.URLUpdate{Variants: &variants, StickyVariants: &sticky})
+		require.NoError(t, err)
+
+		url, err := store.GetURL(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, variants, url.Variants)
+		assert.True(t, url.StickyVariants)
+		assert.NotEmpty(t, url.Rules, "rules have to be kept")
+	})
+}
+
+func TestJSONFileStorage_Sessions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "urls.json")
+	store := NewJSONFileStorage(path)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	active := entities.Session{ID: "active", UserID: 1, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	expired := entities.Session{ID: "expired", UserID: 1, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	revoked := entities.Session{ID: "revoked", UserID: 2, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	for _, session := range []entities.Session{active, expired, revoked} {
+		require.NoError(t, store.CreateSession(ctx, session), "error while preparing a storage")
+	}
+	require.NoError(t, store.ExtendSession(ctx, "active", now.Add(2*time.Hour)))
+	require.NoError(t, store.RevokeSession(ctx, "revoked", now.Add(-time.Minute)))
+
+	//the last version of a session wins after a restart
+	restarted := NewJSONFileStorage(path)
+	session, err := restarted.GetSession(ctx, "active")
+	require.NoError(t, err)
+	assert.True(t, session.ExpiresAt.Equal(now.Add(2*time.Hour)))
+	session, err = restarted.GetSession(ctx, "revoked")
+	require.NoError(t, err)
+	assert.NotNil(t, session.RevokedAt)
+
+	deleted, err := restarted.DeleteEndedSessions(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	for _, id := range []string{"expired", "revoked"} {
+		_, err = restarted.GetSession(ctx, id)
+		assert.ErrorIs(t, err, ErrSessionNotFound())
+	}
+
+	//a compacted file has only live sessions
+	session, err = NewJSONFileStorage(path).GetSession(ctx, "active")
+	require.NoError(t, err)
+	assert.True(t, session.ExpiresAt.Equal(now.Add(2*time.Hour)))
+	_, err = NewJSONFileStorage(path).GetSession(ctx, "expired")
+	assert.ErrorIs(t, err, ErrSessionNotFound())
+}
+
+func TestJSONFileStorage_DeleteBatchWithUserID(t *testing.T) {
+	ctx := context.Background()
+	userID := 1
+
+	t.Run("deleted", func(t *testing.T) {
+		store := NewJSONFileStorage(filepath.Join(t.TempDir(), "urls.json"))
+		err := store.SaveWithUserID(ctx, userID, entities.URL{ShortURL: "abc", OriginalURL: "https://ya.ru/"})
+		require.NoError(t, err, "error while preparing a storage")
+
+		urlsChan, done, err := store.DeleteBatchWithUserID(userID)
+		require.NoError(t, err)
+		urlsChan <- "abc"
+		close(urlsChan)
+		require.NoError(t, <-done)
+
+		url, err := store.GetURL(ctx, "abc")
+		require.NoError(t, err)
+		assert.True(t, url.IsDeleted)
+	})
+
+	t.Run("file can`t be written", func(t *testing.T) {
+		store := NewJSONFileStorage(filepath.Join(t.TempDir(), "missing dir", "urls.json"))
+
+		urlsChan, done, err := store.DeleteBatchWithUserID(userID)
+		require.NoError(t, err)
+		urlsChan <- "abc"
+		close(urlsChan)
+		assert.Error(t, <-done)
+	})
+}
+
+func TestJSONFileStorage_GetURL(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "urls.json")
+	store := NewJSONFileStorage(path)
+	maxClicks := 10
+	for _, url := range []entities.URL{
+		{ShortURL: "abc", OriginalURL: "https://ya.ru/", ClicksLeft: &maxClicks, MaxClicks: maxClicks},
+		{ShortURL: "def", OriginalURL: "https://example.com/"},
+	} {
+		require.NoError(t, store.Save(ctx, url), "error while preparing a storage")
+	}
+
+	url, err := store.GetURL(ctx, "def")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", url.OriginalURL)
+
+	//a line of "abc" gets shorter (10 -> 9 clicks left), so an offset of "def" is changed by a rewrite of a file
+	require.NoError(t, store.UseClick(ctx, "abc"))
+	url, err = store.GetURL(ctx, "abc")
+	require.NoError(t, err)
+	require.NotNil(t, url.ClicksLeft)
+	assert.Equal(t, maxClicks-1, *url.ClicksLeft)
+	url, err = store.GetURL(ctx, "def")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", url.OriginalURL)
+
+	url, err = NewJSONFileStorage(path).GetURL(ctx, "def")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", url.OriginalURL)
+
+	_, err = store.GetURL(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrURLNotFound())
+}
@@ -0,0 +1,17 @@
+package databases
+
+import (
+	"sort"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// sortAPIKeys sorts API keys by creation time (old keys first) and by ID if they were created at the same time.
+func sortAPIKeys(keys []entities.APIKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+}
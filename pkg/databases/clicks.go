@@ -0,0 +1,82 @@
+package databases
+
+import (
+	"sort"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// aggregateClicks builds a statistics of one short URL from clicks (clicks of other URLs are skipped).
+func aggregateClicks(short string, clicks []entities.Click, topReferrers int) entities.URLStats {
+	stats := entities.URLStats{
+		ShortURL:     short,
+		ClicksPerDay: make([]entities.DayClicks, 0),
+		TopReferrers: make([]entities.ReferrerClicks, 0),
+		Variants:     make([]entities.VariantClicks, 0),
+	}
+
+	visitors := make(map[string]struct{})
+	perVariant := make(map[string]*entities.VariantClicks)
+	variantVisitors := make(map[string]map[string]struct{})
+	perDay := make(map[string]int)
+	perReferrer := make(map[string]int)
+	for _, click := range clicks {
+		if click.ShortURL != short {
+			continue
+		}
+		stats.TotalClicks++
+		if click.IPHash != "" {
+			visitors[click.IPHash] = struct{}{}
+		}
+		perDay[click.Time.UTC().Format(entities.DayFormat)]++
+		referrer := click.Referrer
+		if referrer == "" {
+			referrer = entities.DirectReferrer
+		}
+		perReferrer[referrer]++
+
+		if click.Variant != "" {
+			variant, ok := perVariant[click.Variant]
+			if !ok {
+				variant = &entities.VariantClicks{Variant: click.Variant}
+				perVariant[click.Variant] = variant
+				variantVisitors[click.Variant] = make(map[string]struct{})
+			}
+			variant.Clicks++
+			if click.IPHash != "" {
+				variantVisitors[click.Variant][click.IPHash] = struct{}{}
+			}
+		}
+	}
+	stats.UniqueVisitors = len(visitors)
+
+	for name, variant := range perVariant {
+		variant.UniqueVisitors = len(variantVisitors[name])
+		stats.Variants = append(stats.Variants, *variant)
+	}
+	sort.Slice(stats.Variants, func(i, j int) bool {
+		return stats.Variants[i].Variant < stats.Variants[j].Variant
+	})
+
+	for day, amount := range perDay {
+		stats.ClicksPerDay = append(stats.ClicksPerDay, entities.DayClicks{Day: day, Clicks: amount})
+	}
+	sort.Slice(stats.ClicksPerDay, func(i, j int) bool {
+		return stats.ClicksPerDay[i].Day < stats.ClicksPerDay[j].Day
+	})
+
+	for referrer, amount := range perReferrer {
+		stats.TopReferrers = append(stats.TopReferrers, entities.ReferrerClicks{Referrer: referrer, Clicks: amount})
+	}
+	sort.Slice(stats.TopReferrers, func(i, j int) bool {
+		if stats.TopReferrers[i].Clicks != stats.TopReferrers[j].Clicks {
+			return stats.TopReferrers[i].Clicks > stats.TopReferrers[j].Clicks
+		}
+		return stats.TopReferrers[i].Referrer < stats.TopReferrers[j].Referrer
+	})
+	if len(stats.TopReferrers) > topReferrers {
+		stats.TopReferrers = stats.TopReferrers[:topReferrers]
+	}
+
+	return stats
+}
@@ -28,6 +28,22 @@ func (a *AlreadyExistsError) Is(target error) bool {
 	return ok
 }
 
+// ShortURLTakenError is returned if a short URL is already used by another original URL
+// (it happens after an original URL of a short URL was changed). A URL has to get another short URL.
+type ShortURLTakenError struct {
+	ShortURL string
+}
+
+// Error returns a text with a ShortURL.
+func (s *ShortURLTakenError) Error() string {
+	return "short url `" + s.ShortURL + "` is already taken"
+}
+
+// NewShortURLTakenError creates a new ShortURLTakenError.
+func NewShortURLTakenError(shortURL string) *ShortURLTakenError {
+	return &ShortURLTakenError{ShortURL: shortURL}
+}
+
 var errURLWasDeleted = errors.New("this url was marked as deleted")
 
 // ErrURLWasDeleted returns an errURLWasDeleted error.
@@ -35,9 +51,58 @@ func ErrURLWasDeleted() error {
 	return errURLWasDeleted
 }
 
+var errURLExpired = errors.New("this url has expired")
+
+// ErrURLExpired returns an errURLExpired error.
+func ErrURLExpired() error {
+	return errURLExpired
+}
+
+var errClicksLimitReached = errors.New("this url has no clicks left")
+
+// ErrClicksLimitReached returns an errClicksLimitReached error.
+func ErrClicksLimitReached() error {
+	return errClicksLimitReached
+}
+
 var errThisFuncIsNotSupported = errors.New("jsonFileStorage storage doesnt support deleteBatch func yet")
 
 // ErrThisFuncIsNotSupported returns an errThisFuncIsNotSupported error.
 func ErrThisFuncIsNotSupported() error {
 	return errThisFuncIsNotSupported
 }
+
+var errURLNotFound = errors.New("url not found")
+
+// ErrURLNotFound returns an errURLNotFound error.
+func ErrURLNotFound() error {
+	return errURLNotFound
+}
+
+var errLoginTaken = errors.New("this login is already taken")
+
+// ErrLoginTaken returns an errLoginTaken error.
+func ErrLoginTaken() error {
+	return errLoginTaken
+}
+
+var errAccountNotFound = errors.New("account not found")
+
+// ErrAccountNotFound returns an errAccountNotFound error.
+func ErrAccountNotFound() error {
+	return errAccountNotFound
+}
+
+var errAPIKeyNotFound = errors.New("api key not found")
+
+// ErrAPIKeyNotFound returns an errAPIKeyNotFound error.
+func ErrAPIKeyNotFound() error {
+	return errAPIKeyNotFound
+}
+
+var errSessionNotFound = errors.New("session not found")
+
+// ErrSessionNotFound returns an errSessionNotFound error.
+func ErrSessionNotFound() error {
+	return errSessionNotFound
+}
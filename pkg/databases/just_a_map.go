@@ -12,58 +12,110 @@ import (
 )
 
 // JustAMap is an in-memory storage.
+// Store keeps URLs by their short versions, UserStore keeps userIDs by short URLs.
+// ClickStore keeps all clicks. AccountStore keeps accounts by logins, APIKeyStore keeps API keys by their hashes,
+// SessionStore keeps sessions by their IDs.
 type JustAMap struct {
-	Store     map[string]string
-	UserStore map[string]int
-	Mutex     sync.RWMutex
+	Store        map[string]entities.URL
+	UserStore    map[string]int
+	ClickStore   []entities.Click
+	AccountStore map[string]entities.Account
+	APIKeyStore  map[string]entities.APIKey
+	SessionStore map[string]entities.Session
+	Mutex        sync.RWMutex
+	index        *searchIndex
+	users        *userURLsIndex
 }
 
 // NewJustAMap build a new JustAMap.
 func NewJustAMap() *JustAMap {
-	jm := &JustAMap{Store: make(map[string]string), UserStore: make(map[string]int)}
+	jm := &JustAMap{
+		Store:        make(map[string]entities.URL),
+		UserStore:    make(map[string]int),
+		ClickStore:   make([]entities.Click, 0),
+		AccountStore: make(map[string]entities.Account),
+		APIKeyStore:  make(map[string]entities.APIKey),
+		SessionStore: make(map[string]entities.Session),
+		index:        newSearchIndex(),
+		users:        newUserURLsIndex(),
+	}
 	return jm
 }
 
+// put saves a new URL to Store with a creation time and adds it to indexes.
+// userID = 0 means a URL without a user. Mutex have to be locked by a caller.
+func (j *JustAMap) put(userID int, url entities.URL) {
+	setCreationTime(&url, time.Now())
+	j.Store[url.ShortURL] = url
+	j.index.add(url)
+	if userID != 0 {
+		j.UserStore[url.ShortURL] = userID
+		j.users.add(userID, url)
+	}
+}
+
+// original returns an original URL of a short URL. Mutex have to be locked by a caller.
+func (j *JustAMap) original(short string) (string, bool) {
+	url, ok := j.Store[short]
+	return url.OriginalURL, ok
+}
+
 // SaveWithUserID saves a URL with userID.
 func (j *JustAMap) SaveWithUserID(ctx context.Context, userID int, url entities.URL) error {
-	j.Mutex.Lock()
-	defer j.Mutex.Unlock()
-	j.Store[url.ShortURL] = url.OriginalURL
-	j.UserStore[url.ShortURL] = userID
-	return nil
+	return j.SaveBatchWithUserID(ctx, userID, []entities.URL{url})
 }
 
-// SaveBatchWithUserID save a batch of URLs with userID.
+// SaveBatchWithUserID save a batch of URLs with userID. Nothing is saved if any short URL is already used.
 func (j *JustAMap) SaveBatchWithUserID(ctx context.Context, userID int, urls []entities.URL) error {
-	for _, el := range urls {
-		err := j.SaveWithUserID(ctx, userID, el)
-		if err != nil {
-			return err
-		}
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	err := checkShorts(urls, j.original)
+	if err != nil {
+		return err
+	}
+	for _, url := range urls {
+		j.put(userID, url)
 	}
 	return nil
 }
 
-// DeleteBatchWithUserID deletes a batch of URLs (if their userID matches with given one).
-func (j *JustAMap) DeleteBatchWithUserID(userID int) (urlsChan chan string, err error) {
+// DeleteBatchWithUserID marks URLs as deleted (if their userID matches with given one).
+// URLs have to be sent to urlsChan, the channel have to be closed by a caller. done gets nil after that.
+func (j *JustAMap) DeleteBatchWithUserID(userID int) (urlsChan chan string, done <-chan error, err error) {
 	urlsChan = make(chan string)
-	return urlsChan, ErrThisFuncIsNotSupported()
+	result := make(chan error, 1)
+	go func() {
+		defer close(result)
+		for short := range urlsChan {
+			j.Mutex.Lock()
+			url, ok := j.Store[short]
+			if ok && !url.IsDeleted && j.UserStore[short] == userID {
+				now := time.Now().UTC()
+				url.IsDeleted = true
+				url.DeletedAt = &now
+				j.Store[short] = url
+			}
+			j.Mutex.Unlock()
+		}
+		result <- nil
+	}()
+	return urlsChan, result, nil
 }
 
-// GetUserUrls returns all URLs of a user.
-func (j *JustAMap) GetUserUrls(ctx context.Context, userID int) ([]entities.URL, error) {
+// GetUserUrls returns one page of user`s URLs.
+func (j *JustAMap) GetUserUrls(ctx context.Context, userID int, query entities.URLsPageQuery) ([]entities.URL, error) {
 	j.Mutex.RLock()
 	defer j.Mutex.RUnlock()
 
-	toRet := make([]entities.URL, 0)
-	for short, full := range j.Store {
-		uID := j.UserStore[short]
-		if (uID == userID) && (uID != 0) {
-			toRet = append(toRet, entities.URL{OriginalURL: full, ShortURL: short})
-		}
-	}
-
-	return toRet, nil
+	//only indexed URLs are checked if it is possible
+	shorts, _ := j.index.candidates(query)
+	return pageURLs(j.users, userID, query, shorts, func(short string) (entities.URL, error) {
+		url := j.Store[short]
+		url.CorrelationID = ""
+		url.UserID = userID
+		return url, nil
+	})
 }
 
 // Ping always returns true.
@@ -86,19 +138,20 @@ func (j *JustAMap) CreateUser(ctx context.Context) (int, error) {
 
 // Save saves a new url to a storage.
 func (j *JustAMap) Save(ctx context.Context, url entities.URL) error {
-	j.Mutex.Lock()
-	defer j.Mutex.Unlock()
-	j.Store[url.ShortURL] = url.OriginalURL
-	return nil
+	return j.SaveBatch(ctx, []entities.URL{url})
 }
 
-// SaveBatch saves a batch of URLs.
+// SaveBatch saves a batch of URLs. Nothing is saved if any short URL is already used.
 func (j *JustAMap) SaveBatch(ctx context.Context, urls []entities.URL) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	err := checkShorts(urls, j.original)
+	if err != nil {
+		return err
+	}
 	for _, url := range urls {
-		err := j.Save(ctx, url)
-		if err != nil {
-			return err
-		}
+		j.put(0, url)
 	}
 	return nil
 }
@@ -107,11 +160,17 @@ func (j *JustAMap) SaveBatch(ctx context.Context, urls []entities.URL) error {
 func (j *JustAMap) Get(ctx context.Context, key string) (toRet string, err error) {
 	j.Mutex.RLock()
 	defer j.Mutex.RUnlock()
-	toRet, ok := j.Store[key]
+	url, ok := j.Store[key]
 	if !ok {
-		err = fmt.Errorf("key doesnt exist")
+		return "", fmt.Errorf("key doesnt exist")
 	}
-	return toRet, err
+	if url.IsExpiredAt(time.Now()) {
+		return "", ErrURLExpired()
+	}
+	if url.IsExhausted() {
+		return "", ErrClicksLimitReached()
+	}
+	return url.OriginalURL, nil
 }
 
 // GetUsersCount returns the total number of users in the database.
@@ -126,3 +185,282 @@ func (j *JustAMap) GetShortURLCount(ctx context.Context) (int, error) {
 	defer j.Mutex.Unlock()
 	return len(j.Store), nil
 }
+
+// DeleteExpired removes all URLs witch expired before given time. Returns an amount of removed URLs.
+func (j *JustAMap) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int, error) {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	deleted := 0
+	for short, url := range j.Store {
+		if url.ExpiresAt != nil && url.ExpiresAt.Before(expiredBefore) {
+			j.index.remove(url)
+			j.users.remove(short)
+			delete(j.Store, short)
+			delete(j.UserStore, short)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// UseClick decreases clicks left of a URL if it has a clicks limit.
+// Returns ErrClicksLimitReached if there are no clicks left.
+func (j *JustAMap) UseClick(ctx context.Context, short string) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	url, ok := j.Store[short]
+	if !ok {
+		return fmt.Errorf("key doesnt exist")
+	}
+	if url.ClicksLeft == nil {
+		return nil
+	}
+	if *url.ClicksLeft <= 0 {
+		return ErrClicksLimitReached()
+	}
+
+	//new value is allocated, so URLs returned earlier are not changed
+	clicksLeft := *url.ClicksLeft - 1
+	url.ClicksLeft = &clicksLeft
+	j.Store[short] = url
+	return nil
+}
+
+// GetURL returns a URL with all its fields using it`s short version.
+// Returns ErrURLNotFound if there is no such URL.
+func (j *JustAMap) GetURL(ctx context.Context, short string) (entities.URL, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	url, ok := j.Store[short]
+	if !ok {
+		return entities.URL{}, ErrURLNotFound()
+	}
+	url.CorrelationID = ""
+	url.UserID = j.UserStore[short]
+	return url, nil
+}
+
+// SaveClicks saves a batch of clicks.
+func (j *JustAMap) SaveClicks(ctx context.Context, clicks []entities.Click) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	j.ClickStore = append(j.ClickStore, clicks...)
+	return nil
+}
+
+// GetURLStats returns a statistics of a short URL clicks.
+func (j *JustAMap) GetURLStats(ctx context.Context, short string, topReferrers int) (entities.URLStats, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	return aggregateClicks(short, j.ClickStore, topReferrers), nil
+}
+
+// UpdateURL changes a URL. Only an owner can change it.
+// Returns ErrURLNotFound if user has no such URL (or it was deleted)
+// and AlreadyExistsError if other short URL already has a new original URL.
+func (j *JustAMap) UpdateURL(ctx context.Context, userID int, short string, update entities.URLUpdate) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	url, ok := j.Store[short]
+	if !ok || url.IsDeleted || j.UserStore[short] != userID {
+		return ErrURLNotFound()
+	}
+	if update.OriginalURL != nil {
+		for otherShort, otherURL := range j.Store {
+			if otherShort != short && otherURL.OriginalURL == *update.OriginalURL {
+				return NewAlreadyExistsError(otherShort)
+			}
+		}
+	}
+
+	j.index.remove(url)
+	applyURLUpdate(&url, update, time.Now())
+	j.Store[short] = url
+	j.index.add(url)
+	return nil
+}
+
+// CreateAccount saves a new account. Returns ErrLoginTaken if a login is already used.
+func (j *JustAMap) CreateAccount(ctx context.Context, account entities.Account) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	if _, ok := j.AccountStore[account.Login]; ok {
+		return ErrLoginTaken()
+	}
+	j.AccountStore[account.Login] = account
+	return nil
+}
+
+// GetAccount returns an account by its login. Returns ErrAccountNotFound if there is no such login.
+func (j *JustAMap) GetAccount(ctx context.Context, login string) (entities.Account, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	account, ok := j.AccountStore[login]
+	if !ok {
+		return entities.Account{}, ErrAccountNotFound()
+	}
+	return account, nil
+}
+
+// ClaimURLs moves all URLs of a user without an account to another user. Returns an amount of moved URLs.
+func (j *JustAMap) ClaimURLs(ctx context.Context, fromUserID int, toUserID int) (int, error) {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	for _, account := range j.AccountStore {
+		if account.UserID == fromUserID {
+			return 0, nil
+		}
+	}
+	claimed := 0
+	for short, userID := range j.UserStore {
+		if userID == fromUserID {
+			j.UserStore[short] = toUserID
+			j.users.add(toUserID, j.Store[short])
+			claimed++
+		}
+	}
+	return claimed, nil
+}
+
+// SaveAPIKey saves a new API key.
+func (j *JustAMap) SaveAPIKey(ctx context.Context, key entities.APIKey) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	j.APIKeyStore[key.KeyHash] = key
+	return nil
+}
+
+// GetAPIKey returns an API key by its hash. Returns ErrAPIKeyNotFound if there is no such key.
+func (j *JustAMap) GetAPIKey(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	key, ok := j.APIKeyStore[keyHash]
+	if !ok {
+		return entities.APIKey{}, ErrAPIKeyNotFound()
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys returns all API keys of a user sorted by creation time.
+func (j *JustAMap) GetUserAPIKeys(ctx context.Context, userID int) ([]entities.APIKey, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	keys := make([]entities.APIKey, 0)
+	for _, key := range j.APIKeyStore {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sortAPIKeys(keys)
+	return keys, nil
+}
+
+// DeleteAPIKey deletes an API key of a user. Returns ErrAPIKeyNotFound if user has no such key.
+func (j *JustAMap) DeleteAPIKey(ctx context.Context, userID int, id string) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	for hash, key := range j.APIKeyStore {
+		if key.ID == id && key.UserID == userID {
+			delete(j.APIKeyStore, hash)
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound()
+}
+
+// SetAPIKeyLastUsed saves a time of the last usage of an API key.
+func (j *JustAMap) SetAPIKeyLastUsed(ctx context.Context, id string, lastUsed time.Time) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	for hash, key := range j.APIKeyStore {
+		if key.ID == id {
+			key.LastUsedAt = &lastUsed
+			j.APIKeyStore[hash] = key
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound()
+}
+
+// CreateSession saves a new session.
+func (j *JustAMap) CreateSession(ctx context.Context, session entities.Session) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	j.SessionStore[session.ID] = session
+	return nil
+}
+
+// GetSession returns a session by its ID (even if it was revoked or has expired).
+// Returns ErrSessionNotFound if there is no such session.
+func (j *JustAMap) GetSession(ctx context.Context, id string) (entities.Session, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	session, ok := j.SessionStore[id]
+	if !ok {
+		return entities.Session{}, ErrSessionNotFound()
+	}
+	return session, nil
+}
+
+// ExtendSession sets a new expiration time of a session. Returns ErrSessionNotFound if there is no such session
+// or it was revoked.
+func (j *JustAMap) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	session, ok := j.SessionStore[id]
+	if !ok || session.RevokedAt != nil {
+		return ErrSessionNotFound()
+	}
+	session.ExpiresAt = expiresAt
+	j.SessionStore[id] = session
+	return nil
+}
+
+// RevokeSession marks a session as revoked. Returns ErrSessionNotFound if there is no such session.
+func (j *JustAMap) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	session, ok := j.SessionStore[id]
+	if !ok {
+		return ErrSessionNotFound()
+	}
+	if session.RevokedAt == nil {
+		session.RevokedAt = &revokedAt
+		j.SessionStore[id] = session
+	}
+	return nil
+}
+
+// DeleteEndedSessions removes all sessions witch expired or were revoked before given time.
+// Returns an amount of removed sessions.
+func (j *JustAMap) DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (int, error) {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	deleted := 0
+	for id, session := range j.SessionStore {
+		if session.EndedBefore(endedBefore) {
+			delete(j.SessionStore, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
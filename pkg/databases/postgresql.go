@@ -3,9 +3,16 @@ package databases
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -39,6 +46,112 @@ func NewPostgresql(connStr string) (*Postgresql, error) {
 		return nil, fmt.Errorf("postgres exec (create urls_table): %w", err)
 	}
 
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+	CREATE INDEX IF NOT EXISTS user_urls_table_expires_at_idx ON user_urls_table (expires_at) WHERE expires_at IS NOT NULL;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add expires_at): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS max_clicks INT NOT NULL DEFAULT 0;
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS clicks_left INT;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add clicks limit): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255) NOT NULL DEFAULT '';
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add password_hash): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	CREATE TABLE IF NOT EXISTS clicks (
+		id BIGSERIAL PRIMARY KEY,
+		short VARCHAR(255) NOT NULL,
+		clicked_at TIMESTAMPTZ NOT NULL,
+		referrer VARCHAR(2048) NOT NULL DEFAULT '',
+		user_agent_class VARCHAR(32) NOT NULL DEFAULT '',
+		ip_hash VARCHAR(64) NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS clicks_short_idx ON clicks (short, clicked_at);
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create clicks): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add timestamps): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS title VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+	CREATE INDEX IF NOT EXISTS user_urls_table_tags_idx ON user_urls_table USING GIN (tags);
+	CREATE INDEX IF NOT EXISTS user_urls_table_title_idx ON user_urls_table USING GIN (to_tsvector('simple', title));
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add titles and tags): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS redirect_code INT NOT NULL DEFAULT 0;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add redirect_code): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS pass_query BOOLEAN;
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS utm TEXT NOT NULL DEFAULT '';
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add query params): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS rules JSONB NOT NULL DEFAULT '[]';
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add redirect rules): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '[]';
+	ALTER TABLE user_urls_table ADD COLUMN IF NOT EXISTS sticky_variants BOOLEAN NOT NULL DEFAULT false;
+	ALTER TABLE clicks ADD COLUMN IF NOT EXISTS variant TEXT NOT NULL DEFAULT '';
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (add variants): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	CREATE INDEX IF NOT EXISTS user_urls_table_user_id_idx ON user_urls_table (user_id, id);
+	CREATE INDEX IF NOT EXISTS user_urls_table_user_short_idx ON user_urls_table (user_id, short);
+	CREATE INDEX IF NOT EXISTS user_urls_table_user_created_idx ON user_urls_table (user_id, created_at, short);
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create user urls indexes): %w", err)
+	}
+
+	//an edited URL keeps its short URL, so a short URL of a new URL can be already taken
+	_, err = toRet.store.Exec(`
+	CREATE UNIQUE INDEX IF NOT EXISTS ` + shortUniqueIndex + ` ON user_urls_table (short);
+`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create unique short index, duplicated short URLs have to be removed first): %w", err)
+	}
+
 	_, err = toRet.store.Exec(`
 	   CREATE TABLE IF NOT EXISTS users (
 	       id SERIAL PRIMARY KEY
@@ -47,15 +160,222 @@ func NewPostgresql(connStr string) (*Postgresql, error) {
 		return nil, fmt.Errorf("postgres exec (create users): %w", err)
 	}
 
+	_, err = toRet.store.Exec(`
+	CREATE TABLE IF NOT EXISTS accounts (
+		user_id INT PRIMARY KEY REFERENCES users (id),
+		login VARCHAR(64) NOT NULL UNIQUE,
+		password_hash VARCHAR(255) NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create accounts): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(32) PRIMARY KEY,
+		user_id INT NOT NULL,
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		key_hash VARCHAR(64) NOT NULL UNIQUE,
+		scopes TEXT[] NOT NULL DEFAULT '{}',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create api_keys): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	CREATE TABLE IF NOT EXISTS sessions (
+		id VARCHAR(32) PRIMARY KEY,
+		user_id INT NOT NULL,
+		refresh_token_hash VARCHAR(64) NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		revoked_at TIMESTAMPTZ
+	);`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create sessions): %w", err)
+	}
+
+	_, err = toRet.store.Exec(`
+	CREATE INDEX IF NOT EXISTS sessions_expires_at_idx ON sessions (expires_at);
+	CREATE INDEX IF NOT EXISTS sessions_revoked_at_idx ON sessions (revoked_at) WHERE revoked_at IS NOT NULL;`)
+	if err != nil {
+		return nil, fmt.Errorf("postgres exec (create sessions indexes): %w", err)
+	}
+
 	return toRet, nil
 }
 
+// urlColumns are columns of user_urls_table witch are filled from entities.URL on insert.
+// Their order must match with urlValues. Timestamps are set by the database.
+var urlColumns = []string{"long", "short", "expires_at", "max_clicks", "clicks_left", "password_hash", "title", "notes", "tags", "redirect_code", "pass_query", "utm", "rules",
+	"variants", "sticky_variants"}
+
+// urlValues returns values of urlColumns for given URL.
+func urlValues(url entities.URL) []any {
+	return []any{url.OriginalURL, url.ShortURL, url.ExpiresAt, url.MaxClicks, url.ClicksLeft, url.PasswordHash,
+		url.Title, url.Notes, tagsValue(url.Tags), url.RedirectCode, url.PassQuery, utmValue(url.UTM),
+		rulesValue(url.Rules), variantsValue(url.Variants), url.StickyVariants}
+}
+
+// tagsValue returns a not nil slice of tags (tags column is NOT NULL).
+func tagsValue(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// rulesValue encodes redirect rules as a JSON array.
+func rulesValue(rules []entities.RedirectRule) string {
+	if len(rules) == 0 {
+		return "[]"
+	}
+	encoded, err := json.Marshal(rules)
+	if err != nil {
+		//rules contain only strings, so it can`t happen
+		return "[]"
+	}
+	return string(encoded)
+}
+
+// variantsValue encodes variants as a JSON array.
+func variantsValue(variants []entities.Variant) string {
+	if len(variants) == 0 {
+		return "[]"
+	}
+	encoded, err := json.Marshal(variants)
+	if err != nil {
+		//variants contain only strings and ints, so it can`t happen
+		return "[]"
+	}
+	return string(encoded)
+}
+
+// utmValue encodes UTM params as a query string.
+func utmValue(utm map[string]string) string {
+	values := url.Values{}
+	for key, value := range utm {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// parseUTM decodes UTM params encoded by utmValue.
+func parseUTM(encoded string) (map[string]string, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(encoded)
+	if err != nil {
+		return nil, err
+	}
+	utm := make(map[string]string, len(values))
+	for key := range values {
+		utm[key] = values.Get(key)
+	}
+	return utm, nil
+}
+
+// urlSelectColumns are columns of user_urls_table witch are read by scanURL.
+// Tags are read as a JSON array.
+const urlSelectColumns = "long, short, expires_at, max_clicks, clicks_left, password_hash, is_deleted, user_id, created_at, updated_at, deleted_at, " +
+	"title, notes, array_to_json(tags), redirect_code, pass_query, utm, rules, variants, sticky_variants"
+
+// rowScanner is a *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL reads urlSelectColumns from a row to a URL.
+func scanURL(row rowScanner) (entities.URL, error) {
+	var url entities.URL
+	var expiresAt sql.NullTime
+	var clicksLeft sql.NullInt64
+	var userID sql.NullInt64
+	var createdAt, updatedAt time.Time
+	var deletedAt sql.NullTime
+	var tags []byte
+	var passQuery sql.NullBool
+	var utm string
+	var rules []byte
+	var variants []byte
+	err := row.Scan(&url.OriginalURL, &url.ShortURL, &expiresAt, &url.MaxClicks, &clicksLeft, &url.PasswordHash, &url.IsDeleted, &userID,
+		&createdAt, &updatedAt, &deletedAt, &url.Title, &url.Notes, &tags, &url.RedirectCode, &passQuery, &utm, &rules,
+		&variants, &url.StickyVariants)
+	if err != nil {
+		return entities.URL{}, err
+	}
+	err = json.Unmarshal(tags, &url.Tags)
+	if err != nil {
+		return entities.URL{}, fmt.Errorf("can`t parse tags: %w", err)
+	}
+	if len(url.Tags) == 0 {
+		url.Tags = nil
+	}
+	err = json.Unmarshal(rules, &url.Rules)
+	if err != nil {
+		return entities.URL{}, fmt.Errorf("can`t parse rules: %w", err)
+	}
+	if len(url.Rules) == 0 {
+		url.Rules = nil
+	}
+	err = json.Unmarshal(variants, &url.Variants)
+	if err != nil {
+		return entities.URL{}, fmt.Errorf("can`t parse variants: %w", err)
+	}
+	if len(url.Variants) == 0 {
+		url.Variants = nil
+	}
+	url.UTM, err = parseUTM(utm)
+	if err != nil {
+		return entities.URL{}, fmt.Errorf("can`t parse utm: %w", err)
+	}
+	if passQuery.Valid {
+		url.PassQuery = &passQuery.Bool
+	}
+	url.UserID = int(userID.Int64)
+	url.CreatedAt = &createdAt
+	url.UpdatedAt = &updatedAt
+	if deletedAt.Valid {
+		url.DeletedAt = &deletedAt.Time
+	}
+	if expiresAt.Valid {
+		url.ExpiresAt = &expiresAt.Time
+	}
+	if clicksLeft.Valid {
+		left := int(clicksLeft.Int64)
+		url.ClicksLeft = &left
+	}
+	return url, nil
+}
+
+// insertURLQuery builds an INSERT query (without ";") for urlColumns and user_id (if withUserID is true).
+// Use urlValues (and userID after them) as query args.
+func insertURLQuery(withUserID bool) string {
+	columns := urlColumns
+	if withUserID {
+		columns = append(columns[:len(columns):len(columns)], "user_id")
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	return "INSERT INTO user_urls_table (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+}
+
 // Save saves a new url to a storage.
 func (p *Postgresql) Save(ctx context.Context, url entities.URL) error {
-	query := "INSERT INTO user_urls_table (long, short) VALUES ($1, $2) ON CONFLICT (long) DO NOTHING;"
+	query := insertURLQuery(false) + " ON CONFLICT (long) DO NOTHING;"
 
-	result, err := p.store.ExecContext(ctx, query, url.OriginalURL, url.ShortURL)
-	if err != nil {
+	result, err := p.store.ExecContext(ctx, query, urlValues(url)...)
+	if isShortConflict(err) {
+		return NewShortURLTakenError(url.ShortURL)
+	} else if err != nil {
 		return fmt.Errorf("postgres execute: %w", err)
 	}
 
@@ -81,10 +401,12 @@ func (p *Postgresql) Save(ctx context.Context, url entities.URL) error {
 
 // SaveWithUserID saves a URL with userID.
 func (p *Postgresql) SaveWithUserID(ctx context.Context, userID int, url entities.URL) error {
-	query := "INSERT INTO user_urls_table (user_id, long, short) VALUES ($1, $2, $3) ON CONFLICT (long) DO NOTHING;"
+	query := insertURLQuery(true) + " ON CONFLICT (long) DO NOTHING;"
 
-	result, err := p.store.ExecContext(ctx, query, userID, url.OriginalURL, url.ShortURL)
-	if err != nil {
+	result, err := p.store.ExecContext(ctx, query, append(urlValues(url), userID)...)
+	if isShortConflict(err) {
+		return NewShortURLTakenError(url.ShortURL)
+	} else if err != nil {
 		return fmt.Errorf("postgres execute: %w", err)
 	}
 
@@ -113,12 +435,16 @@ func (p *Postgresql) SaveBatch(ctx context.Context, urls []entities.URL) error {
 	if err != nil {
 		return fmt.Errorf("postgres transaction start: %w", err)
 	}
-	query := "INSERT INTO user_urls_table (long, short) VALUES ($1, $2);"
+	query := insertURLQuery(false) + ";"
 
 	for _, url := range urls {
-		_, err = tx.ExecContext(ctx, query, url.OriginalURL, url.ShortURL)
-		if err != nil {
+		_, err = tx.ExecContext(ctx, query, urlValues(url)...)
+		if isShortConflict(err) {
 			tx.Rollback()
+			return NewShortURLTakenError(url.ShortURL)
+		} else if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("postgres, transaction error: %w", err)
 		}
 	}
 
@@ -136,11 +462,14 @@ func (p *Postgresql) SaveBatchWithUserID(ctx context.Context, userID int, urls [
 	if err != nil {
 		return fmt.Errorf("postgres transaction start: %w", err)
 	}
-	query := "INSERT INTO user_urls_table (user_id, long, short) VALUES ($1, $2, $3);"
+	query := insertURLQuery(true) + ";"
 
 	for _, url := range urls {
-		_, err = tx.ExecContext(ctx, query, userID, url.OriginalURL, url.ShortURL)
-		if err != nil {
+		_, err = tx.ExecContext(ctx, query, append(urlValues(url), userID)...)
+		if isShortConflict(err) {
+			tx.Rollback()
+			return NewShortURLTakenError(url.ShortURL)
+		} else if err != nil {
 			tx.Rollback()
 			return fmt.Errorf("postgres, transaction error: %w", err)
 		}
@@ -154,41 +483,53 @@ func (p *Postgresql) SaveBatchWithUserID(ctx context.Context, userID int, urls [
 	return nil
 }
 
-// DeleteBatchWithUserID deletes a batch of URLs (if their userID matches with given one).
-func (p *Postgresql) DeleteBatchWithUserID(userID int) (urlsChan chan string, err error) {
+// DeleteBatchWithUserID deletes a batch of URLs (if their userID matches with given one) in one transaction.
+// URLs have to be sent to urlsChan, the channel have to be closed by a caller. done gets a result of a transaction.
+func (p *Postgresql) DeleteBatchWithUserID(userID int) (urlsChan chan string, done <-chan error, err error) {
 	tx, err := p.store.BeginTx(context.TODO(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("postgres transaction start: %w", err)
+		return nil, nil, fmt.Errorf("postgres transaction start: %w", err)
 	}
 
-	query := "UPDATE user_urls_table SET is_deleted = true WHERE short = $1 AND user_id = $2;"
+	query := "UPDATE user_urls_table SET is_deleted = true, deleted_at = now() WHERE short = $1 AND user_id = $2 AND is_deleted = false;"
 
+	urlsChan = make(chan string)
+	result := make(chan error, 1)
 	go func() {
+		defer close(result)
+		var errLocal error
 		for url := range urlsChan {
-			_, errLocal := tx.Exec(query, url, userID)
+			//the channel is read till the end, so a caller is never blocked
 			if errLocal != nil {
-				tx.Rollback()
-				return
+				continue
 			}
+			_, errLocal = tx.Exec(query, url, userID)
 		}
-		if errLocal := tx.Commit(); errLocal != nil {
+		if errLocal != nil {
 			tx.Rollback()
+			result <- fmt.Errorf("postgres delete urls: %w", errLocal)
 			return
 		}
+		if errLocal = tx.Commit(); errLocal != nil {
+			result <- fmt.Errorf("postgres transaction commit: %w", errLocal)
+			return
+		}
+		result <- nil
 	}()
 
-	urlsChan = make(chan string)
-	return urlsChan, nil
+	return urlsChan, result, nil
 }
 
 // Get returns an original URL using it`s short version.
 func (p *Postgresql) Get(ctx context.Context, short string) (full string, err error) {
 
-	query := "SELECT long, is_deleted  FROM user_urls_table WHERE short = $1;"
+	query := "SELECT long, is_deleted, expires_at, clicks_left FROM user_urls_table WHERE short = $1;"
 	row := p.store.QueryRowContext(ctx, query, short)
 
 	var isDeleted bool
-	err = row.Scan(&full, &isDeleted)
+	var expiresAt sql.NullTime
+	var clicksLeft sql.NullInt64
+	err = row.Scan(&full, &isDeleted, &expiresAt, &clicksLeft)
 
 	if err != nil {
 		return "", fmt.Errorf("postgres query: %w", err)
@@ -196,24 +537,73 @@ func (p *Postgresql) Get(ctx context.Context, short string) (full string, err er
 	if isDeleted {
 		return "", ErrURLWasDeleted()
 	}
+	if expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		return "", ErrURLExpired()
+	}
+	if clicksLeft.Valid && clicksLeft.Int64 <= 0 {
+		return "", ErrClicksLimitReached()
+	}
 	return full, nil
 }
 
-// GetUserUrls returns all URLs of a user.
-func (p *Postgresql) GetUserUrls(ctx context.Context, userID int) ([]entities.URL, error) {
-	query := "SELECT long, short FROM user_urls_table WHERE user_id = $1;"
+// GetUserUrls returns one page of user`s URLs.
+func (p *Postgresql) GetUserUrls(ctx context.Context, userID int, query entities.URLsPageQuery) ([]entities.URL, error) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	addArg := func(arg interface{}) string {
+		args = append(args, arg)
+		return "$" + strconv.Itoa(len(args))
+	}
 
-	var urls []entities.URL
+	//filters
+	if !query.IncludeDeleted {
+		conditions = append(conditions, "is_deleted = false")
+	}
+	if query.OriginalContains != "" {
+		conditions = append(conditions, "strpos(long, "+addArg(query.OriginalContains)+") > 0")
+	}
+	if query.Tag != "" {
+		conditions = append(conditions, "tags @> ARRAY["+addArg(query.Tag)+"]::text[]")
+	}
+	if query.TitleQuery != "" {
+		conditions = append(conditions, "to_tsvector('simple', title) @@ plainto_tsquery('simple', "+addArg(query.TitleQuery)+")")
+	}
 
-	rows, err := p.store.QueryContext(ctx, query, userID)
+	//cursor and sorting, short URLs are unique, so they order URLs created at the same time
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	orderBy := "created_at " + direction + ", short " + direction
+	if query.SortBy == entities.SortByShort {
+		orderBy = "short " + direction
+	}
+	if query.After != nil {
+		if query.SortBy == entities.SortByShort {
+			conditions = append(conditions, "short "+comparison+" "+addArg(query.After.ShortURL))
+		} else {
+			conditions = append(conditions, "(created_at, short) "+comparison+" ("+addArg(query.After.CreatedAt)+", "+addArg(query.After.ShortURL)+")")
+		}
+	}
+
+	sqlQuery := "SELECT " + urlSelectColumns + " FROM user_urls_table WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + orderBy
+	if query.Limit > 0 {
+		sqlQuery += " LIMIT " + addArg(query.Limit)
+	}
+	sqlQuery += ";"
+
+	urls := make([]entities.URL, 0)
+
+	rows, err := p.store.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("postgres query: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var url entities.URL
-		if err := rows.Scan(&url.OriginalURL, &url.ShortURL); err != nil {
+		url, err := scanURL(rows)
+		if err != nil {
 			return nil, fmt.Errorf("postgres row scan: %w", err)
 		}
 		urls = append(urls, url)
@@ -277,3 +667,460 @@ func (p *Postgresql) GetShortURLCount(ctx context.Context) (int, error) {
 
 	return urlCount, nil
 }
+
+// DeleteExpired removes all URLs witch expired before given time. Returns an amount of removed URLs.
+func (p *Postgresql) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int, error) {
+	query := "DELETE FROM user_urls_table WHERE expires_at IS NOT NULL AND expires_at < $1;"
+
+	result, err := p.store.ExecContext(ctx, query, expiredBefore)
+	if err != nil {
+		return 0, fmt.Errorf("postgres delete expired: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("postgres delete expired rows affected: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// UseClick decreases clicks left of a URL if it has a clicks limit.
+// Returns ErrClicksLimitReached if there are no clicks left.
+// Decreasing is done by one conditional UPDATE, so concurrent clicks can`t go over the limit.
+func (p *Postgresql) UseClick(ctx context.Context, short string) error {
+	query := "UPDATE user_urls_table SET clicks_left = clicks_left - 1 WHERE short = $1 AND clicks_left > 0;"
+	result, err := p.store.ExecContext(ctx, query, short)
+	if err != nil {
+		return fmt.Errorf("postgres use click: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("postgres use click rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	//nothing was updated, so URL has no limit or no clicks left
+	var clicksLeft sql.NullInt64
+	query = "SELECT clicks_left FROM user_urls_table WHERE short = $1;"
+	err = p.store.QueryRowContext(ctx, query, short).Scan(&clicksLeft)
+	if err != nil {
+		return fmt.Errorf("postgres query: %w", err)
+	}
+	if clicksLeft.Valid {
+		return ErrClicksLimitReached()
+	}
+	return nil
+}
+
+// GetURL returns a URL with all its fields using it`s short version.
+// Returns ErrURLNotFound if there is no such URL.
+func (p *Postgresql) GetURL(ctx context.Context, short string) (entities.URL, error) {
+	query := "SELECT " + urlSelectColumns + " FROM user_urls_table WHERE short = $1;"
+	url, err := scanURL(p.store.QueryRowContext(ctx, query, short))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.URL{}, ErrURLNotFound()
+	} else if err != nil {
+		return entities.URL{}, fmt.Errorf("postgres query: %w", err)
+	}
+	return url, nil
+}
+
+// SaveClicks saves a batch of clicks in one transaction.
+func (p *Postgresql) SaveClicks(ctx context.Context, clicks []entities.Click) error {
+	tx, err := p.store.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres transaction start: %w", err)
+	}
+	query := "INSERT INTO clicks (short, clicked_at, referrer, user_agent_class, ip_hash, variant) VALUES ($1, $2, $3, $4, $5, $6);"
+
+	for _, click := range clicks {
+		_, err = tx.ExecContext(ctx, query, click.ShortURL, click.Time, click.Referrer, click.UserAgentClass, click.IPHash, click.Variant)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("postgres, transaction error: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("postgres, transaction commit: %w", err)
+	}
+	return nil
+}
+
+// GetURLStats returns a statistics of a short URL clicks. All aggregation is done by the database.
+func (p *Postgresql) GetURLStats(ctx context.Context, short string, topReferrers int) (entities.URLStats, error) {
+	stats := entities.URLStats{
+		ShortURL:     short,
+		ClicksPerDay: make([]entities.DayClicks, 0),
+		TopReferrers: make([]entities.ReferrerClicks, 0),
+		Variants:     make([]entities.VariantClicks, 0),
+	}
+
+	//totals
+	query := "SELECT COUNT(*), COUNT(DISTINCT NULLIF(ip_hash, '')) FROM clicks WHERE short = $1;"
+	err := p.store.QueryRowContext(ctx, query, short).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+	if err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres get clicks totals: %w", err)
+	}
+
+	//clicks per day
+	query = `SELECT to_char(clicked_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*) FROM clicks
+		WHERE short = $1 GROUP BY day ORDER BY day;`
+	rows, err := p.store.QueryContext(ctx, query, short)
+	if err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres get clicks per day: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day entities.DayClicks
+		if err := rows.Scan(&day.Day, &day.Clicks); err != nil {
+			return entities.URLStats{}, fmt.Errorf("postgres row scan: %w", err)
+		}
+		stats.ClicksPerDay = append(stats.ClicksPerDay, day)
+	}
+	if err := rows.Err(); err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres rows iteration: %w", err)
+	}
+
+	//top referrers
+	query = `SELECT COALESCE(NULLIF(referrer, ''), $2) AS ref, COUNT(*) AS amount FROM clicks
+		WHERE short = $1 GROUP BY ref ORDER BY amount DESC, ref LIMIT $3;`
+	refRows, err := p.store.QueryContext(ctx, query, short, entities.DirectReferrer, topReferrers)
+	if err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres get top referrers: %w", err)
+	}
+	defer refRows.Close()
+	for refRows.Next() {
+		var referrer entities.ReferrerClicks
+		if err := refRows.Scan(&referrer.Referrer, &referrer.Clicks); err != nil {
+			return entities.URLStats{}, fmt.Errorf("postgres row scan: %w", err)
+		}
+		stats.TopReferrers = append(stats.TopReferrers, referrer)
+	}
+	if err := refRows.Err(); err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres rows iteration: %w", err)
+	}
+
+	//variants
+	query = `SELECT variant, COUNT(*), COUNT(DISTINCT NULLIF(ip_hash, '')) FROM clicks
+		WHERE short = $1 AND variant <> '' GROUP BY variant ORDER BY variant;`
+	variantRows, err := p.store.QueryContext(ctx, query, short)
+	if err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres get variants clicks: %w", err)
+	}
+	defer variantRows.Close()
+	for variantRows.Next() {
+		var variant entities.VariantClicks
+		if err := variantRows.Scan(&variant.Variant, &variant.Clicks, &variant.UniqueVisitors); err != nil {
+			return entities.URLStats{}, fmt.Errorf("postgres row scan: %w", err)
+		}
+		stats.Variants = append(stats.Variants, variant)
+	}
+	if err := variantRows.Err(); err != nil {
+		return entities.URLStats{}, fmt.Errorf("postgres rows iteration: %w", err)
+	}
+
+	return stats, nil
+}
+
+// uniqueViolationCode is a postgres error code of a unique constraint violation.
+const uniqueViolationCode = "23505"
+
+// shortUniqueIndex is a name of a unique index of short URLs.
+const shortUniqueIndex = "user_urls_table_short_key"
+
+// isShortConflict returns true if an error is a violation of shortUniqueIndex.
+func isShortConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == shortUniqueIndex
+}
+
+// UpdateURL changes a URL. Only an owner can change it.
+// Returns ErrURLNotFound if user has no such URL (or it was deleted)
+// and AlreadyExistsError if other short URL already has a new original URL.
+func (p *Postgresql) UpdateURL(ctx context.Context, userID int, short string, update entities.URLUpdate) error {
+	sets := []string{"updated_at = now()"}
+	args := []interface{}{short, userID}
+	addSet := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if update.OriginalURL != nil {
+		addSet("long", *update.OriginalURL)
+	}
+	if update.Title != nil {
+		addSet("title", *update.Title)
+	}
+	if update.Notes != nil {
+		addSet("notes", *update.Notes)
+	}
+	if update.Tags != nil {
+		addSet("tags", tagsValue(*update.Tags))
+	}
+	if update.Rules != nil {
+		addSet("rules", rulesValue(*update.Rules))
+	}
+	if update.Variants != nil {
+		addSet("variants", variantsValue(*update.Variants))
+	}
+	if update.StickyVariants != nil {
+		addSet("sticky_variants", *update.StickyVariants)
+	}
+
+	query := "UPDATE user_urls_table SET " + strings.Join(sets, ", ") + " WHERE short = $1 AND user_id = $2 AND is_deleted = false;"
+	result, err := p.store.ExecContext(ctx, query, args...)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && update.OriginalURL != nil {
+		shortURL := ""
+		row := p.store.QueryRowContext(ctx, "SELECT short FROM user_urls_table WHERE long = $1;", *update.OriginalURL)
+		err = row.Scan(&shortURL)
+		if err != nil {
+			return fmt.Errorf("postgres query: %w", err)
+		}
+		return NewAlreadyExistsError(shortURL)
+	} else if err != nil {
+		return fmt.Errorf("postgres execute: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrURLNotFound()
+	}
+	return nil
+}
+
+// CreateAccount saves a new account. Returns ErrLoginTaken if a login is already used.
+func (p *Postgresql) CreateAccount(ctx context.Context, account entities.Account) error {
+	query := "INSERT INTO accounts (user_id, login, password_hash, created_at) VALUES ($1, $2, $3, $4);"
+
+	_, err := p.store.ExecContext(ctx, query, account.UserID, account.Login, account.PasswordHash, account.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return ErrLoginTaken()
+	} else if err != nil {
+		return fmt.Errorf("postgres create account: %w", err)
+	}
+	return nil
+}
+
+// GetAccount returns an account by its login. Returns ErrAccountNotFound if there is no such login.
+func (p *Postgresql) GetAccount(ctx context.Context, login string) (entities.Account, error) {
+	query := "SELECT user_id, login, password_hash, created_at FROM accounts WHERE login = $1;"
+
+	account := entities.Account{}
+	err := p.store.QueryRowContext(ctx, query, login).Scan(&account.UserID, &account.Login, &account.PasswordHash, &account.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Account{}, ErrAccountNotFound()
+	} else if err != nil {
+		return entities.Account{}, fmt.Errorf("postgres get account: %w", err)
+	}
+	return account, nil
+}
+
+// ClaimURLs moves all URLs of a user without an account to another user. Returns an amount of moved URLs.
+func (p *Postgresql) ClaimURLs(ctx context.Context, fromUserID int, toUserID int) (int, error) {
+	query := `UPDATE user_urls_table SET user_id = $2
+	WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM accounts WHERE user_id = $1);`
+
+	result, err := p.store.ExecContext(ctx, query, fromUserID, toUserID)
+	if err != nil {
+		return 0, fmt.Errorf("postgres claim urls: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(claimed), nil
+}
+
+// apiKeySelectColumns are columns of api_keys witch are read by scanAPIKey.
+const apiKeySelectColumns = "id, user_id, name, key_hash, array_to_json(scopes), created_at, expires_at, last_used_at"
+
+// scanAPIKey reads apiKeySelectColumns from a row to an API key.
+func scanAPIKey(row rowScanner) (entities.APIKey, error) {
+	var key entities.APIKey
+	var scopes []byte
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.KeyHash, &scopes, &key.CreatedAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return entities.APIKey{}, err
+	}
+	err = json.Unmarshal(scopes, &key.Scopes)
+	if err != nil {
+		return entities.APIKey{}, fmt.Errorf("can`t parse scopes: %w", err)
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return key, nil
+}
+
+// SaveAPIKey saves a new API key.
+func (p *Postgresql) SaveAPIKey(ctx context.Context, key entities.APIKey) error {
+	query := "INSERT INTO api_keys (id, user_id, name, key_hash, scopes, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7);"
+
+	_, err := p.store.ExecContext(ctx, query, key.ID, key.UserID, key.Name, key.KeyHash, tagsValue(key.Scopes), key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("postgres save api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey returns an API key by its hash. Returns ErrAPIKeyNotFound if there is no such key.
+func (p *Postgresql) GetAPIKey(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	query := "SELECT " + apiKeySelectColumns + " FROM api_keys WHERE key_hash = $1;"
+
+	key, err := scanAPIKey(p.store.QueryRowContext(ctx, query, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.APIKey{}, ErrAPIKeyNotFound()
+	} else if err != nil {
+		return entities.APIKey{}, fmt.Errorf("postgres get api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys returns all API keys of a user sorted by creation time.
+func (p *Postgresql) GetUserAPIKeys(ctx context.Context, userID int) ([]entities.APIKey, error) {
+	query := "SELECT " + apiKeySelectColumns + " FROM api_keys WHERE user_id = $1 ORDER BY created_at, id;"
+
+	rows, err := p.store.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("postgres get user api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]entities.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("postgres scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres rows: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey deletes an API key of a user. Returns ErrAPIKeyNotFound if user has no such key.
+func (p *Postgresql) DeleteAPIKey(ctx context.Context, userID int, id string) error {
+	query := "DELETE FROM api_keys WHERE id = $1 AND user_id = $2;"
+
+	result, err := p.store.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("postgres delete api key: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAPIKeyNotFound()
+	}
+	return nil
+}
+
+// SetAPIKeyLastUsed saves a time of the last usage of an API key.
+func (p *Postgresql) SetAPIKeyLastUsed(ctx context.Context, id string, lastUsed time.Time) error {
+	query := "UPDATE api_keys SET last_used_at = $2 WHERE id = $1;"
+
+	_, err := p.store.ExecContext(ctx, query, id, lastUsed)
+	if err != nil {
+		return fmt.Errorf("postgres set api key last used: %w", err)
+	}
+	return nil
+}
+
+// CreateSession saves a new session.
+func (p *Postgresql) CreateSession(ctx context.Context, session entities.Session) error {
+	query := "INSERT INTO sessions (id, user_id, refresh_token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5);"
+
+	_, err := p.store.ExecContext(ctx, query, session.ID, session.UserID, session.RefreshTokenHash, session.CreatedAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("postgres create session: %w", err)
+	}
+	return nil
+}
+
+// GetSession returns a session by its ID (even if it was revoked or has expired).
+// Returns ErrSessionNotFound if there is no such session.
+func (p *Postgresql) GetSession(ctx context.Context, id string) (entities.Session, error) {
+	query := "SELECT id, user_id, refresh_token_hash, created_at, expires_at, revoked_at FROM sessions WHERE id = $1;"
+
+	var session entities.Session
+	var revokedAt sql.NullTime
+	err := p.store.QueryRowContext(ctx, query, id).Scan(&session.ID, &session.UserID, &session.RefreshTokenHash,
+		&session.CreatedAt, &session.ExpiresAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Session{}, ErrSessionNotFound()
+	} else if err != nil {
+		return entities.Session{}, fmt.Errorf("postgres get session: %w", err)
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
+
+// ExtendSession sets a new expiration time of a session. Returns ErrSessionNotFound if there is no such session
+// or it was revoked.
+func (p *Postgresql) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	query := "UPDATE sessions SET expires_at = $2 WHERE id = $1 AND revoked_at IS NULL;"
+
+	result, err := p.store.ExecContext(ctx, query, id, expiresAt)
+	if err != nil {
+		return fmt.Errorf("postgres extend session: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrSessionNotFound()
+	}
+	return nil
+}
+
+// RevokeSession marks a session as revoked. Returns ErrSessionNotFound if there is no such session.
+func (p *Postgresql) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	query := "UPDATE sessions SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1;"
+
+	result, err := p.store.ExecContext(ctx, query, id, revokedAt)
+	if err != nil {
+		return fmt.Errorf("postgres revoke session: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrSessionNotFound()
+	}
+	return nil
+}
+
+// DeleteEndedSessions removes all sessions witch expired or were revoked before given time.
+// Returns an amount of removed sessions.
+func (p *Postgresql) DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (int, error) {
+	query := "DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $1;"
+
+	result, err := p.store.ExecContext(ctx, query, endedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("postgres delete ended sessions: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
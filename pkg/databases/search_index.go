@@ -0,0 +1,94 @@
+package databases
+
+import (
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// searchIndex is an in-memory index of short URLs by tags and by words of titles.
+// It is not thread-safe, storages have to lock it by themselves.
+type searchIndex struct {
+	tags  map[string]map[string]struct{}
+	words map[string]map[string]struct{}
+}
+
+// newSearchIndex builds an empty searchIndex.
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		tags:  make(map[string]map[string]struct{}),
+		words: make(map[string]map[string]struct{}),
+	}
+}
+
+// add adds a URL to an index.
+func (s *searchIndex) add(url entities.URL) {
+	for _, tag := range url.Tags {
+		addToSet(s.tags, tag, url.ShortURL)
+	}
+	for _, word := range entities.Words(url.Title) {
+		addToSet(s.words, word, url.ShortURL)
+	}
+}
+
+// remove removes a URL from an index. URL have to be the same as it was added.
+func (s *searchIndex) remove(url entities.URL) {
+	for _, tag := range url.Tags {
+		removeFromSet(s.tags, tag, url.ShortURL)
+	}
+	for _, word := range entities.Words(url.Title) {
+		removeFromSet(s.words, word, url.ShortURL)
+	}
+}
+
+// candidates returns short URLs witch can match a query.
+// ok is false if a query has no tag and no title query (an index can`t help in this case).
+// Candidates still have to be checked with pageURLs.
+func (s *searchIndex) candidates(query entities.URLsPageQuery) (shorts map[string]struct{}, ok bool) {
+	sets := make([]map[string]struct{}, 0)
+	if query.Tag != "" {
+		sets = append(sets, s.tags[query.Tag])
+	}
+	for _, word := range entities.Words(query.TitleQuery) {
+		sets = append(sets, s.words[word])
+	}
+	if len(sets) == 0 {
+		return nil, false
+	}
+
+	//intersection of all sets
+	shorts = make(map[string]struct{})
+	for short := range sets[0] {
+		inAll := true
+		for _, set := range sets[1:] {
+			if _, found := set[short]; !found {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			shorts[short] = struct{}{}
+		}
+	}
+	return shorts, true
+}
+
+// addToSet adds a value to a set by a key.
+func addToSet(sets map[string]map[string]struct{}, key string, value string) {
+	set, ok := sets[key]
+	if !ok {
+		set = make(map[string]struct{})
+		sets[key] = set
+	}
+	set[value] = struct{}{}
+}
+
+// removeFromSet removes a value from a set by a key.
+func removeFromSet(sets map[string]map[string]struct{}, key string, value string) {
+	set, ok := sets[key]
+	if !ok {
+		return
+	}
+	delete(set, value)
+	if len(set) == 0 {
+		delete(sets, key)
+	}
+}
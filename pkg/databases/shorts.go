@@ -0,0 +1,23 @@
+package databases
+
+import "github.com/Lesnoi3283/url_shortener/internal/app/entities"
+
+// checkShorts checks that short URLs of new URLs are free. original returns an original URL saved with a short URL.
+// Returns an AlreadyExistsError if a short URL is used by the same original URL
+// and a ShortURLTakenError if it is used by another original URL (including other URLs of the same batch).
+func checkShorts(urls []entities.URL, original func(short string) (string, bool)) error {
+	batch := make(map[string]string, len(urls))
+	for _, url := range urls {
+		if saved, ok := original(url.ShortURL); ok {
+			if saved == url.OriginalURL {
+				return NewAlreadyExistsError(url.ShortURL)
+			}
+			return NewShortURLTakenError(url.ShortURL)
+		}
+		if saved, ok := batch[url.ShortURL]; ok && saved != url.OriginalURL {
+			return NewShortURLTakenError(url.ShortURL)
+		}
+		batch[url.ShortURL] = url.OriginalURL
+	}
+	return nil
+}
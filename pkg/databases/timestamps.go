@@ -0,0 +1,45 @@
+package databases
+
+import (
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// setCreationTime sets CreatedAt and UpdatedAt of a new URL, times set by a caller are kept.
+func setCreationTime(url *entities.URL, now time.Time) {
+	now = now.UTC()
+	if url.CreatedAt == nil {
+		url.CreatedAt = &now
+	}
+	if url.UpdatedAt == nil {
+		url.UpdatedAt = url.CreatedAt
+	}
+}
+
+// applyURLUpdate copies not nil fields of an update to a URL and sets its UpdatedAt.
+func applyURLUpdate(url *entities.URL, update entities.URLUpdate, now time.Time) {
+	now = now.UTC()
+	if update.OriginalURL != nil {
+		url.OriginalURL = *update.OriginalURL
+	}
+	if update.Title != nil {
+		url.Title = *update.Title
+	}
+	if update.Notes != nil {
+		url.Notes = *update.Notes
+	}
+	if update.Tags != nil {
+		url.Tags = *update.Tags
+	}
+	if update.Rules != nil {
+		url.Rules = *update.Rules
+	}
+	if update.Variants != nil {
+		url.Variants = *update.Variants
+	}
+	if update.StickyVariants != nil {
+		url.StickyVariants = *update.StickyVariants
+	}
+	url.UpdatedAt = &now
+}
@@ -0,0 +1,233 @@
+package databases
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+)
+
+// userURL is a position of a URL in user`s URLs listings.
+type userURL struct {
+	createdAt time.Time
+	short     string
+}
+
+// newUserURL returns a position of a URL. URLs without a creation time go first.
+func newUserURL(url entities.URL) userURL {
+	position := userURL{short: url.ShortURL}
+	if url.CreatedAt != nil {
+		position.createdAt = *url.CreatedAt
+	}
+	return position
+}
+
+// createdLess sorts URLs by a creation time, short URLs are unique, so they order URLs created at the same time.
+func createdLess(a, b userURL) bool {
+	if !a.createdAt.Equal(b.createdAt) {
+		return a.createdAt.Before(b.createdAt)
+	}
+	return a.short < b.short
+}
+
+// shortLess sorts URLs by short URLs.
+func shortLess(a, b userURL) bool {
+	return a.short < b.short
+}
+
+// ownedURL is a position of a URL and its owner.
+type ownedURL struct {
+	userID   int
+	position userURL
+}
+
+// userURLsIndex keeps URLs of every user sorted by a creation time and by short URLs,
+// so a page of user`s URLs starts with a binary search by a cursor instead of a scan of all URLs.
+// It is not thread-safe, storages have to lock it by themselves.
+type userURLsIndex struct {
+	byCreated map[int][]userURL
+	byShort   map[int][]userURL
+	urls      map[string]ownedURL
+}
+
+// newUserURLsIndex builds an empty userURLsIndex.
+func newUserURLsIndex() *userURLsIndex {
+	return &userURLsIndex{
+		byCreated: make(map[int][]userURL),
+		byShort:   make(map[int][]userURL),
+		urls:      make(map[string]ownedURL),
+	}
+}
+
+// add adds a URL of a user to an index. An old position of a URL is replaced.
+func (u *userURLsIndex) add(userID int, url entities.URL) {
+	u.remove(url.ShortURL)
+	position := newUserURL(url)
+	u.byCreated[userID] = insertPosition(u.byCreated[userID], position, createdLess)
+	u.byShort[userID] = insertPosition(u.byShort[userID], position, shortLess)
+	u.urls[url.ShortURL] = ownedURL{userID: userID, position: position}
+}
+
+// remove removes a URL from an index.
+func (u *userURLsIndex) remove(short string) {
+	owned, ok := u.urls[short]
+	if !ok {
+		return
+	}
+	delete(u.urls, short)
+	u.byCreated[owned.userID] = removePosition(u.byCreated[owned.userID], owned.position, createdLess)
+	u.byShort[owned.userID] = removePosition(u.byShort[owned.userID], owned.position, shortLess)
+	if len(u.byCreated[owned.userID]) == 0 {
+		delete(u.byCreated, owned.userID)
+		delete(u.byShort, owned.userID)
+	}
+}
+
+// walk calls fn for short URLs of a user in an order of a query, starting after query.After.
+// It stops if fn returns false.
+func (u *userURLsIndex) walk(userID int, query entities.URLsPageQuery, fn func(short string) bool) {
+	positions := u.byCreated[userID]
+	if query.SortBy == entities.SortByShort {
+		positions = u.byShort[userID]
+	}
+	walkPositions(positions, query, fn)
+}
+
+// walkShorts works like walk, but only for given short URLs.
+// It sorts them, so it is faster than walk only if there are few of them.
+func (u *userURLsIndex) walkShorts(userID int, shorts map[string]struct{}, query entities.URLsPageQuery, fn func(short string) bool) {
+	less := sortLess(query)
+	positions := make([]userURL, 0, len(shorts))
+	for short := range shorts {
+		if owned, ok := u.urls[short]; ok && owned.userID == userID {
+			positions = append(positions, owned.position)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool { return less(positions[i], positions[j]) })
+	walkPositions(positions, query, fn)
+}
+
+// sortLess returns a less func of a sorting of a query.
+func sortLess(query entities.URLsPageQuery) func(a, b userURL) bool {
+	if query.SortBy == entities.SortByShort {
+		return shortLess
+	}
+	return createdLess
+}
+
+// walkPositions calls fn for positions sorted by a query, starting after query.After. It stops if fn returns false.
+func walkPositions(positions []userURL, query entities.URLsPageQuery, fn func(short string) bool) {
+	less := sortLess(query)
+	var after userURL
+	if query.After != nil {
+		after = userURL{createdAt: query.After.CreatedAt, short: query.After.ShortURL}
+	}
+
+	if !query.Descending {
+		start := 0
+		if query.After != nil {
+			start = sort.Search(len(positions), func(i int) bool { return less(after, positions[i]) })
+		}
+		for i := start; i < len(positions); i++ {
+			if !fn(positions[i].short) {
+				return
+			}
+		}
+		return
+	}
+
+	end := len(positions)
+	if query.After != nil {
+		end = sort.Search(len(positions), func(i int) bool { return !less(positions[i], after) })
+	}
+	for i := end - 1; i >= 0; i-- {
+		if !fn(positions[i].short) {
+			return
+		}
+	}
+}
+
+// insertPosition inserts a position into a sorted slice.
+func insertPosition(positions []userURL, position userURL, less func(a, b userURL) bool) []userURL {
+	i := sort.Search(len(positions), func(i int) bool { return !less(positions[i], position) })
+	positions = append(positions, userURL{})
+	copy(positions[i+1:], positions[i:])
+	positions[i] = position
+	return positions
+}
+
+// removePosition removes a position from a sorted slice.
+func removePosition(positions []userURL, position userURL, less func(a, b userURL) bool) []userURL {
+	i := sort.Search(len(positions), func(i int) bool { return !less(positions[i], position) })
+	if i < len(positions) && positions[i].short == position.short {
+		positions = append(positions[:i], positions[i+1:]...)
+	}
+	return positions
+}
+
+// pageURLs reads a page of user`s URLs: it walks URLs of a user from a cursor and keeps URLs witch match a query
+// until a page is full. If shorts is not nil, only URLs from it are read (see searchIndex.candidates),
+// they are walked instead of all user`s URLs if there are fewer of them.
+// get returns a URL by its short version.
+func pageURLs(index *userURLsIndex, userID int, query entities.URLsPageQuery, shorts map[string]struct{}, get func(short string) (entities.URL, error)) ([]entities.URL, error) {
+	toRet := make([]entities.URL, 0)
+	var err error
+	walk := func(fn func(short string) bool) {
+		index.walk(userID, query, fn)
+	}
+	if shorts != nil && len(shorts) < len(index.byCreated[userID]) {
+		walk = func(fn func(short string) bool) {
+			index.walkShorts(userID, shorts, query, fn)
+		}
+	}
+	walk(func(short string) bool {
+		if _, ok := shorts[short]; shorts != nil && !ok {
+			return true
+		}
+		var url entities.URL
+		url, err = get(short)
+		if err != nil {
+			return false
+		}
+		if matchesQuery(url, query) {
+			toRet = append(toRet, url)
+		}
+		return query.Limit <= 0 || len(toRet) < query.Limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toRet, nil
+}
+
+// matchesQuery returns true if a URL passes all filters of a query.
+func matchesQuery(url entities.URL, query entities.URLsPageQuery) bool {
+	if url.IsDeleted && !query.IncludeDeleted {
+		return false
+	}
+	if query.OriginalContains != "" && !strings.Contains(url.OriginalURL, query.OriginalContains) {
+		return false
+	}
+	if query.Tag != "" && !url.HasTag(query.Tag) {
+		return false
+	}
+	if query.TitleQuery != "" && !titleMatches(url.Title, query.TitleQuery) {
+		return false
+	}
+	return true
+}
+
+// titleMatches returns true if a title has all words of a query.
+func titleMatches(title string, query string) bool {
+	titleWords := make(map[string]struct{})
+	for _, word := range entities.Words(title) {
+		titleWords[word] = struct{}{}
+	}
+	for _, word := range entities.Words(query) {
+		if _, ok := titleWords[word]; !ok {
+			return false
+		}
+	}
+	return true
+}
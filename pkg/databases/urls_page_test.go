@@ -0,0 +1,136 @@
+package databases
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lesnoi3283/url_shortener/internal/app/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagesStorage is a part of a storage interface used by user`s URLs listings.
+type pagesStorage interface {
+	SaveWithUserID(ctx context.Context, userID int, url entities.URL) error
+	GetUserUrls(ctx context.Context, userID int, query entities.URLsPageQuery) ([]entities.URL, error)
+	DeleteExpired(ctx context.Context, expiredBefore time.Time) (int, error)
+	UpdateURL(ctx context.Context, userID int, short string, update entities.URLUpdate) error
+}
+
+// pagesStorages builds every storage witch reads pages of user`s URLs by itself.
+var pagesStorages = map[string]func(t *testing.T) pagesStorage{
+	"map": func(t *testing.T) pagesStorage {
+		return NewJustAMap()
+	},
+	"JSON": func(t *testing.T) pagesStorage {
+		return NewJSONFileStorage(filepath.Join(t.TempDir(), "urls.json"))
+	},
+}
+
+// shortsOf returns short URLs of URLs.
+func shortsOf(urls []entities.URL) []string {
+	shorts := make([]string, 0, len(urls))
+	for _, url := range urls {
+		shorts = append(shorts, url.ShortURL)
+	}
+	return shorts
+}
+
+func TestGetUserUrls_Cursor(t *testing.T) {
+	userID, otherUserID := 1, 2
+	expiresAt := time.Now().Add(time.Hour)
+
+	cursorOf := func(url entities.URL) *entities.URLsCursor {
+		return &entities.URLsCursor{CreatedAt: *url.CreatedAt, ShortURL: url.ShortURL}
+	}
+
+	for name, newStorage := range pagesStorages {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := newStorage(t)
+			for _, short := range []string{"c", "a", "d", "b", "e"} {
+				url := entities.URL{ShortURL: short, OriginalURL: "http://" + short + ".example.com"}
+				if short == "a" || short == "b" {
+					url.ExpiresAt = &expiresAt
+				}
+				require.NoError(t, storage.SaveWithUserID(ctx, userID, url), "error while preparing a storage")
+			}
+			err := storage.SaveWithUserID(ctx, otherUserID, entities.URL{ShortURL: "other", OriginalURL: "http://other.example.com"})
+			require.NoError(t, err, "error while preparing a storage")
+
+			created, err := storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Limit: 2})
+			require.NoError(t, err)
+			require.Equal(t, []string{"c", "a"}, shortsOf(created))
+			byShort, err := storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Limit: 2, SortBy: entities.SortByShort})
+			require.NoError(t, err)
+			require.Equal(t, []string{"a", "b"}, shortsOf(byShort))
+			descending, err := storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Limit: 2, Descending: true})
+			require.NoError(t, err)
+			require.Equal(t, []string{"e", "b"}, shortsOf(descending))
+
+			//last URLs of pages are deleted, but next pages are still found
+			deleted, err := storage.DeleteExpired(ctx, expiresAt.Add(time.Minute))
+			require.NoError(t, err)
+			require.Equal(t, 2, deleted)
+
+			next, err := storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Limit: 2, After: cursorOf(created[1])})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"d", "e"}, shortsOf(next))
+			next, err = storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Limit: 2, SortBy: entities.SortByShort, After: cursorOf(byShort[1])})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"c", "d"}, shortsOf(next))
+			next, err = storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Limit: 2, Descending: true, After: cursorOf(descending[1])})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"d", "c"}, shortsOf(next))
+		})
+	}
+}
+
+func TestGetUserUrls_Search(t *testing.T) {
+	userID, otherUserID := 1, 2
+
+	for name, newStorage := range pagesStorages {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := newStorage(t)
+			save := func(userID int, short string, tags ...string) {
+				url := entities.URL{ShortURL: short, OriginalURL: "http://" + short + ".example.com", Tags: tags}
+				require.NoError(t, storage.SaveWithUserID(ctx, userID, url), "error while preparing a storage")
+			}
+			for _, short := range []string{"a", "b", "c", "d", "e", "f"} {
+				save(userID, short)
+			}
+			save(userID, "go1", "go")
+			save(userID, "go2", "go", "news")
+			save(otherUserID, "other", "go")
+
+			tagged, err := storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Tag: "go"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"go1", "go2"}, shortsOf(tagged))
+
+			//an index is changed by updates and new URLs
+			tags := []string{"go"}
+			require.NoError(t, storage.UpdateURL(ctx, userID, "b", entities.URLUpdate{Tags: &tags}))
+			require.NoError(t, storage.UpdateURL(ctx, userID, "go1", entities.URLUpdate{Tags: &[]string{}}))
+			save(userID, "go3", "go")
+
+			tagged, err = storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Tag: "go", Limit: 2})
+			require.NoError(t, err)
+			require.Equal(t, []string{"b", "go2"}, shortsOf(tagged))
+			last := tagged[1]
+			tagged, err = storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{
+				Tag:   "go",
+				Limit: 2,
+				After: &entities.URLsCursor{CreatedAt: *last.CreatedAt, ShortURL: last.ShortURL},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"go3"}, shortsOf(tagged))
+
+			tagged, err = storage.GetUserUrls(ctx, userID, entities.URLsPageQuery{Tag: "go", SortBy: entities.SortByShort, Descending: true})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"go3", "go2", "b"}, shortsOf(tagged))
+		})
+	}
+}
@@ -1,48 +1,83 @@
 package secure
 
 import (
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"sync"
 	"time"
 )
 
-// Claims is a jwt.RegisteredClaims struct with custom field "Claims.UserID".
+// Claims is a jwt.RegisteredClaims struct with custom fields "Claims.UserID" and "Claims.SessionID".
+// SessionID is empty for tokens without a server-side session.
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int
+	UserID    int
+	SessionID string `json:",omitempty"`
 }
 
 // JWTHelper helps you to work with JWT tokens. It allows you to create and parse tokens.
+// It has a keyset: tokens are signed by a current signing key and can be verified by any key of a keyset
+// (a key is chosen by a "kid" header), so older keys can be kept for verification after a rotation.
 type JWTHelper struct {
-	Claims    Claims
-	secretKey string
-	TokenExp  time.Duration
-	m         sync.RWMutex
+	Claims     Claims
+	signingKey JWTKey
+	keys       map[string]JWTKey
+	TokenExp   time.Duration
+	m          sync.RWMutex
 }
 
-// NewJWTHelper creates a new JWTHelper.
+// NewJWTHelper creates a new JWTHelper with a single HS256 key.
 func NewJWTHelper(secretKey string, tokenTimeoutHours int) *JWTHelper {
+	key := NewHMACKey(secretKey)
 	return &JWTHelper{
-		secretKey: secretKey,
-		TokenExp:  time.Duration(tokenTimeoutHours) * time.Hour,
+		signingKey: key,
+		keys:       map[string]JWTKey{key.KID: key},
+		TokenExp:   time.Duration(tokenTimeoutHours) * time.Hour,
 	}
 }
 
-// BuildNewJWTString returns new JWT string with userID inside.
-func (j *JWTHelper) BuildNewJWTString(userID int) (string, error) {
+// NewJWTHelperWithKeys creates a new JWTHelper with a keyset.
+// signingKey signs new tokens, verificationKeys only verify tokens signed earlier.
+func NewJWTHelperWithKeys(signingKey JWTKey, verificationKeys []JWTKey, tokenTimeoutHours int) (*JWTHelper, error) {
+	if !signingKey.CanSign() {
+		return nil, fmt.Errorf("key `%s` can`t sign tokens, a private key is required", signingKey.KID)
+	}
+	keys := map[string]JWTKey{signingKey.KID: signingKey}
+	for _, key := range verificationKeys {
+		if _, ok := keys[key.KID]; ok {
+			return nil, fmt.Errorf("key `%s` was added twice", key.KID)
+		}
+		keys[key.KID] = key.VerificationOnly()
+	}
+	return &JWTHelper{
+		signingKey: signingKey,
+		keys:       keys,
+		TokenExp:   time.Duration(tokenTimeoutHours) * time.Hour,
+	}, nil
+}
+
+// SigningKID returns a kid of a current signing key.
+func (j *JWTHelper) SigningKID() string {
+	return j.signingKey.KID
+}
+
+// BuildSessionJWTString returns new JWT string with userID and sessionID inside.
+func (j *JWTHelper) BuildSessionJWTString(userID int, sessionID string) (string, error) {
 	j.m.Lock()
 	defer j.m.Unlock()
 
 	claims := Claims{RegisteredClaims: jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.TokenExp)),
 	},
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(j.signingKey.Method, claims)
+	token.Header["kid"] = j.signingKey.KID
 
-	stringToken, err := token.SignedString([]byte(j.secretKey))
+	stringToken, err := token.SignedString(j.signingKey.signKey)
 	if err != nil {
 		return "", fmt.Errorf("building new jwt: %w", err)
 	}
@@ -52,21 +87,69 @@ func (j *JWTHelper) BuildNewJWTString(userID int) (string, error) {
 
 // GetUserID parses JWT and returns a userID from it.
 func (j *JWTHelper) GetUserID(tokenString string) (int, error) {
+	claims, err := j.GetClaims(tokenString)
+	if err != nil {
+		return -1, err
+	}
+	return claims.UserID, nil
+}
+
+// GetClaims parses JWT and returns its claims.
+// Tokens without a "kid" header (they were built before keysets) are checked by all HS256 keys.
+func (j *JWTHelper) GetClaims(tokenString string) (Claims, error) {
 	j.m.Lock()
 	defer j.m.Unlock()
 
+	kid, err := tokenKID(tokenString)
+	if err != nil {
+		return Claims{}, fmt.Errorf("parsing token: %w", err)
+	}
+	if kid != "" {
+		key, ok := j.keys[kid]
+		if !ok {
+			return Claims{}, NewErrTokenIsNotValid()
+		}
+		return parseWithKey(tokenString, key)
+	}
+
+	for _, key := range j.keys {
+		if key.Method != jwt.SigningMethodHS256 {
+			continue
+		}
+		claims, err := parseWithKey(tokenString, key)
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			continue
+		}
+		return claims, err
+	}
+	return Claims{}, NewErrTokenIsNotValid()
+}
+
+// tokenKID returns a "kid" header of a token without its verifying.
+func tokenKID(tokenString string) (string, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return "", err
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid, nil
+}
+
+// parseWithKey parses and verifies a token with a key and returns its claims.
+// A token has to be signed by a method of a key.
+func parseWithKey(tokenString string, key JWTKey) (Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims,
 		func(t *jwt.Token) (interface{}, error) {
-			return []byte(j.secretKey), nil
-		})
+			return key.verifyKey, nil
+		}, jwt.WithValidMethods([]string{key.Method.Alg()}))
 	if err != nil {
-		return -1, fmt.Errorf("parsing token: %w", err)
+		return Claims{}, fmt.Errorf("parsing token: %w", err)
 	}
 
 	if !token.Valid {
-		return -1, NewErrTokenIsNotValid()
+		return Claims{}, NewErrTokenIsNotValid()
 	}
 
-	return claims.UserID, nil
+	return *claims, nil
 }
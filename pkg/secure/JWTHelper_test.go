@@ -0,0 +1,183 @@
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// edKey generates an Ed25519 key and returns its private and public keys in PEM format.
+func edKey(t *testing.T) (private []byte, public []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "error while generating a key")
+	return pemBlocks(t, priv, pub)
+}
+
+// rsaKey generates an RSA key and returns its private and public keys in PEM format.
+func rsaKey(t *testing.T, bits int) (private []byte, public []byte) {
+	priv, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err, "error while generating a key")
+	return pemBlocks(t, priv, &priv.PublicKey)
+}
+
+func pemBlocks(t *testing.T, priv interface{}, pub interface{}) ([]byte, []byte) {
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err, "error while encoding a key")
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err, "error while encoding a key")
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+}
+
+func TestJWTHelper_Rotation(t *testing.T) {
+	userID := 7
+	sessionID := "session"
+
+	t.Run("old HMAC secret", func(t *testing.T) {
+		old := NewJWTHelper("old secret", 1)
+		token, err := old.BuildSessionJWTString(userID, sessionID)
+		require.NoError(t, err)
+
+		rotated, err := NewJWTHelperWithKeys(NewHMACKey("new secret"), []JWTKey{NewHMACKey("old secret")}, 1)
+		require.NoError(t, err)
+		claims, err := rotated.GetClaims(token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+		assert.Equal(t, sessionID, claims.SessionID)
+
+		//new tokens are signed by a new key
+		newToken, err := rotated.BuildSessionJWTString(userID, sessionID)
+		require.NoError(t, err)
+		_, err = old.GetClaims(newToken)
+		assert.Error(t, err)
+		kid, err := tokenKID(newToken)
+		require.NoError(t, err)
+		assert.Equal(t, NewHMACKey("new secret").KID, kid)
+	})
+
+	t.Run("old PEM key", func(t *testing.T) {
+		oldPrivate, oldPublic := edKey(t)
+		newPrivate, _ := rsaKey(t, 2048)
+		oldKey, err := ParsePEMKey(oldPrivate)
+		require.NoError(t, err)
+		old, err := NewJWTHelperWithKeys(oldKey, nil, 1)
+		require.NoError(t, err)
+		token, err := old.BuildSessionJWTString(userID, sessionID)
+		require.NoError(t, err)
+
+		//an old key is kept as a public key file
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "old.pem"), oldPublic, 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "new.pem"), newPrivate, 0600))
+		oldPublicKey, err := LoadPEMKey(filepath.Join(dir, "old.pem"))
+		require.NoError(t, err)
+		assert.False(t, oldPublicKey.CanSign())
+		assert.Equal(t, oldKey.KID, oldPublicKey.KID, "private and public keys have to have the same kid")
+		newKey, err := LoadPEMKey(filepath.Join(dir, "new.pem"))
+		require.NoError(t, err)
+
+		rotated, err := NewJWTHelperWithKeys(newKey, []JWTKey{oldPublicKey, NewHMACKey("old secret")}, 1)
+		require.NoError(t, err)
+		claims, err := rotated.GetClaims(token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+		assert.Equal(t, sessionID, claims.SessionID)
+		assert.Equal(t, newKey.KID, rotated.SigningKID())
+	})
+
+	t.Run("public key can`t sign", func(t *testing.T) {
+		_, public := edKey(t)
+		key, err := ParsePEMKey(public)
+		require.NoError(t, err)
+		_, err = NewJWTHelperWithKeys(key, nil, 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestJWTHelper_GetClaims(t *testing.T) {
+	private, _ := edKey(t)
+	signingKey, err := ParsePEMKey(private)
+	require.NoError(t, err)
+	helper, err := NewJWTHelperWithKeys(signingKey, []JWTKey{NewHMACKey("old secret")}, 1)
+	require.NoError(t, err)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		UserID:           7,
+	}
+
+	t.Run("unknown kid", func(t *testing.T) {
+		token, err := NewJWTHelper("other secret", 1).BuildSessionJWTString(7, "session")
+		require.NoError(t, err)
+		_, err = helper.GetClaims(token)
+		assert.ErrorIs(t, err, NewErrTokenIsNotValid())
+	})
+
+	t.Run("alg of a token doesn`t match a key", func(t *testing.T) {
+		//HS256 token signed by a public key of an EdDSA key (everyone knows it)
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = signingKey.KID
+		public, err := x509.MarshalPKIXPublicKey(signingKey.verifyKey)
+		require.NoError(t, err)
+		signed, err := token.SignedString(public)
+		require.NoError(t, err)
+
+		_, err = helper.GetClaims(signed)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("legacy token without kid", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("old secret"))
+		require.NoError(t, err)
+		got, err := helper.GetClaims(signed)
+		require.NoError(t, err)
+		assert.Equal(t, 7, got.UserID)
+
+		signed, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("other secret"))
+		require.NoError(t, err)
+		_, err = helper.GetClaims(signed)
+		assert.ErrorIs(t, err, NewErrTokenIsNotValid())
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := NewJWTHelper("old secret", 1)
+		expired.TokenExp = -time.Minute
+		token, err := expired.BuildSessionJWTString(7, "session")
+		require.NoError(t, err)
+		_, err = helper.GetClaims(token)
+		assert.Error(t, err)
+	})
+}
+
+func TestParsePEMKey(t *testing.T) {
+	t.Run("short RSA key", func(t *testing.T) {
+		private, public := rsaKey(t, 1024)
+		_, err := ParsePEMKey(private)
+		assert.Error(t, err)
+		_, err = ParsePEMKey(public)
+		assert.Error(t, err)
+	})
+
+	t.Run("not a key", func(t *testing.T) {
+		_, err := ParsePEMKey([]byte("not a key"))
+		assert.Error(t, err)
+	})
+
+	t.Run("RS256 key", func(t *testing.T) {
+		private, _ := rsaKey(t, 2048)
+		key, err := ParsePEMKey(private)
+		require.NoError(t, err)
+		assert.Equal(t, jwt.SigningMethodRS256, key.Method)
+		assert.True(t, key.CanSign())
+		assert.False(t, key.VerificationOnly().CanSign())
+	})
+}
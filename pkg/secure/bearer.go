@@ -0,0 +1,17 @@
+package secure
+
+import "strings"
+
+// BearerScheme is an authorization scheme of JWT tokens ("Authorization: Bearer <token>").
+const BearerScheme = "Bearer"
+
+// BearerToken returns a token from an "Authorization" header value like "Bearer <token>".
+// Returns false if a value has another scheme or has no token.
+func BearerToken(authorization string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !found || !strings.EqualFold(scheme, BearerScheme) {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
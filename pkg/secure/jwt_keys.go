@@ -0,0 +1,88 @@
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWTKey is a key of a JWTHelper keyset. It is identified by KID (a "kid" header of tokens).
+// HS256 keys are built by NewHMACKey, EdDSA and RS256 keys are loaded from PEM files by LoadPEMKey.
+// Keys without a private part (public keys and keys returned by VerificationOnly) can only verify tokens.
+type JWTKey struct {
+	KID       string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey returns an HS256 key.
+// Its KID is a beginning of a secret`s SHA-256 hash, so it changes with a secret and doesn`t have to be configured.
+func NewHMACKey(secret string) JWTKey {
+	hash := sha256.Sum256([]byte(secret))
+	return JWTKey{
+		KID:       "hs-" + hex.EncodeToString(hash[:8]),
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// LoadPEMKey reads a key from a PEM file. See ParsePEMKey.
+func LoadPEMKey(path string) (JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JWTKey{}, fmt.Errorf("reading key file: %w", err)
+	}
+	key, err := ParsePEMKey(data)
+	if err != nil {
+		return JWTKey{}, fmt.Errorf("key file `%s`: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParsePEMKey parses an Ed25519 (EdDSA) or RSA (RS256) key from PEM data.
+// A private key can sign and verify tokens, a public key can only verify them.
+// KID of a key is a beginning of a SHA-256 hash of its public part, so a private key and its public key have the same KID.
+func ParsePEMKey(data []byte) (JWTKey, error) {
+	var key JWTKey
+	if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		key = JWTKey{Method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.(ed25519.PrivateKey).Public()}
+	} else if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		key = JWTKey{Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+	} else if public, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		key = JWTKey{Method: jwt.SigningMethodEdDSA, verifyKey: public}
+	} else if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		key = JWTKey{Method: jwt.SigningMethodRS256, verifyKey: public}
+	} else {
+		return JWTKey{}, fmt.Errorf("not an Ed25519 or RSA key in PEM format")
+	}
+
+	if rsaKey, ok := key.verifyKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return JWTKey{}, fmt.Errorf("RSA key has to be at least 2048 bits long, got %d", rsaKey.N.BitLen())
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.verifyKey)
+	if err != nil {
+		return JWTKey{}, fmt.Errorf("encoding a public key: %w", err)
+	}
+	hash := sha256.Sum256(der)
+	key.KID = key.Method.Alg() + "-" + hex.EncodeToString(hash[:8])
+	return key, nil
+}
+
+// CanSign returns true if a key can sign tokens.
+func (k JWTKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// VerificationOnly returns a copy of a key that can only verify tokens.
+func (k JWTKey) VerificationOnly() JWTKey {
+	k.signKey = nil
+	return k
+}